@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/urfave/cli/v2"
+	"os"
+	"text/tabwriter"
+)
+
+var organizationFlag = &cli.StringFlag{Name: "organization", Usage: "organization id or slug", Value: entity.DefaultOrganization}
+
+var migrateCommand = &cli.Command{
+	Name:  "migrate",
+	Usage: "create the missing tables, columns and triggers",
+	Action: func(c *cli.Context) error {
+		db, err := repository.OpenPostgresDB(dbConfig())
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if err := repository.Migrate(db); err != nil {
+			return err
+		}
+		fmt.Println("Database is up to date")
+		return nil
+	},
+}
+
+var seedCommand = &cli.Command{
+	Name:  "seed",
+	Usage: "generate demo users, projects and tasks",
+	Flags: []cli.Flag{
+		&cli.IntFlag{Name: "users", Value: 50},
+		&cli.IntFlag{Name: "projects", Value: 10},
+		&cli.IntFlag{Name: "tasks", Value: 1000},
+		organizationFlag,
+	},
+	Action: func(c *cli.Context) error {
+		repo, closeDB, err := openRepository()
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+
+		organization, err := service.NewOrganizationService(repo.Organization).ResolveOrganization(c.Context, c.String("organization"))
+		if err != nil {
+			return err
+		}
+
+		seed := entity.Seed{Users: c.Int("users"), Projects: c.Int("projects"), Tasks: c.Int("tasks")}
+		if err := service.NewMaintenanceService(repo).Seed(c.Context, organization.ID, seed); err != nil {
+			return err
+		}
+		fmt.Printf("Seeded %d users, %d projects and %d tasks into %s\n", seed.Users, seed.Projects, seed.Tasks, organization.Slug)
+		return nil
+	},
+}
+
+var userCommand = &cli.Command{
+	Name:  "user",
+	Usage: "manage users",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "create",
+			Usage: "create a user in an organization",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "name", Required: true},
+				&cli.StringFlag{Name: "email", Required: true},
+				&cli.StringFlag{Name: "role", Usage: "account role", Value: "USER"},
+				&cli.BoolFlag{Name: "admin", Usage: "make the user an admin of the organization"},
+				organizationFlag,
+			},
+			Action: createUser,
+		},
+	},
+}
+
+func createUser(c *cli.Context) error {
+	repo, closeDB, err := openRepository()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	organization, err := service.NewOrganizationService(repo.Organization).ResolveOrganization(c.Context, c.String("organization"))
+	if err != nil {
+		return err
+	}
+
+	user := entity.User{Name: c.String("name"), Email: c.String("email"), Role: c.String("role")}
+	var id string
+	if c.Bool("admin") {
+		if !c.IsSet("role") {
+			user.Role = "ADMIN"
+		}
+		id, err = service.NewMaintenanceService(repo).CreateAdmin(c.Context, organization.ID, user)
+	} else {
+		id, _, err = service.NewUserService(repo.User, repo.Organization).CreateUser(c.Context, organization.ID, user)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(id)
+	return nil
+}
+
+var dbCommand = &cli.Command{
+	Name:  "db",
+	Usage: "inspect the database",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "check",
+			Usage: "report orphans and inconsistencies; exits with 1 when any are found",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "fix", Usage: "repair what was found"},
+			},
+			Action: checkDB,
+		},
+	},
+}
+
+func checkDB(c *cli.Context) error {
+	repo, closeDB, err := openRepository()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	maintenance := service.NewMaintenanceService(repo)
+	inconsistencies, err := maintenance.CheckIntegrity(c.Context)
+	if err != nil {
+		return err
+	}
+	if len(inconsistencies) == 0 {
+		fmt.Println("No inconsistencies found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tID\tDETAIL")
+	for _, inconsistency := range inconsistencies {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", inconsistency.Check, inconsistency.ID, inconsistency.Detail)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !c.Bool("fix") {
+		return cli.Exit(fmt.Sprintf("%d inconsistencies found, run with --fix to repair them", len(inconsistencies)), 1)
+	}
+
+	fixes, err := maintenance.FixIntegrity(c.Context)
+	if err != nil {
+		return err
+	}
+	for _, fix := range fixes {
+		if fix.Fixed > 0 {
+			fmt.Printf("Fixed %d rows for %s\n", fix.Fixed, fix.Check)
+		}
+	}
+	return nil
+}
+
+// openRepository connects to the database without migrating it.
+func openRepository() (*repository.Repository, func(), error) {
+	db, err := repository.OpenPostgresDB(dbConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+	return repository.NewRepository(db), func() { db.Close() }, nil
+}
@@ -1,14 +1,15 @@
 package main
 
 import (
-	"github.com/Aytya/projects-manager-HL"
-	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/logging"
 	"github.com/Aytya/projects-manager-HL/internal/repository"
-	"github.com/Aytya/projects-manager-HL/internal/service"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"github.com/spf13/viper"
+	"github.com/urfave/cli/v2"
 	"log"
+	"log/slog"
+	"os"
 )
 
 // @title Projects-Manager
@@ -17,34 +18,33 @@ import (
 
 // @host localhost:8080
 func main() {
-	if err := initConfig(); err != nil {
-		log.Fatal("Error at initializing config", err)
-	}
-
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error at loading .env file", err)
-	}
+	app := &cli.App{
+		Name:  "projects-manager",
+		Usage: "run and administer the Projects-Manager server",
+		Before: func(c *cli.Context) error {
+			if err := initConfig(); err != nil {
+				log.Fatal("Error at initializing config", err)
+			}
 
-	db, err := repository.NewPostgresDB(repository.Config{
-		Host:     viper.GetString("db.host"),
-		Port:     viper.GetString("db.port"),
-		Username: viper.GetString("db.username"),
-		Database: viper.GetString("db.dbname"),
-		SSLMode:  viper.GetString("db.sslmode"),
-		Password: viper.GetString("db.password"),
-	})
+			if err := godotenv.Load(); err != nil {
+				log.Fatal("Error at loading .env file", err)
+			}
 
-	if err != nil {
-		log.Fatal("Failed at initializing db", err)
+			slog.SetDefault(logging.New(os.Stdout, viper.GetString("log.format"), viper.GetString("log.level")))
+			return nil
+		},
+		Action: serve,
+		Commands: []*cli.Command{
+			serveCommand,
+			migrateCommand,
+			seedCommand,
+			userCommand,
+			dbCommand,
+		},
 	}
 
-	repo := repository.NewRepository(db)
-	services := service.NewService(repo)
-	handlers := handler.NewHandler(services)
-
-	server := new(projects_manager.Server)
-	if err := server.Run(viper.GetString("8080"), handlers.InitRoutes()); err != nil {
-		log.Fatal("Error occured while running http server")
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
 	}
 }
 
@@ -53,3 +53,14 @@ func initConfig() error {
 	viper.SetConfigName("config")
 	return viper.ReadInConfig()
 }
+
+func dbConfig() repository.Config {
+	return repository.Config{
+		Host:     viper.GetString("db.host"),
+		Port:     viper.GetString("db.port"),
+		Username: viper.GetString("db.username"),
+		Database: viper.GetString("db.dbname"),
+		SSLMode:  viper.GetString("db.sslmode"),
+		Password: viper.GetString("db.password"),
+	}
+}
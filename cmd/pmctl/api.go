@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/Aytya/projects-manager-HL/pkg/client"
+	"github.com/urfave/cli/v2"
+	"net/http"
+	"time"
+)
+
+func newClient(c *cli.Context) (*client.Client, config, error) {
+	conf, err := loadConfig(c)
+	if err != nil {
+		return nil, conf, err
+	}
+
+	api, err := client.New(conf.Server,
+		client.WithHTTPClient(&http.Client{Timeout: 30 * time.Second}),
+		client.WithToken(conf.Token),
+		client.WithOrganization(conf.Organization),
+		client.WithUser(conf.User),
+	)
+	return api, conf, err
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"github.com/urfave/cli/v2"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultServer = "http://localhost:8080"
+
+var configKeys = []string{"server", "token", "organization", "user"}
+
+type config struct {
+	Server       string
+	Token        string
+	Organization string
+	User         string
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pmctl", "config.yaml")
+}
+
+func readConfigFile(path string) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	v.SetConfigPermissions(0o600)
+	if path == "" {
+		return v, nil
+	}
+
+	if err := v.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return v, nil
+}
+
+// loadConfig merges the config file with the global flags; flags and their
+// environment variables win.
+func loadConfig(c *cli.Context) (config, error) {
+	v, err := readConfigFile(c.String("config"))
+	if err != nil {
+		return config{}, err
+	}
+
+	value := func(key string) string {
+		if c.IsSet(key) {
+			return c.String(key)
+		}
+		return v.GetString(key)
+	}
+
+	conf := config{
+		Server:       strings.TrimSuffix(value("server"), "/"),
+		Token:        value("token"),
+		Organization: value("organization"),
+		User:         value("user"),
+	}
+	if conf.Server == "" {
+		conf.Server = defaultServer
+	}
+	return conf, nil
+}
+
+var configCommand = &cli.Command{
+	Name:  "config",
+	Usage: "show or change the config file",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "show",
+			Usage: "print the effective configuration",
+			Action: func(c *cli.Context) error {
+				conf, err := loadConfig(c)
+				if err != nil {
+					return err
+				}
+				if conf.Token != "" {
+					conf.Token = "********"
+				}
+
+				return render(c, conf, [][]string{
+					{"server", conf.Server},
+					{"token", conf.Token},
+					{"organization", conf.Organization},
+					{"user", conf.User},
+				}, "KEY", "VALUE")
+			},
+		},
+		{
+			Name:      "set",
+			Usage:     "store a value in the config file",
+			ArgsUsage: "<" + strings.Join(configKeys, "|") + "> <value>",
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 2 {
+					return cli.ShowSubcommandHelp(c)
+				}
+				key := c.Args().Get(0)
+				if !contains(configKeys, key) {
+					return fmt.Errorf("unknown config key %q", key)
+				}
+
+				path := c.String("config")
+				if path == "" {
+					return errors.New("no config file path, pass --config")
+				}
+				v, err := readConfigFile(path)
+				if err != nil {
+					return err
+				}
+				v.Set(key, c.Args().Get(1))
+
+				if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+					return err
+				}
+				return v.WriteConfigAs(path)
+			},
+		},
+	},
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
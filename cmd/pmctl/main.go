@@ -0,0 +1,34 @@
+// Command pmctl manages projects and tasks of a Projects-Manager server from
+// the terminal.
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"os"
+)
+
+func main() {
+	app := &cli.App{
+		Name:  "pmctl",
+		Usage: "manage projects and tasks from the terminal",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "config", Usage: "config file", Value: defaultConfigPath(), EnvVars: []string{"PMCTL_CONFIG"}},
+			&cli.StringFlag{Name: "server", Usage: "server URL", EnvVars: []string{"PMCTL_SERVER"}},
+			&cli.StringFlag{Name: "token", Usage: "bearer token", EnvVars: []string{"PMCTL_TOKEN"}},
+			&cli.StringFlag{Name: "organization", Usage: "organization id or slug", EnvVars: []string{"PMCTL_ORGANIZATION"}},
+			&cli.StringFlag{Name: "user", Usage: "your user id", EnvVars: []string{"PMCTL_USER"}},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "output format: table or json", Value: "table"},
+		},
+		Commands: []*cli.Command{
+			taskCommand,
+			projectCommand,
+			configCommand,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, "pmctl:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// render prints value as JSON or rows as a table, depending on --output.
+func render(c *cli.Context, value interface{}, rows [][]string, header ...string) error {
+	switch c.String("output") {
+	case "json":
+		encoder := json.NewEncoder(c.App.Writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case "table":
+		w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, use table or json", c.String("output"))
+	}
+}
+
+func formatTime(t sql.NullTime) string {
+	if !t.Valid {
+		return "-"
+	}
+	return t.Time.Format(time.DateOnly)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"github.com/Aytya/projects-manager-HL/pkg/client"
+	"github.com/urfave/cli/v2"
+	"time"
+)
+
+var projectCommand = &cli.Command{
+	Name:  "project",
+	Usage: "list and show projects",
+	Subcommands: []*cli.Command{
+		{
+			Name:    "ls",
+			Aliases: []string{"list"},
+			Usage:   "list projects",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "all", Usage: "include archived projects"},
+			},
+			Action: func(c *cli.Context) error {
+				api, _, err := newClient(c)
+				if err != nil {
+					return err
+				}
+
+				projects, err := api.ListProjects(c.Context, client.ListOptions{IncludeArchived: c.Bool("all")})
+				if err != nil {
+					return err
+				}
+
+				rows := make([][]string, 0, len(projects))
+				for _, project := range projects {
+					rows = append(rows, []string{project.ID, project.Title, project.Manager, formatTime(project.FinishedAt)})
+				}
+				return render(c, projects, rows, "ID", "TITLE", "MANAGER", "FINISHED")
+			},
+		},
+		{
+			Name:      "show",
+			Usage:     "show a project with its tasks",
+			ArgsUsage: "<id>",
+			Action:    showProject,
+		},
+	},
+}
+
+func showProject(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.ShowSubcommandHelp(c)
+	}
+	api, _, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	p, err := api.GetProject(c.Context, c.Args().First())
+	if err != nil {
+		return err
+	}
+	tasks, err := api.ListProjectTasks(c.Context, c.Args().First(), client.TaskQuery{})
+	if err != nil {
+		return err
+	}
+
+	if c.String("output") == "json" {
+		return render(c, struct {
+			client.Project
+			Tasks []client.Task `json:"tasks"`
+		}{p, tasks}, nil)
+	}
+
+	if err := render(c, nil, [][]string{
+		{"id", p.ID},
+		{"title", p.Title},
+		{"description", p.Description},
+		{"manager", p.Manager},
+		{"created", p.CreatedAt.Format(time.DateOnly)},
+		{"finished", formatTime(p.FinishedAt)},
+	}, "PROJECT", ""); err != nil {
+		return err
+	}
+	c.App.Writer.Write([]byte("\n"))
+	return renderTasks(c, tasks, tasks)
+}
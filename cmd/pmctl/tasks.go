@@ -0,0 +1,206 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/pkg/client"
+	"github.com/urfave/cli/v2"
+	"strings"
+	"time"
+)
+
+// statusOpen selects the tasks that are neither done nor cancelled.
+const statusOpen = "open"
+
+var taskCommand = &cli.Command{
+	Name:  "task",
+	Usage: "list, create and move tasks",
+	Subcommands: []*cli.Command{
+		{
+			Name:    "ls",
+			Aliases: []string{"list"},
+			Usage:   "list tasks",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "status", Usage: `status, or "open" for tasks that are not done or cancelled`},
+				&cli.StringFlag{Name: "priority", Usage: "priority"},
+				&cli.StringFlag{Name: "project", Usage: "project id"},
+				&cli.StringFlag{Name: "assignee", Usage: "assignee id"},
+				&cli.BoolFlag{Name: "mine", Usage: "only tasks assigned to you"},
+				&cli.BoolFlag{Name: "all", Usage: "include tasks of archived projects"},
+			},
+			Action: listTasks,
+		},
+		{
+			Name:      "show",
+			Usage:     "show a task",
+			ArgsUsage: "<id>",
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 1 {
+					return cli.ShowSubcommandHelp(c)
+				}
+				api, _, err := newClient(c)
+				if err != nil {
+					return err
+				}
+
+				task, err := api.GetTask(c.Context, c.Args().First())
+				if err != nil {
+					return err
+				}
+				return renderTasks(c, task, []client.Task{task})
+			},
+		},
+		{
+			Name:  "create",
+			Usage: "create a task",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "title", Required: true},
+				&cli.StringFlag{Name: "description", Required: true},
+				&cli.StringFlag{Name: "project", Usage: "project id", Required: true},
+				&cli.StringFlag{Name: "assignee", Usage: "assignee id, you by default"},
+				&cli.StringFlag{Name: "priority", Value: "Medium"},
+				&cli.StringFlag{Name: "status", Value: "Not Started"},
+				&cli.TimestampFlag{Name: "due", Usage: "due date", Layout: time.DateOnly},
+			},
+			Action: createTask,
+		},
+		{
+			Name:      "move",
+			Usage:     "move a task to another status column",
+			ArgsUsage: "<id> <status>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "before", Usage: "place the card before this task"},
+				&cli.StringFlag{Name: "after", Usage: "place the card after this task"},
+			},
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 2 {
+					return cli.ShowSubcommandHelp(c)
+				}
+				api, _, err := newClient(c)
+				if err != nil {
+					return err
+				}
+
+				id := c.Args().Get(0)
+				input := client.MoveTask{Status: taskStatus(c.Args().Get(1)), Before: c.String("before"), After: c.String("after")}
+				if err := api.MoveTask(c.Context, id, input); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "Task %s moved to %s\n", id, input.Status)
+				return nil
+			},
+		},
+	},
+}
+
+func listTasks(c *cli.Context) error {
+	api, conf, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	assignee := c.String("assignee")
+	if c.Bool("mine") {
+		if conf.User == "" {
+			return errors.New("--mine needs your user id, set it with `pmctl config set user <id>`")
+		}
+		assignee = conf.User
+	}
+	status := c.String("status")
+	options := client.ListOptions{IncludeArchived: c.Bool("all")}
+
+	// Narrow the request with the most selective route and filter the rest
+	// here.
+	var found []client.Task
+	switch {
+	case c.String("project") != "":
+		found, err = api.ListProjectTasks(c.Context, c.String("project"), client.TaskQuery{})
+	case assignee != "":
+		found, err = api.GetTasksByAssignee(c.Context, assignee, options)
+	case status != "" && !strings.EqualFold(status, statusOpen):
+		found, err = api.GetTasksByStatus(c.Context, taskStatus(status), options)
+	default:
+		found, err = api.ListTasks(c.Context, options)
+	}
+	if err != nil {
+		return err
+	}
+
+	tasks := make([]client.Task, 0, len(found))
+	for _, task := range found {
+		if matchesStatus(task, status) &&
+			(c.String("priority") == "" || strings.EqualFold(task.Priority, c.String("priority"))) &&
+			(c.String("project") == "" || task.Project == c.String("project")) &&
+			(assignee == "" || task.Assignee == assignee) {
+			tasks = append(tasks, task)
+		}
+	}
+	return renderTasks(c, tasks, tasks)
+}
+
+func createTask(c *cli.Context) error {
+	api, conf, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	task := client.Task{
+		Title:       c.String("title"),
+		Description: c.String("description"),
+		Project:     c.String("project"),
+		Assignee:    c.String("assignee"),
+		Priority:    c.String("priority"),
+		Status:      taskStatus(c.String("status")),
+	}
+	if task.Assignee == "" {
+		task.Assignee = conf.User
+	}
+	if task.Assignee == "" {
+		return errors.New("--assignee is required when no user is configured")
+	}
+	if due := c.Timestamp("due"); due != nil {
+		task.DueAt = sql.NullTime{Time: *due, Valid: true}
+	}
+
+	created, err := api.CreateTask(c.Context, task)
+	if err != nil {
+		return err
+	}
+
+	if c.String("output") == "json" {
+		return render(c, created, nil)
+	}
+	fmt.Fprintln(c.App.Writer, created.ID)
+	return nil
+}
+
+func renderTasks(c *cli.Context, value interface{}, tasks []client.Task) error {
+	rows := make([][]string, 0, len(tasks))
+	for _, task := range tasks {
+		rows = append(rows, []string{task.ID, task.Title, task.Status, task.Priority, task.Assignee, formatTime(task.DueAt)})
+	}
+	return render(c, value, rows, "ID", "TITLE", "STATUS", "PRIORITY", "ASSIGNEE", "DUE")
+}
+
+// taskStatus expands the lowercase shorthands of the closing statuses.
+func taskStatus(status string) string {
+	switch strings.ToLower(status) {
+	case "done":
+		return client.TaskStatusDone
+	case "cancelled", "canceled":
+		return client.TaskStatusCancelled
+	}
+	return status
+}
+
+func matchesStatus(task client.Task, status string) bool {
+	switch {
+	case status == "":
+		return true
+	case strings.EqualFold(status, statusOpen):
+		return task.Status != client.TaskStatusDone && task.Status != client.TaskStatusCancelled
+	}
+	return strings.EqualFold(task.Status, taskStatus(status))
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL"
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/grpcserver"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/logging"
+	"github.com/Aytya/projects-manager-HL/internal/mailer"
+	"github.com/Aytya/projects-manager-HL/internal/metrics"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/spf13/viper"
+	"github.com/urfave/cli/v2"
+	"log"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var serveCommand = &cli.Command{
+	Name:   "serve",
+	Usage:  "migrate the database and run the HTTP and gRPC servers (default)",
+	Action: serve,
+}
+
+func serve(c *cli.Context) error {
+	shutdownTracing, err := tracing.Setup(c.Context, tracing.Config{
+		Exporter:    viper.GetString("tracing.exporter"),
+		Endpoint:    viper.GetString("tracing.endpoint"),
+		SampleRatio: viper.GetFloat64("tracing.sample_ratio"),
+	})
+	if err != nil {
+		log.Fatal("Failed at initializing tracing", err)
+	}
+
+	config := dbConfig()
+	db, err := repository.NewPostgresDB(config)
+
+	if err != nil {
+		log.Fatal("Failed at initializing db", err)
+	}
+
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db.DB, "postgres"))
+
+	// Unsubscribe links are signed with MAIL_SECRET; without it anyone could
+	// forge them.
+	if viper.GetString("mail.host") != "" && os.Getenv("MAIL_SECRET") == "" {
+		log.Fatal("MAIL_SECRET is required when mail.host is set")
+	}
+
+	repo := repository.NewRepository(db)
+	transport := mailer.NewSMTPTransport(mailer.Config{
+		Host:     viper.GetString("mail.host"),
+		Port:     viper.GetString("mail.port"),
+		Username: viper.GetString("mail.username"),
+		Password: os.Getenv("MAIL_PASSWORD"),
+		From:     viper.GetString("mail.from"),
+	})
+	services := service.NewService(repo, transport, service.EmailConfig{
+		BaseURL: viper.GetString("mail.base_url"),
+		Secret:  os.Getenv("MAIL_SECRET"),
+	})
+	handlers := handler.NewHandler(services, viper.GetString("organizations.domain"), graph.Limits{
+		Complexity: viper.GetInt("graphql.complexity"),
+	}, slog.Default())
+
+	prometheus.MustRegister(metrics.NewUsageCollector(services.Dashboard, 5*time.Second))
+
+	// Workers run until the shutdown cancels their context.
+	workers, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	services.Health.Go(worker(workers, "due_soon"), "due_soon", func(ctx context.Context) {
+		service.RunDueSoonNotifier(ctx, services.Notification, time.Hour, 24*time.Hour)
+	})
+	services.Health.Go(worker(workers, "recurrence"), "recurrence", func(ctx context.Context) {
+		service.RunRecurrenceScheduler(ctx, services.Series, 15*time.Minute, 24*time.Hour)
+	})
+	if viper.GetString("mail.host") != "" {
+		services.Health.Go(worker(workers, "mailer"), "mailer", func(ctx context.Context) {
+			service.RunMailer(ctx, services.Email, time.Minute, 24*time.Hour)
+		})
+	}
+
+	feed := service.NewTaskFeed()
+	services.Health.Go(worker(workers, "task_events"), "task_events", func(ctx context.Context) {
+		if err := repository.ListenTaskEvents(ctx, config, feed.Publish); err != nil {
+			logging.FromContext(ctx).Error("task events listener stopped", "error", err)
+		}
+	})
+
+	// Without a token callers are trusted by the x-user-id they send, so the
+	// gRPC port is only reachable from the host itself.
+	grpcToken, grpcHost := os.Getenv("GRPC_TOKEN"), ""
+	if grpcToken == "" {
+		grpcHost = "127.0.0.1"
+		slog.Warn("GRPC_TOKEN is not set, serving grpc on localhost only")
+	}
+	lis, err := net.Listen("tcp", net.JoinHostPort(grpcHost, viper.GetString("grpc.port")))
+	if err != nil {
+		log.Fatal("Failed at listening for grpc", err)
+	}
+	grpcServer := grpcserver.New(services, feed, grpcToken)
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatal("Error occured while running grpc server", err)
+		}
+	}()
+
+	server := projects_manager.NewServer(viper.GetString("port"), handlers.InitRoutes())
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Run()
+	}()
+
+	signals, stop := signal.NotifyContext(c.Context, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-serverErr:
+		log.Fatal("Error occured while running http server", err)
+	case <-signals.Done():
+	}
+	// A second signal kills the process without waiting for the shutdown.
+	stop()
+
+	// Readiness fails from the first signal on, and the servers keep serving
+	// until load balancers had the time to notice it.
+	services.Health.Drain()
+	delay, timeout := viper.GetDuration("shutdown.drain_delay"), viper.GetDuration("shutdown.drain_timeout")
+	slog.Info("Shutting down", "drain_delay", delay, "drain_timeout", timeout)
+	time.Sleep(delay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Failed to drain http server", "error", err)
+	}
+	// Watch streams never end on their own and would hold GracefulStop up.
+	feed.Close()
+	if !wait(ctx, grpcServer.GracefulStop) {
+		slog.Error("Failed to drain grpc server", "error", ctx.Err())
+		grpcServer.Stop()
+	}
+
+	// The workers and the trace flush get their own budgets, so that a slow
+	// drain does not leave them none.
+	stopWorkers()
+	workersCtx, cancelWorkers := context.WithTimeout(context.Background(), viper.GetDuration("shutdown.workers_timeout"))
+	defer cancelWorkers()
+	if wait(workersCtx, services.Health.Wait) {
+		if err := db.Close(); err != nil {
+			slog.Error("Failed to close db", "error", err)
+		}
+	} else {
+		slog.Error("Failed to stop background workers, leaving db open", "error", workersCtx.Err())
+	}
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), viper.GetDuration("shutdown.flush_timeout"))
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+
+	slog.Info("Server stopped")
+	return nil
+}
+
+// wait runs fn and reports whether it returned before ctx was done.
+func wait(ctx context.Context, fn func()) bool {
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// worker returns the context of a background worker, whose log lines are
+// tagged with its name.
+func worker(ctx context.Context, name string) context.Context {
+	return logging.WithLogger(ctx, slog.Default().With("worker", name))
+}
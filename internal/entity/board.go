@@ -0,0 +1,17 @@
+package entity
+
+import "database/sql"
+
+// MoveTask places a task on the board. The task goes after the task in
+// After and before the one in Before; with neither it goes to the bottom of
+// the column. Status moves it to another column.
+type MoveTask struct {
+	Status string `json:"status"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type TaskRank struct {
+	ID   string         `db:"id"`
+	Rank sql.NullString `db:"rank"`
+}
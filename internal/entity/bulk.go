@@ -0,0 +1,32 @@
+package entity
+
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+type BulkTaskItem struct {
+	Op   string `json:"op"`
+	ID   string `json:"id,omitempty"`
+	Task Task   `json:"task"`
+}
+
+type BulkTaskRequest struct {
+	// Atomic rolls back every item when one of them fails. When false each
+	// item is applied on its own and the response reports per-item results.
+	Atomic bool           `json:"atomic"`
+	Items  []BulkTaskItem `json:"items"`
+}
+
+type BulkTaskResult struct {
+	Index int    `json:"index"`
+	Op    string `json:"op"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type ReassignTasks struct {
+	Assignee string `json:"assignee"`
+	Project  string `json:"project,omitempty"`
+}
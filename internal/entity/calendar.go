@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	CalendarScopeUser    = "user"
+	CalendarScopeProject = "project"
+)
+
+type CalendarToken struct {
+	ID        string       `json:"id" db:"id"`
+	Token     string       `json:"token" db:"token"`
+	Scope     string       `json:"scope" db:"scope"`
+	Target    string       `json:"target" db:"target"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+	RevokedAt sql.NullTime `json:"revoked_at" db:"revoked_at"`
+
+	Organization string `json:"organization" db:"organization"`
+}
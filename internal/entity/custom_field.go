@@ -0,0 +1,97 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	FieldText        = "text"
+	FieldNumber      = "number"
+	FieldDate        = "date"
+	FieldSelect      = "select"
+	FieldMultiSelect = "multi_select"
+	FieldUser        = "user"
+)
+
+// CustomField is a project-defined task attribute. Options list the allowed
+// values of select and multi_select fields.
+type CustomField struct {
+	ID        string       `json:"id" db:"id"`
+	Project   string       `json:"project" db:"project"`
+	Name      string       `json:"name" db:"name"`
+	Type      string       `json:"type" db:"type"`
+	Options   FieldOptions `json:"options" db:"options"`
+	Required  bool         `json:"required" db:"required"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+// CustomFieldUpdate changes a field; what is left out keeps its value.
+type CustomFieldUpdate struct {
+	Name     string       `json:"name"`
+	Type     string       `json:"type"`
+	Options  FieldOptions `json:"options"`
+	Required *bool        `json:"required"`
+}
+
+type FieldOptions []string
+
+func (o FieldOptions) Value() (driver.Value, error) {
+	if o == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(o)
+}
+
+func (o *FieldOptions) Scan(src interface{}) error {
+	return scanJSON(src, o)
+}
+
+// CustomValues holds a task's custom field values keyed by field id.
+type CustomValues map[string]interface{}
+
+func (v CustomValues) Value() (driver.Value, error) {
+	if v == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(v)
+}
+
+func (v *CustomValues) Scan(src interface{}) error {
+	return scanJSON(src, v)
+}
+
+func scanJSON(src interface{}, dest interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, dest)
+	case string:
+		return json.Unmarshal([]byte(data), dest)
+	default:
+		return fmt.Errorf("cannot scan %T as JSON", src)
+	}
+}
+
+// FieldFilter keeps tasks whose value of Field equals Value; for
+// multi_select fields the value must be one of the selected options.
+type FieldFilter struct {
+	Field CustomField
+	Value string
+}
+
+type FieldSort struct {
+	Field CustomField
+	Desc  bool
+}
+
+// TaskListQuery filters and sorts a project's tasks by custom fields, which
+// are referred to by name or id.
+type TaskListQuery struct {
+	Fields map[string]string
+	Sort   string
+	Desc   bool
+}
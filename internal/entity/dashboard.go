@@ -0,0 +1,27 @@
+package entity
+
+type TaskCount struct {
+	Status   string `json:"status" db:"status"`
+	Priority string `json:"priority" db:"priority"`
+	Count    int    `json:"count" db:"count"`
+}
+
+type ProjectProgress struct {
+	Project
+	Total    int     `json:"total" db:"total"`
+	Finished int     `json:"finished" db:"finished"`
+	Progress float64 `json:"progress"`
+}
+
+type Dashboard struct {
+	OpenTasks       []TaskCount       `json:"open_tasks"`
+	DueSoon         []Task            `json:"due_soon"`
+	RecentlyChanged []Task            `json:"recently_changed"`
+	Projects        []ProjectProgress `json:"projects"`
+}
+
+// Usage sums up the open work of the whole service.
+type Usage struct {
+	OpenTasks      []TaskCount
+	ActiveProjects int
+}
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	EmailModeInstant = "instant"
+	EmailModeDigest  = "digest"
+	EmailModeOff     = "off"
+)
+
+type EmailPreference struct {
+	Mode string `json:"mode" db:"mode"`
+}
+
+type Email struct {
+	ID            string         `json:"id" db:"id"`
+	UserID        string         `json:"user_id" db:"user_id"`
+	Recipient     string         `json:"recipient" db:"recipient"`
+	Subject       string         `json:"subject" db:"subject"`
+	TextBody      string         `json:"text_body" db:"text_body"`
+	HTMLBody      string         `json:"html_body" db:"html_body"`
+	Attempts      int            `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time      `json:"next_attempt_at" db:"next_attempt_at"`
+	SentAt        sql.NullTime   `json:"sent_at" db:"sent_at"`
+	LastError     sql.NullString `json:"last_error" db:"last_error"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+}
+
+type PendingNotification struct {
+	Notification
+	UserName  string `db:"user_name"`
+	UserEmail string `db:"user_email"`
+}
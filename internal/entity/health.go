@@ -0,0 +1,25 @@
+package entity
+
+const (
+	HealthOK      = "ok"
+	HealthFailing = "failing"
+)
+
+const (
+	ComponentDatabase   = "database"
+	ComponentMigrations = "migrations"
+	ComponentWorkers    = "workers"
+	ComponentShutdown   = "shutdown"
+)
+
+type ComponentHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Readiness is ok when all of its components are.
+type Readiness struct {
+	Status     string            `json:"status"`
+	Components []ComponentHealth `json:"components"`
+}
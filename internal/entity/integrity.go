@@ -0,0 +1,33 @@
+package entity
+
+// Integrity checks run by `db check`. They cover rules the schema cannot
+// enforce with foreign keys.
+const (
+	CheckManagerNotMember   = "manager_not_member"
+	CheckAssigneeNotMember  = "assignee_not_member"
+	CheckUserWithoutOrg     = "user_without_organization"
+	CheckArchivedOpenTask   = "archived_open_task"
+	CheckForeignSprint      = "task_sprint_other_project"
+	CheckForeignMilestone   = "task_milestone_other_project"
+	CheckOrphanCalendarLink = "calendar_token_orphan"
+)
+
+// Inconsistency is one row that breaks an integrity check.
+type Inconsistency struct {
+	Check  string `json:"check" db:"-"`
+	ID     string `json:"id" db:"id"`
+	Detail string `json:"detail" db:"detail"`
+}
+
+// IntegrityFix is the number of rows a check repaired.
+type IntegrityFix struct {
+	Check string `json:"check"`
+	Fixed int64  `json:"fixed"`
+}
+
+// Seed is the amount of demo data `seed` generates.
+type Seed struct {
+	Users    int `json:"users"`
+	Projects int `json:"projects"`
+	Tasks    int `json:"tasks"`
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Milestone struct {
+	ID          string    `json:"id" db:"id"`
+	Project     string    `json:"project" db:"project"`
+	Title       string    `json:"title" db:"title"`
+	Description string    `json:"description" db:"description"`
+	TargetAt    time.Time `json:"target_at" db:"target_at"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// MilestoneProgress is a milestone with the state of its tasks. Progress is
+// the share of done tasks in percent, like ProjectProgress. The estimated
+// completion extrapolates the pace since the milestone was created.
+type MilestoneProgress struct {
+	Milestone
+	Total               int          `json:"total" db:"total"`
+	Done                int          `json:"done" db:"done"`
+	LastFinishedAt      sql.NullTime `json:"-" db:"last_finished_at"`
+	Progress            float64      `json:"progress"`
+	EstimatedCompletion sql.NullTime `json:"estimated_completion"`
+	Overdue             bool         `json:"overdue"`
+}
+
+type MilestoneTasks struct {
+	Tasks []string `json:"tasks" binding:"required"`
+}
@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	NotificationAssigned      = "task_assigned"
+	NotificationStatusChanged = "task_status_changed"
+	NotificationDueSoon       = "task_due_soon"
+)
+
+var NotificationTypes = []string{
+	NotificationAssigned,
+	NotificationStatusChanged,
+	NotificationDueSoon,
+}
+
+type Notification struct {
+	ID        string         `json:"id" db:"id"`
+	UserID    string         `json:"user_id" db:"user_id"`
+	Type      string         `json:"type" db:"type"`
+	Task      sql.NullString `json:"task" db:"task"`
+	Message   string         `json:"message" db:"message"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+	ReadAt    sql.NullTime   `json:"read_at" db:"read_at"`
+	EmailedAt sql.NullTime   `json:"-" db:"emailed_at"`
+}
+
+type NotificationPreference struct {
+	Type    string `json:"type" db:"type"`
+	Enabled bool   `json:"enabled" db:"enabled"`
+}
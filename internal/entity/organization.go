@@ -0,0 +1,58 @@
+package entity
+
+import "time"
+
+// DefaultOrganization is the slug of the organization requests fall back to
+// when they name none; data created before organizations existed lives there.
+const DefaultOrganization = "default"
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+var OrgRoles = []string{OrgRoleOwner, OrgRoleAdmin, OrgRoleMember}
+
+// Resources that belong to an organization and can be checked by id.
+const (
+	ResourceUser      = "user"
+	ResourceProject   = "project"
+	ResourceTask      = "task"
+	ResourceSprint    = "sprint"
+	ResourceMilestone = "milestone"
+	ResourceField     = "field"
+)
+
+// Organization is a tenant: projects belong to exactly one organization and
+// users join organizations through memberships.
+type Organization struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Slug      string    `json:"slug" db:"slug"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type Membership struct {
+	Organization string    `json:"organization" db:"organization"`
+	UserID       string    `json:"user_id" db:"user_id"`
+	Role         string    `json:"role" db:"role"`
+	JoinedAt     time.Time `json:"joined_at" db:"joined_at"`
+}
+
+// Member is a user together with their role in one organization.
+type Member struct {
+	User
+	OrgRole  string    `json:"org_role" db:"org_role"`
+	JoinedAt time.Time `json:"joined_at" db:"joined_at"`
+}
+
+// UserOrganization is an organization together with the user's role in it.
+type UserOrganization struct {
+	Organization
+	Role string `json:"role" db:"role"`
+}
+
+type SetMember struct {
+	Role string `json:"role" binding:"required"`
+}
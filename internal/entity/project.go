@@ -15,4 +15,55 @@ type Project struct {
 	// format: date-time
 	FinishedAt sql.NullTime `db:"finished_at" json:"finished_at"`
 	Manager    string       `db:"manager" json:"manager"`
+
+	Organization string `db:"organization" json:"organization"`
+
+	// StartAt anchors the due dates of the project's tasks when it is cloned.
+	// type: string
+	// format: date-time
+	StartAt  sql.NullTime `db:"start_at" json:"start_at"`
+	Template bool         `db:"is_template" json:"is_template"`
+}
+
+// CloneProject describes a copy of a project. Tasks keep the distance of
+// their due dates from the project start, counted from StartAt in the copy;
+// Assignees maps original assignees to new ones. The copied tasks start
+// over unfinished in Status, Not Started unless set.
+type CloneProject struct {
+	Title     string            `json:"title"`
+	StartAt   time.Time         `json:"start_at"`
+	Manager   string            `json:"manager"`
+	Status    string            `json:"status"`
+	Assignees map[string]string `json:"assignees"`
+}
+
+const (
+	ArchiveClose  = "close"
+	ArchiveCancel = "cancel"
+
+	TaskStatusNotStarted = "Not Started"
+	TaskStatusDone       = "Done"
+	TaskStatusCancelled  = "Cancelled"
+)
+
+// ArchiveProject finishes a project. Its open tasks are finished as done or
+// cancelled depending on Policy; Status, when set to Done or Cancelled,
+// replaces the status they get.
+type ArchiveProject struct {
+	Policy string `json:"policy" binding:"required"`
+	Status string `json:"status"`
+}
+
+type SaveTemplate struct {
+	Title string `json:"title"`
+}
+
+// ProjectCopy is everything written when a project is cloned. Fields,
+// milestones and tasks keep the ids of their originals so that references
+// between them can be remapped.
+type ProjectCopy struct {
+	Project    Project
+	Fields     []CustomField
+	Milestones []Milestone
+	Tasks      []Task
 }
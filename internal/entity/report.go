@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+const (
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+type ReportRange struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Interval string    `json:"interval"`
+}
+
+type BurndownPoint struct {
+	Date      time.Time `json:"date" db:"point"`
+	Total     int       `json:"total" db:"total"`
+	Remaining int       `json:"remaining" db:"remaining"`
+}
+
+type StatusCount struct {
+	Date   time.Time `json:"date" db:"point"`
+	Status string    `json:"status" db:"status"`
+	Count  int       `json:"count" db:"count"`
+}
+
+type CumulativeFlowPoint struct {
+	Date     time.Time      `json:"date"`
+	Statuses map[string]int `json:"statuses"`
+}
+
+type ThroughputPoint struct {
+	Date     time.Time `json:"date" db:"point"`
+	Finished int       `json:"finished" db:"finished"`
+}
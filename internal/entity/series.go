@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	// RecurOnFinish creates the next occurrence when the current one is
+	// finished, RecurOnSchedule creates it ahead of its date regardless.
+	RecurOnFinish   = "finish"
+	RecurOnSchedule = "schedule"
+)
+
+// TaskSeries is a recurring task. Its template fields are copied into every
+// occurrence it generates.
+type TaskSeries struct {
+	ID          string       `json:"id" db:"id"`
+	Rule        string       `json:"rule" db:"rule"`
+	Trigger     string       `json:"trigger" db:"trigger"`
+	StartAt     time.Time    `json:"start_at" db:"start_at"`
+	NextAt      sql.NullTime `json:"next_at" db:"next_at"`
+	Title       string       `json:"title" db:"title"`
+	Description string       `json:"description" db:"description"`
+	Priority    string       `json:"priority" db:"priority"`
+	Status      string       `json:"status" db:"status"`
+	Assignee    string       `json:"assignee" db:"assignee"`
+	Project     string       `json:"project" db:"project"`
+	Fields      CustomValues `json:"custom_fields" db:"custom_fields"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+}
+
+type Recurrence struct {
+	Rule    string `json:"rule" binding:"required"`
+	Trigger string `json:"trigger"`
+}
+
+// SeriesUpdate changes a series from one occurrence on. Empty fields are
+// left as they are.
+type SeriesUpdate struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Priority    string `json:"priority"`
+	Status      string `json:"status"`
+	Assignee    string `json:"assignee"`
+	Rule        string `json:"rule"`
+	Trigger     string `json:"trigger"`
+}
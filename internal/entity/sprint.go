@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	SprintPlanned = "planned"
+	SprintActive  = "active"
+	SprintClosed  = "closed"
+)
+
+type Sprint struct {
+	ID        string       `json:"id" db:"id"`
+	Project   string       `json:"project" db:"project"`
+	Name      string       `json:"name" db:"name"`
+	Goal      string       `json:"goal" db:"goal"`
+	StartAt   time.Time    `json:"start_at" db:"start_at"`
+	EndAt     time.Time    `json:"end_at" db:"end_at"`
+	Capacity  int          `json:"capacity" db:"capacity"`
+	State     string       `json:"state" db:"state"`
+	StartedAt sql.NullTime `json:"started_at" db:"started_at"`
+	ClosedAt  sql.NullTime `json:"closed_at" db:"closed_at"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+type SprintTasks struct {
+	Tasks []string `json:"tasks" binding:"required"`
+}
+
+// CloseSprint optionally names the sprint unfinished tasks move to. By
+// default they go to the next planned sprint of the project, or back to the
+// backlog if there is none.
+type CloseSprint struct {
+	Next string `json:"next"`
+}
+
+type CloseSprintResult struct {
+	Next  string `json:"next,omitempty"`
+	Moved int64  `json:"moved"`
+}
+
+// SprintReport compares the scope the sprint started with to what was added,
+// removed and completed while it ran.
+type SprintReport struct {
+	Sprint             Sprint  `json:"sprint"`
+	Committed          int     `json:"committed" db:"committed"`
+	Added              int     `json:"added" db:"added"`
+	Removed            int     `json:"removed" db:"removed"`
+	Completed          int     `json:"completed" db:"completed"`
+	CommittedCompleted int     `json:"committed_completed" db:"committed_completed"`
+	AddedCompleted     int     `json:"added_completed" db:"added_completed"`
+	Remaining          int     `json:"remaining" db:"remaining"`
+	CompletionRate     float64 `json:"completion_rate"`
+}
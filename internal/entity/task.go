@@ -6,13 +6,20 @@ import (
 )
 
 type Task struct {
-	ID          string       `json:"id" db:"id"`
-	Title       string       `json:"title" db:"title"`
-	Description string       `json:"description" db:"description"`
-	Priority    string       `json:"priority" db:"priority"`
-	Status      string       `json:"status" db:"status"`
-	Assignee    string       `json:"assignee" db:"assignee"`
-	Project     string       `json:"project" db:"project"`
-	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
-	FinishedAt  sql.NullTime `json:"finished_at" db:"finished_at"`
+	ID          string         `json:"id" db:"id"`
+	Title       string         `json:"title" db:"title"`
+	Description string         `json:"description" db:"description"`
+	Priority    string         `json:"priority" db:"priority"`
+	Status      string         `json:"status" db:"status"`
+	Assignee    string         `json:"assignee" db:"assignee"`
+	Project     string         `json:"project" db:"project"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	FinishedAt  sql.NullTime   `json:"finished_at" db:"finished_at"`
+	DueAt       sql.NullTime   `json:"due_at" db:"due_at"`
+	Series      sql.NullString `json:"series" db:"series_id"`
+	OccursAt    sql.NullTime   `json:"occurs_at" db:"occurs_at"`
+	Sprint      sql.NullString `json:"sprint" db:"sprint_id"`
+	Milestone   sql.NullString `json:"milestone" db:"milestone_id"`
+	Rank        sql.NullString `json:"rank" db:"rank"`
+	Fields      CustomValues   `json:"custom_fields" db:"custom_fields"`
 }
@@ -0,0 +1,17 @@
+package entity
+
+const (
+	TaskInserted = "insert"
+	TaskUpdated  = "update"
+	TaskDeleted  = "delete"
+)
+
+// TaskEvent announces a change to a task. Organization is empty when the
+// task was deleted together with its project.
+type TaskEvent struct {
+	Type         string `json:"type"`
+	ID           string `json:"id"`
+	Project      string `json:"project"`
+	Assignee     string `json:"assignee"`
+	Organization string `json:"organization"`
+}
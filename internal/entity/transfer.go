@@ -0,0 +1,26 @@
+package entity
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+type TaskExport struct {
+	Task
+	AssigneeName  string `json:"assignee_name" db:"assignee_name"`
+	AssigneeEmail string `json:"assignee_email" db:"assignee_email"`
+}
+
+type ImportRowError struct {
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
+
+type TaskImportReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Rows     int              `json:"rows"`
+	Imported int              `json:"imported"`
+	IDs      []string         `json:"ids,omitempty"`
+	Errors   []ImportRowError `json:"errors,omitempty"`
+}
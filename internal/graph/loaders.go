@@ -0,0 +1,108 @@
+package graph
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/graph-gophers/dataloader/v7"
+	"time"
+)
+
+// batchWait is how long a loader collects keys before querying them.
+const batchWait = 2 * time.Millisecond
+
+// userTasksKey selects the tasks of a user with or without those of
+// archived projects.
+type userTasksKey struct {
+	user            string
+	includeArchived bool
+}
+
+// loaders batch the lookups made while resolving one request, so a list of
+// tasks costs one query for their assignees and one for their projects.
+type loaders struct {
+	users        *dataloader.Loader[string, *entity.User]
+	projects     *dataloader.Loader[string, *entity.Project]
+	projectTasks *dataloader.Loader[string, []entity.Task]
+	userTasks    *dataloader.Loader[userTasksKey, []entity.Task]
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, services *service.Service) context.Context {
+	l := &loaders{
+		users: dataloader.NewBatchedLoader(func(ctx context.Context, ids []string) []*dataloader.Result[*entity.User] {
+			users, err := services.GetUsersByIds(ctx, organization(ctx), ids)
+			return byId(ids, users, err, func(u entity.User) string { return u.ID })
+		}, dataloader.WithWait[string, *entity.User](batchWait)),
+
+		projects: dataloader.NewBatchedLoader(func(ctx context.Context, ids []string) []*dataloader.Result[*entity.Project] {
+			projects, err := services.GetProjectsByIds(ctx, organization(ctx), ids)
+			return byId(ids, projects, err, func(p entity.Project) string { return p.ID })
+		}, dataloader.WithWait[string, *entity.Project](batchWait)),
+
+		projectTasks: dataloader.NewBatchedLoader(func(ctx context.Context, ids []string) []*dataloader.Result[[]entity.Task] {
+			tasks, err := services.GetTasksByProjectIds(ctx, ids)
+			return groupBy(ids, tasks, err, func(t entity.Task) string { return t.Project })
+		}, dataloader.WithWait[string, []entity.Task](batchWait)),
+
+		userTasks: dataloader.NewBatchedLoader(func(ctx context.Context, keys []userTasksKey) []*dataloader.Result[[]entity.Task] {
+			results := make([]*dataloader.Result[[]entity.Task], len(keys))
+			for _, includeArchived := range []bool{false, true} {
+				index := make([]int, 0, len(keys))
+				ids := make([]string, 0, len(keys))
+				for i, key := range keys {
+					if key.includeArchived == includeArchived {
+						index = append(index, i)
+						ids = append(ids, key.user)
+					}
+				}
+				if len(ids) == 0 {
+					continue
+				}
+
+				tasks, err := services.GetTasksByAssignees(ctx, organization(ctx), ids, includeArchived)
+				for i, result := range groupBy(ids, tasks, err, func(t entity.Task) string { return t.Assignee }) {
+					results[index[i]] = result
+				}
+			}
+			return results
+		}, dataloader.WithWait[userTasksKey, []entity.Task](batchWait)),
+	}
+
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// byId orders the fetched items like the requested ids. Missing items
+// resolve to nil.
+func byId[T any](ids []string, items []T, err error, id func(T) string) []*dataloader.Result[*T] {
+	results := make([]*dataloader.Result[*T], len(ids))
+	found := make(map[string]*T, len(items))
+	for i := range items {
+		found[id(items[i])] = &items[i]
+	}
+
+	for i, key := range ids {
+		results[i] = &dataloader.Result[*T]{Data: found[key], Error: err}
+	}
+	return results
+}
+
+// groupBy collects the fetched items of every requested id, keeping their
+// order.
+func groupBy[T any](ids []string, items []T, err error, key func(T) string) []*dataloader.Result[[]T] {
+	groups := make(map[string][]T, len(ids))
+	for _, item := range items {
+		groups[key(item)] = append(groups[key(item)], item)
+	}
+
+	results := make([]*dataloader.Result[[]T], len(ids))
+	for i, id := range ids {
+		results[i] = &dataloader.Result[[]T]{Data: groups[id], Error: err}
+	}
+	return results
+}
@@ -0,0 +1,69 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package model
+
+import (
+	"time"
+)
+
+type Mutation struct {
+}
+
+type NewProject struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Manager     string `json:"manager"`
+}
+
+type NewTask struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Priority    string     `json:"priority"`
+	Status      string     `json:"status"`
+	Assignee    string     `json:"assignee"`
+	Project     string     `json:"project"`
+	DueAt       *time.Time `json:"dueAt,omitempty"`
+}
+
+type NewUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type ProjectFilter struct {
+	Manager         *string `json:"manager,omitempty"`
+	IncludeArchived *bool   `json:"includeArchived,omitempty"`
+}
+
+type Query struct {
+}
+
+type TaskFilter struct {
+	Status          *string `json:"status,omitempty"`
+	Priority        *string `json:"priority,omitempty"`
+	Assignee        *string `json:"assignee,omitempty"`
+	Project         *string `json:"project,omitempty"`
+	IncludeArchived *bool   `json:"includeArchived,omitempty"`
+}
+
+type UpdateProject struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Manager     *string `json:"manager,omitempty"`
+}
+
+type UpdateTask struct {
+	Title       *string    `json:"title,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Priority    *string    `json:"priority,omitempty"`
+	Status      *string    `json:"status,omitempty"`
+	Assignee    *string    `json:"assignee,omitempty"`
+	DueAt       *time.Time `json:"dueAt,omitempty"`
+}
+
+type UpdateUser struct {
+	Name  *string `json:"name,omitempty"`
+	Email *string `json:"email,omitempty"`
+	Role  *string `json:"role,omitempty"`
+}
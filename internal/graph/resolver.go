@@ -0,0 +1,262 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/graph/model"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"net/http"
+	"time"
+)
+
+//go:generate go run github.com/99designs/gqlgen generate --config ../../gqlgen.yml
+
+const (
+	// maxPageSize caps the first argument of every list field.
+	maxPageSize = 100
+
+	// DefaultComplexity is used when Limits leave the complexity unset. It
+	// admits twenty projects with twenty tasks each and their assignees.
+	DefaultComplexity = 5000
+)
+
+var (
+	errNotFound     = errors.New("not found")
+	errInvalidInput = errors.New("invalid input")
+)
+
+// Limits bound the work a single query may cause. Every field costs one and
+// list fields multiply the cost of their selection by the page size.
+type Limits struct {
+	Complexity int
+}
+
+type Resolver struct {
+	service *service.Service
+}
+
+type organizationKey struct{}
+
+// WithOrganization scopes a request to the organization, as the organization
+// middleware of the REST API does.
+func WithOrganization(ctx context.Context, orgId string) context.Context {
+	return context.WithValue(ctx, organizationKey{}, orgId)
+}
+
+func organization(ctx context.Context) string {
+	orgId, _ := ctx.Value(organizationKey{}).(string)
+	return orgId
+}
+
+type userKey struct{}
+
+// WithUser sets the user that makes the request.
+func WithUser(ctx context.Context, userId string) context.Context {
+	return context.WithValue(ctx, userKey{}, userId)
+}
+
+func caller(ctx context.Context) string {
+	userId, _ := ctx.Value(userKey{}).(string)
+	return userId
+}
+
+// Server executes GraphQL requests with fresh dataloaders for every request.
+type Server struct {
+	service *service.Service
+	handler *handler.Server
+}
+
+func NewServer(services *service.Service, limits Limits) *Server {
+	config := Config{Resolvers: &Resolver{service: services}}
+	complexity(&config.Complexity)
+
+	srv := handler.New(NewExecutableSchema(config))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	if limits.Complexity <= 0 {
+		limits.Complexity = DefaultComplexity
+	}
+	srv.Use(extension.FixedComplexityLimit(limits.Complexity))
+	srv.SetErrorPresenter(presentError)
+
+	return &Server{service: services, handler: srv}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := withLoaders(r.Context(), s.service)
+	s.handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// complexity charges list fields for every item they may return.
+func complexity(c *ComplexityRoot) {
+	c.Query.Users = func(child int, first, offset *int) int {
+		return pageCost(child, first)
+	}
+	c.Query.Projects = func(child int, filter *model.ProjectFilter, first, offset *int) int {
+		return pageCost(child, first)
+	}
+	c.Query.Tasks = func(child int, filter *model.TaskFilter, first, offset *int) int {
+		return pageCost(child, first)
+	}
+	c.Project.Tasks = func(child int, first, offset *int) int {
+		return pageCost(child, first)
+	}
+	c.User.Tasks = func(child int, includeArchived *bool, first, offset *int) int {
+		return pageCost(child, first)
+	}
+}
+
+func pageCost(child int, first *int) int {
+	return 1 + child*pageSize(first)
+}
+
+func pageSize(first *int) int {
+	if first == nil || *first > maxPageSize {
+		return maxPageSize
+	}
+	if *first < 0 {
+		return 0
+	}
+	return *first
+}
+
+// page returns the slice of items selected by first and offset.
+func page[T any](items []T, first, offset *int) []T {
+	start := 0
+	if offset != nil && *offset > 0 {
+		start = *offset
+	}
+	if start > len(items) {
+		start = len(items)
+	}
+
+	end := start + pageSize(first)
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+// contains checks that the resource belongs to the organization of the
+// request; ids of other organizations are reported as not found.
+func (r *Resolver) contains(ctx context.Context, resource, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return errNotFound
+	}
+
+	ok, err := r.service.Contains(ctx, organization(ctx), resource, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errNotFound
+	}
+	return nil
+}
+
+// presentError adds a code extension clients can switch on.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+
+	var code string
+	switch {
+	case errors.Is(err, errNotFound):
+		code = "NOT_FOUND"
+	case errors.Is(err, service.ErrProjectArchived), errors.Is(err, service.ErrLastOwner), errors.Is(err, service.ErrUserExists):
+		code = "CONFLICT"
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrNotMember):
+		code = "FORBIDDEN"
+	case errors.Is(err, errInvalidInput), errors.Is(err, service.ErrUnknownField), errors.Is(err, service.ErrInvalidFieldValue):
+		code = "BAD_USER_INPUT"
+	default:
+		return presented
+	}
+
+	if presented.Extensions == nil {
+		presented.Extensions = map[string]interface{}{}
+	}
+	presented.Extensions["code"] = code
+	return presented
+}
+
+// reference checks an id given in the input of a mutation.
+func (r *Resolver) reference(ctx context.Context, resource, id string) error {
+	if err := r.contains(ctx, resource, id); err != nil {
+		if errors.Is(err, errNotFound) {
+			return fmt.Errorf("%w: %s %q is not in the organization", errInvalidInput, resource, id)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *Resolver) user(ctx context.Context, id string) (*entity.User, error) {
+	user, err := r.service.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *Resolver) project(ctx context.Context, id string) (*entity.Project, error) {
+	project, err := r.service.GetProjectById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *Resolver) task(ctx context.Context, id string) (*entity.Task, error) {
+	task, err := r.service.GetTaskById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// nothing turns a missing object into a null result.
+func nothing(err error) error {
+	if errors.Is(err, errNotFound) {
+		return nil
+	}
+	return err
+}
+
+func matches(task entity.Task, filter *model.TaskFilter) bool {
+	return (filter.Status == nil || task.Status == *filter.Status) &&
+		(filter.Priority == nil || task.Priority == *filter.Priority) &&
+		(filter.Assignee == nil || task.Assignee == *filter.Assignee) &&
+		(filter.Project == nil || task.Project == *filter.Project)
+}
+
+func value[T any](v *T) T {
+	var zero T
+	if v == nil {
+		return zero
+	}
+	return *v
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
@@ -0,0 +1,333 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.49
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/graph/model"
+)
+
+// CreateUser is the resolver for the createUser field.
+func (r *mutationResolver) CreateUser(ctx context.Context, input model.NewUser) (*entity.User, error) {
+	if input.Name == "" || input.Email == "" || input.Role == "" {
+		return nil, fmt.Errorf("%w: name, email and role are required", errInvalidInput)
+	}
+
+	id, _, err := r.service.CreateUser(ctx, organization(ctx), entity.User{Name: input.Name, Email: input.Email, Role: input.Role})
+	if err != nil {
+		return nil, err
+	}
+	return r.user(ctx, id)
+}
+
+// UpdateUser is the resolver for the updateUser field.
+func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input model.UpdateUser) (*entity.User, error) {
+	if err := r.contains(ctx, entity.ResourceUser, id); err != nil {
+		return nil, err
+	}
+
+	user := entity.User{Name: value(input.Name), Email: value(input.Email), Role: value(input.Role)}
+	if user.Name == "" && user.Email == "" && user.Role == "" {
+		return nil, fmt.Errorf("%w: nothing to update", errInvalidInput)
+	}
+
+	if err := r.service.UpdateUser(ctx, organization(ctx), caller(ctx), id, user); err != nil {
+		return nil, err
+	}
+	return r.user(ctx, id)
+}
+
+// DeleteUser is the resolver for the deleteUser field.
+func (r *mutationResolver) DeleteUser(ctx context.Context, id string) (bool, error) {
+	if err := r.contains(ctx, entity.ResourceUser, id); err != nil {
+		return false, err
+	}
+
+	if _, err := r.service.DeleteUser(ctx, organization(ctx), caller(ctx), id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateProject is the resolver for the createProject field.
+func (r *mutationResolver) CreateProject(ctx context.Context, input model.NewProject) (*entity.Project, error) {
+	if input.Title == "" || input.Description == "" {
+		return nil, fmt.Errorf("%w: title and description are required", errInvalidInput)
+	}
+	if err := r.reference(ctx, entity.ResourceUser, input.Manager); err != nil {
+		return nil, err
+	}
+
+	project := entity.Project{Title: input.Title, Description: input.Description, Manager: input.Manager, Organization: organization(ctx)}
+	id, _, err := r.service.Project.CreateProject(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return r.project(ctx, id)
+}
+
+// UpdateProject is the resolver for the updateProject field.
+func (r *mutationResolver) UpdateProject(ctx context.Context, id string, input model.UpdateProject) (*entity.Project, error) {
+	if err := r.contains(ctx, entity.ResourceProject, id); err != nil {
+		return nil, err
+	}
+
+	project := entity.Project{Title: value(input.Title), Description: value(input.Description), Manager: value(input.Manager)}
+	if project.Title == "" && project.Description == "" && project.Manager == "" {
+		return nil, fmt.Errorf("%w: nothing to update", errInvalidInput)
+	}
+	if project.Manager != "" {
+		if err := r.reference(ctx, entity.ResourceUser, project.Manager); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.service.UpdateProject(ctx, id, project); err != nil {
+		return nil, err
+	}
+	return r.project(ctx, id)
+}
+
+// DeleteProject is the resolver for the deleteProject field.
+func (r *mutationResolver) DeleteProject(ctx context.Context, id string) (bool, error) {
+	if err := r.contains(ctx, entity.ResourceProject, id); err != nil {
+		return false, err
+	}
+
+	if err := r.service.DeleteProject(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateTask is the resolver for the createTask field.
+func (r *mutationResolver) CreateTask(ctx context.Context, input model.NewTask) (*entity.Task, error) {
+	if input.Title == "" || input.Description == "" || input.Priority == "" || input.Status == "" {
+		return nil, fmt.Errorf("%w: title, description, priority and status are required", errInvalidInput)
+	}
+	if err := r.reference(ctx, entity.ResourceProject, input.Project); err != nil {
+		return nil, err
+	}
+	if err := r.reference(ctx, entity.ResourceUser, input.Assignee); err != nil {
+		return nil, err
+	}
+
+	task := entity.Task{
+		Title:       input.Title,
+		Description: input.Description,
+		Priority:    input.Priority,
+		Status:      input.Status,
+		Assignee:    input.Assignee,
+		Project:     input.Project,
+		DueAt:       nullTime(input.DueAt),
+	}
+	id, _, err := r.service.Task.CreateTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	return r.task(ctx, id)
+}
+
+// UpdateTask is the resolver for the updateTask field.
+func (r *mutationResolver) UpdateTask(ctx context.Context, id string, input model.UpdateTask) (*entity.Task, error) {
+	if err := r.contains(ctx, entity.ResourceTask, id); err != nil {
+		return nil, err
+	}
+
+	task := entity.Task{
+		Title:       value(input.Title),
+		Description: value(input.Description),
+		Priority:    value(input.Priority),
+		Status:      value(input.Status),
+		Assignee:    value(input.Assignee),
+		DueAt:       nullTime(input.DueAt),
+	}
+	if task.Title == "" && task.Description == "" && task.Priority == "" && task.Status == "" && task.Assignee == "" && !task.DueAt.Valid {
+		return nil, fmt.Errorf("%w: nothing to update", errInvalidInput)
+	}
+	if task.Assignee != "" {
+		if err := r.reference(ctx, entity.ResourceUser, task.Assignee); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.service.UpdateTask(ctx, id, task); err != nil {
+		return nil, err
+	}
+	return r.task(ctx, id)
+}
+
+// DeleteTask is the resolver for the deleteTask field.
+func (r *mutationResolver) DeleteTask(ctx context.Context, id string) (bool, error) {
+	if err := r.contains(ctx, entity.ResourceTask, id); err != nil {
+		return false, err
+	}
+
+	if err := r.service.DeleteTask(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FinishedAt is the resolver for the finishedAt field.
+func (r *projectResolver) FinishedAt(ctx context.Context, obj *entity.Project) (*time.Time, error) {
+	return timePtr(obj.FinishedAt), nil
+}
+
+// StartAt is the resolver for the startAt field.
+func (r *projectResolver) StartAt(ctx context.Context, obj *entity.Project) (*time.Time, error) {
+	return timePtr(obj.StartAt), nil
+}
+
+// Archived is the resolver for the archived field.
+func (r *projectResolver) Archived(ctx context.Context, obj *entity.Project) (bool, error) {
+	return obj.FinishedAt.Valid, nil
+}
+
+// Manager is the resolver for the manager field.
+func (r *projectResolver) Manager(ctx context.Context, obj *entity.Project) (*entity.User, error) {
+	return loadersFrom(ctx).users.Load(ctx, obj.Manager)()
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *projectResolver) Tasks(ctx context.Context, obj *entity.Project, first *int, offset *int) ([]entity.Task, error) {
+	tasks, err := loadersFrom(ctx).projectTasks.Load(ctx, obj.ID)()
+	return page(tasks, first, offset), err
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id string) (*entity.User, error) {
+	if err := r.contains(ctx, entity.ResourceUser, id); err != nil {
+		return nil, nothing(err)
+	}
+	return loadersFrom(ctx).users.Load(ctx, id)()
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, first *int, offset *int) ([]entity.User, error) {
+	users, err := r.service.GetAllUsers(ctx, organization(ctx))
+	return page(users, first, offset), err
+}
+
+// Project is the resolver for the project field.
+func (r *queryResolver) Project(ctx context.Context, id string) (*entity.Project, error) {
+	if err := r.contains(ctx, entity.ResourceProject, id); err != nil {
+		return nil, nothing(err)
+	}
+	return loadersFrom(ctx).projects.Load(ctx, id)()
+}
+
+// Projects is the resolver for the projects field.
+func (r *queryResolver) Projects(ctx context.Context, filter *model.ProjectFilter, first *int, offset *int) ([]entity.Project, error) {
+	if filter == nil {
+		filter = &model.ProjectFilter{}
+	}
+	orgId, includeArchived := organization(ctx), value(filter.IncludeArchived)
+
+	var projects []entity.Project
+	var err error
+	if filter.Manager != nil {
+		projects, err = r.service.GetProjectByManagerId(ctx, orgId, *filter.Manager, includeArchived)
+	} else {
+		projects, err = r.service.GetAllProjects(ctx, orgId, includeArchived)
+	}
+	return page(projects, first, offset), err
+}
+
+// Task is the resolver for the task field.
+func (r *queryResolver) Task(ctx context.Context, id string) (*entity.Task, error) {
+	if err := r.contains(ctx, entity.ResourceTask, id); err != nil {
+		return nil, nothing(err)
+	}
+	return r.task(ctx, id)
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *queryResolver) Tasks(ctx context.Context, filter *model.TaskFilter, first *int, offset *int) ([]entity.Task, error) {
+	if filter == nil {
+		filter = &model.TaskFilter{}
+	}
+	orgId, includeArchived := organization(ctx), value(filter.IncludeArchived)
+
+	var tasks []entity.Task
+	var err error
+	switch {
+	case filter.Project != nil:
+		if err := r.contains(ctx, entity.ResourceProject, *filter.Project); err != nil {
+			return nil, nothing(err)
+		}
+		tasks, err = r.service.GetTasksByProjectId(ctx, *filter.Project)
+	case filter.Assignee != nil:
+		tasks, err = r.service.GetTasksByUserId(ctx, orgId, *filter.Assignee, "assignee", includeArchived)
+	case filter.Status != nil:
+		tasks, err = r.service.GetTaskByStatus(ctx, orgId, *filter.Status, includeArchived)
+	case filter.Priority != nil:
+		tasks, err = r.service.GetTaskByPriority(ctx, orgId, *filter.Priority, includeArchived)
+	default:
+		tasks, err = r.service.GetAllTasks(ctx, orgId, includeArchived)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	matching := make([]entity.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if matches(task, filter) {
+			matching = append(matching, task)
+		}
+	}
+	return page(matching, first, offset), nil
+}
+
+// FinishedAt is the resolver for the finishedAt field.
+func (r *taskResolver) FinishedAt(ctx context.Context, obj *entity.Task) (*time.Time, error) {
+	return timePtr(obj.FinishedAt), nil
+}
+
+// DueAt is the resolver for the dueAt field.
+func (r *taskResolver) DueAt(ctx context.Context, obj *entity.Task) (*time.Time, error) {
+	return timePtr(obj.DueAt), nil
+}
+
+// Assignee is the resolver for the assignee field.
+func (r *taskResolver) Assignee(ctx context.Context, obj *entity.Task) (*entity.User, error) {
+	return loadersFrom(ctx).users.Load(ctx, obj.Assignee)()
+}
+
+// Project is the resolver for the project field.
+func (r *taskResolver) Project(ctx context.Context, obj *entity.Task) (*entity.Project, error) {
+	return loadersFrom(ctx).projects.Load(ctx, obj.Project)()
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *userResolver) Tasks(ctx context.Context, obj *entity.User, includeArchived *bool, first *int, offset *int) ([]entity.Task, error) {
+	tasks, err := loadersFrom(ctx).userTasks.Load(ctx, userTasksKey{user: obj.ID, includeArchived: value(includeArchived)})()
+	return page(tasks, first, offset), err
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Project returns ProjectResolver implementation.
+func (r *Resolver) Project() ProjectResolver { return &projectResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Task returns TaskResolver implementation.
+func (r *Resolver) Task() TaskResolver { return &taskResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type projectResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type taskResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
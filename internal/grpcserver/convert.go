@@ -0,0 +1,75 @@
+package grpcserver
+
+import (
+	"database/sql"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	pmv1 "github.com/Aytya/projects-manager-HL/pkg/api/pm/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toUser(user entity.User) *pmv1.User {
+	return &pmv1.User{
+		Id:           user.ID,
+		Name:         user.Name,
+		Email:        user.Email,
+		Role:         user.Role,
+		RegisteredAt: timestamppb.New(user.RegisteredAt),
+	}
+}
+
+func toProject(project entity.Project) *pmv1.Project {
+	return &pmv1.Project{
+		Id:           project.ID,
+		Title:        project.Title,
+		Description:  project.Description,
+		Manager:      project.Manager,
+		Organization: project.Organization,
+		CreatedAt:    timestamppb.New(project.CreatedAt),
+		FinishedAt:   toTimestamp(project.FinishedAt),
+		StartAt:      toTimestamp(project.StartAt),
+		Template:     project.Template,
+	}
+}
+
+func toTask(task entity.Task) *pmv1.Task {
+	return &pmv1.Task{
+		Id:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		Status:      task.Status,
+		Assignee:    task.Assignee,
+		Project:     task.Project,
+		CreatedAt:   timestamppb.New(task.CreatedAt),
+		FinishedAt:  toTimestamp(task.FinishedAt),
+		DueAt:       toTimestamp(task.DueAt),
+	}
+}
+
+func toTasks(tasks []entity.Task) *pmv1.ListTasksResponse {
+	response := &pmv1.ListTasksResponse{Tasks: make([]*pmv1.Task, 0, len(tasks))}
+	for _, task := range tasks {
+		response.Tasks = append(response.Tasks, toTask(task))
+	}
+	return response
+}
+
+var eventTypes = map[string]pmv1.TaskEvent_Type{
+	entity.TaskInserted: pmv1.TaskEvent_TYPE_CREATED,
+	entity.TaskUpdated:  pmv1.TaskEvent_TYPE_UPDATED,
+	entity.TaskDeleted:  pmv1.TaskEvent_TYPE_DELETED,
+}
+
+func toTimestamp(t sql.NullTime) *timestamppb.Timestamp {
+	if !t.Valid {
+		return nil
+	}
+	return timestamppb.New(t.Time)
+}
+
+func toNullTime(t *timestamppb.Timestamp) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.AsTime(), Valid: true}
+}
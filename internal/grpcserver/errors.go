@@ -0,0 +1,59 @@
+package grpcserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errNotFound = errors.New("not found")
+
+func invalidArgument(format string, args ...interface{}) error {
+	return status.Errorf(codes.InvalidArgument, format, args...)
+}
+
+func unaryErrors(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	return resp, toStatus(err)
+}
+
+func streamErrors(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return toStatus(handler(srv, ss))
+}
+
+// toStatus maps service errors to gRPC status codes the way the REST
+// handlers map them to HTTP statuses.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var code codes.Code
+	switch {
+	case errors.Is(err, errNotFound), errors.Is(err, sql.ErrNoRows), errors.Is(err, service.ErrOrganizationNotFound):
+		code = codes.NotFound
+	case errors.Is(err, service.ErrProjectArchived), errors.Is(err, service.ErrLastOwner):
+		code = codes.FailedPrecondition
+	case errors.Is(err, service.ErrUserExists):
+		code = codes.AlreadyExists
+	case errors.Is(err, service.ErrNotMember), errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrNotProjectManager):
+		code = codes.PermissionDenied
+	case errors.Is(err, service.ErrInvalidArchive), errors.Is(err, service.ErrInvalidOrganization),
+		errors.Is(err, service.ErrUnknownField), errors.Is(err, service.ErrInvalidFieldValue):
+		code = codes.InvalidArgument
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+	return status.Error(code, err.Error())
+}
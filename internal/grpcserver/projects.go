@@ -0,0 +1,126 @@
+package grpcserver
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	pmv1 "github.com/Aytya/projects-manager-HL/pkg/api/pm/v1"
+)
+
+type projectServer struct {
+	pmv1.UnimplementedProjectServiceServer
+	base
+}
+
+func (s *projectServer) CreateProject(ctx context.Context, req *pmv1.CreateProjectRequest) (*pmv1.Project, error) {
+	if req.Title == "" || req.Description == "" {
+		return nil, invalidArgument("title and description are required")
+	}
+	if err := s.reference(ctx, entity.ResourceUser, req.Manager); err != nil {
+		return nil, err
+	}
+
+	project := entity.Project{Title: req.Title, Description: req.Description, Manager: req.Manager, Organization: organization(ctx)}
+	id, _, err := s.service.Project.CreateProject(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return s.project(ctx, id)
+}
+
+func (s *projectServer) UpdateProject(ctx context.Context, req *pmv1.UpdateProjectRequest) (*pmv1.Project, error) {
+	if err := s.contains(ctx, entity.ResourceProject, req.Id); err != nil {
+		return nil, err
+	}
+
+	project := entity.Project{Title: req.GetTitle(), Description: req.GetDescription(), Manager: req.GetManager()}
+	if project.Title == "" && project.Description == "" && project.Manager == "" {
+		return nil, invalidArgument("nothing to update")
+	}
+	if project.Manager != "" {
+		if err := s.reference(ctx, entity.ResourceUser, project.Manager); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.service.UpdateProject(ctx, req.Id, project); err != nil {
+		return nil, err
+	}
+	return s.project(ctx, req.Id)
+}
+
+func (s *projectServer) DeleteProject(ctx context.Context, req *pmv1.DeleteProjectRequest) (*pmv1.DeleteProjectResponse, error) {
+	if err := s.contains(ctx, entity.ResourceProject, req.Id); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.DeleteProject(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &pmv1.DeleteProjectResponse{}, nil
+}
+
+func (s *projectServer) GetProject(ctx context.Context, req *pmv1.GetProjectRequest) (*pmv1.Project, error) {
+	if err := s.contains(ctx, entity.ResourceProject, req.Id); err != nil {
+		return nil, err
+	}
+	return s.project(ctx, req.Id)
+}
+
+func (s *projectServer) GetProjectByTitle(ctx context.Context, req *pmv1.GetProjectByTitleRequest) (*pmv1.Project, error) {
+	project, err := s.service.GetProjectByTitle(ctx, organization(ctx), req.Title)
+	if err != nil {
+		return nil, err
+	}
+	return toProject(project), nil
+}
+
+func (s *projectServer) ListProjects(ctx context.Context, req *pmv1.ListProjectsRequest) (*pmv1.ListProjectsResponse, error) {
+	var projects []entity.Project
+	var err error
+	if req.Manager != "" {
+		projects, err = s.service.GetProjectByManagerId(ctx, organization(ctx), req.Manager, req.IncludeArchived)
+	} else {
+		projects, err = s.service.GetAllProjects(ctx, organization(ctx), req.IncludeArchived)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := &pmv1.ListProjectsResponse{Projects: make([]*pmv1.Project, 0, len(projects))}
+	for _, project := range projects {
+		response.Projects = append(response.Projects, toProject(project))
+	}
+	return response, nil
+}
+
+func (s *projectServer) ListProjectTasks(ctx context.Context, req *pmv1.ListProjectTasksRequest) (*pmv1.ListTasksResponse, error) {
+	if err := s.contains(ctx, entity.ResourceProject, req.ProjectId); err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.service.GetTasksByProjectId(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	return toTasks(tasks), nil
+}
+
+func (s *projectServer) ArchiveProject(ctx context.Context, req *pmv1.ArchiveProjectRequest) (*pmv1.ArchiveProjectResponse, error) {
+	if err := s.contains(ctx, entity.ResourceProject, req.Id); err != nil {
+		return nil, err
+	}
+
+	closed, err := s.service.ArchiveProject(ctx, req.Id, caller(ctx), entity.ArchiveProject{Policy: req.Policy, Status: req.Status})
+	if err != nil {
+		return nil, err
+	}
+	return &pmv1.ArchiveProjectResponse{Closed: closed}, nil
+}
+
+func (s *projectServer) project(ctx context.Context, id string) (*pmv1.Project, error) {
+	project, err := s.service.GetProjectById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toProject(project), nil
+}
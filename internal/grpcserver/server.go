@@ -0,0 +1,56 @@
+package grpcserver
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	pmv1 "github.com/Aytya/projects-manager-HL/pkg/api/pm/v1"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+)
+
+// New creates the gRPC server for the user, project and task services.
+// When token is set, clients must send it as a bearer token.
+func New(services *service.Service, feed *service.TaskFeed, token string) *grpc.Server {
+	auth := authenticator{service: services, token: token}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryErrors, auth.unary),
+		grpc.ChainStreamInterceptor(streamErrors, auth.stream),
+	)
+
+	pmv1.RegisterUserServiceServer(server, &userServer{base: base{service: services}})
+	pmv1.RegisterProjectServiceServer(server, &projectServer{base: base{service: services}})
+	pmv1.RegisterTaskServiceServer(server, &taskServer{base: base{service: services}, feed: feed})
+	return server
+}
+
+type base struct {
+	service *service.Service
+}
+
+// contains checks that the resource belongs to the organization of the
+// call; ids of other organizations are reported as not found.
+func (b base) contains(ctx context.Context, resource, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return errNotFound
+	}
+
+	ok, err := b.service.Contains(ctx, organization(ctx), resource, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errNotFound
+	}
+	return nil
+}
+
+// reference checks an id given in a request.
+func (b base) reference(ctx context.Context, resource, id string) error {
+	if err := b.contains(ctx, resource, id); err != nil {
+		if err == errNotFound {
+			return invalidArgument("%s %q is not in the organization", resource, id)
+		}
+		return err
+	}
+	return nil
+}
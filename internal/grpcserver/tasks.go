@@ -0,0 +1,178 @@
+package grpcserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	pmv1 "github.com/Aytya/projects-manager-HL/pkg/api/pm/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type taskServer struct {
+	pmv1.UnimplementedTaskServiceServer
+	base
+	feed *service.TaskFeed
+}
+
+func (s *taskServer) CreateTask(ctx context.Context, req *pmv1.CreateTaskRequest) (*pmv1.Task, error) {
+	if req.Title == "" || req.Description == "" || req.Priority == "" || req.Status == "" {
+		return nil, invalidArgument("title, description, priority and status are required")
+	}
+	if err := s.reference(ctx, entity.ResourceProject, req.Project); err != nil {
+		return nil, err
+	}
+	if err := s.reference(ctx, entity.ResourceUser, req.Assignee); err != nil {
+		return nil, err
+	}
+
+	task := entity.Task{
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    req.Priority,
+		Status:      req.Status,
+		Assignee:    req.Assignee,
+		Project:     req.Project,
+		DueAt:       toNullTime(req.DueAt),
+	}
+	id, _, err := s.service.Task.CreateTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	return s.task(ctx, id)
+}
+
+func (s *taskServer) UpdateTask(ctx context.Context, req *pmv1.UpdateTaskRequest) (*pmv1.Task, error) {
+	if err := s.contains(ctx, entity.ResourceTask, req.Id); err != nil {
+		return nil, err
+	}
+
+	task := entity.Task{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Priority:    req.GetPriority(),
+		Status:      req.GetStatus(),
+		Assignee:    req.GetAssignee(),
+		FinishedAt:  toNullTime(req.FinishedAt),
+		DueAt:       toNullTime(req.DueAt),
+	}
+	if task.Title == "" && task.Description == "" && task.Priority == "" && task.Status == "" && task.Assignee == "" && !task.FinishedAt.Valid && !task.DueAt.Valid {
+		return nil, invalidArgument("nothing to update")
+	}
+	if task.Assignee != "" {
+		if err := s.reference(ctx, entity.ResourceUser, task.Assignee); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.service.UpdateTask(ctx, req.Id, task); err != nil {
+		return nil, err
+	}
+	return s.task(ctx, req.Id)
+}
+
+func (s *taskServer) DeleteTask(ctx context.Context, req *pmv1.DeleteTaskRequest) (*pmv1.DeleteTaskResponse, error) {
+	if err := s.contains(ctx, entity.ResourceTask, req.Id); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.DeleteTask(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &pmv1.DeleteTaskResponse{}, nil
+}
+
+func (s *taskServer) GetTask(ctx context.Context, req *pmv1.GetTaskRequest) (*pmv1.Task, error) {
+	if err := s.contains(ctx, entity.ResourceTask, req.Id); err != nil {
+		return nil, err
+	}
+	return s.task(ctx, req.Id)
+}
+
+func (s *taskServer) GetTaskByTitle(ctx context.Context, req *pmv1.GetTaskByTitleRequest) (*pmv1.Task, error) {
+	task, err := s.service.GetTaskByTitle(ctx, organization(ctx), req.Title)
+	if err != nil {
+		return nil, err
+	}
+	return toTask(task), nil
+}
+
+func (s *taskServer) ListTasks(ctx context.Context, req *pmv1.ListTasksRequest) (*pmv1.ListTasksResponse, error) {
+	orgId := organization(ctx)
+
+	var tasks []entity.Task
+	var err error
+	switch {
+	case req.Assignee != "":
+		tasks, err = s.service.GetTasksByUserId(ctx, orgId, req.Assignee, "assignee", req.IncludeArchived)
+	case req.Status != "":
+		tasks, err = s.service.GetTaskByStatus(ctx, orgId, req.Status, req.IncludeArchived)
+	case req.Priority != "":
+		tasks, err = s.service.GetTaskByPriority(ctx, orgId, req.Priority, req.IncludeArchived)
+	default:
+		tasks, err = s.service.GetAllTasks(ctx, orgId, req.IncludeArchived)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	matching := make([]entity.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if (req.Status == "" || task.Status == req.Status) && (req.Priority == "" || task.Priority == req.Priority) {
+			matching = append(matching, task)
+		}
+	}
+	return toTasks(matching), nil
+}
+
+// WatchTasks sends the changes to the tasks of the organization as the
+// database announces them. A client that cannot keep up, or whose stream is
+// ended by a shutdown, is disconnected and should watch again.
+func (s *taskServer) WatchTasks(req *pmv1.WatchTasksRequest, stream pmv1.TaskService_WatchTasksServer) error {
+	ctx := stream.Context()
+	events, cancel := s.feed.Subscribe(organization(ctx))
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.feed.Done():
+			return status.Error(codes.Unavailable, "server is shutting down, watch again")
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "too many pending task events, watch again")
+			}
+			if (req.Project != "" && event.Project != req.Project) || (req.Assignee != "" && event.Assignee != req.Assignee) {
+				continue
+			}
+
+			message := &pmv1.TaskEvent{Type: eventTypes[event.Type], TaskId: event.ID}
+			if event.Type != entity.TaskDeleted {
+				task, err := s.service.GetTaskById(ctx, event.ID)
+				if errors.Is(err, sql.ErrNoRows) {
+					// Deleted since; its delete event follows.
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				message.Task = toTask(task)
+			}
+
+			if err := stream.Send(message); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *taskServer) task(ctx context.Context, id string) (*pmv1.Task, error) {
+	task, err := s.service.GetTaskById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toTask(task), nil
+}
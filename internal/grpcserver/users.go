@@ -0,0 +1,95 @@
+package grpcserver
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	pmv1 "github.com/Aytya/projects-manager-HL/pkg/api/pm/v1"
+)
+
+type userServer struct {
+	pmv1.UnimplementedUserServiceServer
+	base
+}
+
+func (s *userServer) CreateUser(ctx context.Context, req *pmv1.CreateUserRequest) (*pmv1.User, error) {
+	if req.Name == "" || req.Email == "" || req.Role == "" {
+		return nil, invalidArgument("name, email and role are required")
+	}
+
+	id, _, err := s.service.CreateUser(ctx, organization(ctx), entity.User{Name: req.Name, Email: req.Email, Role: req.Role})
+	if err != nil {
+		return nil, err
+	}
+	return s.user(ctx, id)
+}
+
+func (s *userServer) UpdateUser(ctx context.Context, req *pmv1.UpdateUserRequest) (*pmv1.User, error) {
+	if err := s.contains(ctx, entity.ResourceUser, req.Id); err != nil {
+		return nil, err
+	}
+
+	user := entity.User{Name: req.GetName(), Email: req.GetEmail(), Role: req.GetRole()}
+	if user.Name == "" && user.Email == "" && user.Role == "" {
+		return nil, invalidArgument("nothing to update")
+	}
+
+	if err := s.service.UpdateUser(ctx, organization(ctx), caller(ctx), req.Id, user); err != nil {
+		return nil, err
+	}
+	return s.user(ctx, req.Id)
+}
+
+func (s *userServer) DeleteUser(ctx context.Context, req *pmv1.DeleteUserRequest) (*pmv1.DeleteUserResponse, error) {
+	if err := s.contains(ctx, entity.ResourceUser, req.Id); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.service.DeleteUser(ctx, organization(ctx), caller(ctx), req.Id); err != nil {
+		return nil, err
+	}
+	return &pmv1.DeleteUserResponse{}, nil
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *pmv1.GetUserRequest) (*pmv1.User, error) {
+	if err := s.contains(ctx, entity.ResourceUser, req.Id); err != nil {
+		return nil, err
+	}
+	return s.user(ctx, req.Id)
+}
+
+func (s *userServer) GetUserByName(ctx context.Context, req *pmv1.GetUserByNameRequest) (*pmv1.User, error) {
+	user, err := s.service.GetUserByName(ctx, organization(ctx), req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+func (s *userServer) GetUserByEmail(ctx context.Context, req *pmv1.GetUserByEmailRequest) (*pmv1.User, error) {
+	user, err := s.service.GetUserByEmail(ctx, organization(ctx), req.Email)
+	if err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+func (s *userServer) ListUsers(ctx context.Context, req *pmv1.ListUsersRequest) (*pmv1.ListUsersResponse, error) {
+	users, err := s.service.GetAllUsers(ctx, organization(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	response := &pmv1.ListUsersResponse{Users: make([]*pmv1.User, 0, len(users))}
+	for _, user := range users {
+		response.Users = append(response.Users, toUser(user))
+	}
+	return response, nil
+}
+
+func (s *userServer) user(ctx context.Context, id string) (*pmv1.User, error) {
+	user, err := s.service.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ArchiveProject
+// @Summary      archive project
+// @Description  Finishes the project and its open tasks in one transaction. The close policy marks open tasks as Done, cancel marks them as Cancelled; status, Done or Cancelled, overrides either. Only the project manager may archive it. Tasks of an archived project cannot be changed until it is unarchived.
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Param        X-User-ID header string true "Current user ID"
+// @Param        input body entity.ArchiveProject true "Archive policy"
+// @Success      200  {object}  map[string]int64
+// @Failure      400  {object}  response.Object
+// @Failure      403  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/archive [post]
+func (h *Handler) archiveProject(c *gin.Context) {
+	var input entity.ArchiveProject
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	closed, err := h.service.ArchiveProject(c.Request.Context(), c.Param("id"), currentUser(c), input)
+	if err != nil {
+		archiveError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"closed": closed})
+}
+
+// UnarchiveProject
+// @Summary      unarchive project
+// @Description  Reopens an archived project for changes. Only the project manager may unarchive it.
+// @Tags         projects
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Param        X-User-ID header string true "Current user ID"
+// @Success      200  {string}  ""message": "Project unarchived""
+// @Failure      401  {object}  response.Object
+// @Failure      403  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/unarchive [post]
+func (h *Handler) unarchiveProject(c *gin.Context) {
+	if err := h.service.UnarchiveProject(c.Request.Context(), c.Param("id"), currentUser(c)); err != nil {
+		archiveError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project unarchived"})
+}
+
+func archiveError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidArchive):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotProjectManager):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+	case errors.Is(err, service.ErrProjectArchived):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// archivedProject answers 409 when a task write was rejected because its
+// project is archived.
+func archivedProject(c *gin.Context, err error) bool {
+	if !errors.Is(err, service.ErrProjectArchived) {
+		return false
+	}
+
+	c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	return true
+}
+
+// includeArchived reports whether a listing should also show archived
+// projects and their tasks.
+func includeArchived(c *gin.Context) bool {
+	return c.Query("include_archived") == "true"
+}
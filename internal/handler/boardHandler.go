@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// MoveTask
+// @Summary      move a task card on the board
+// @Description  Places the task after "after" and/or before "before", which must be tasks of the target column and adjacent when both are given. "status" moves the card to another column; with no neighbors the card goes to the bottom.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Task ID"
+// @Param        input body entity.MoveTask true "New position"
+// @Success      200  {string}  ""message": "Task moved""
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /tasks/{id}/move [post]
+func (h *Handler) moveTask(c *gin.Context) {
+	var input entity.MoveTask
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.MoveTask(c.Request.Context(), c.Param(taskId), input); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		case errors.Is(err, service.ErrInvalidMove), errors.Is(err, service.ErrProjectArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task moved"})
+}
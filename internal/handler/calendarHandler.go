@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+const calendarToken = "token"
+
+// @Summary create calendar feed token for user's tasks
+// @Tags users
+// @Produce json
+// @Param id path string true "user id"
+// @Success 201 {object} entity.CalendarToken
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /users/{id}/calendar [post]
+func (h *Handler) createUserCalendar(c *gin.Context) {
+	id := c.Param(userId)
+	if _, err := h.service.User.GetUserById(c.Request.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.createCalendarToken(c, entity.CalendarScopeUser, id)
+}
+
+// CreateProjectCalendar
+// @Summary      create calendar feed token for project's tasks
+// @Tags         projects
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Success      201  {object}	entity.CalendarToken
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/calendar [post]
+func (h *Handler) createProjectCalendar(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := h.service.GetProjectById(c.Request.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.createCalendarToken(c, entity.CalendarScopeProject, id)
+}
+
+func (h *Handler) createCalendarToken(c *gin.Context, scope, target string) {
+	token, err := h.service.CreateCalendarToken(c.Request.Context(), currentOrganization(c), scope, target)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar token", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token": token,
+		"url":   fmt.Sprintf("/calendar/%s.ics", token.Token),
+	})
+}
+
+// GetCalendar
+// @Summary      iCalendar feed of tasks
+// @Tags         calendar
+// @Produce      text/calendar
+// @Param        token path string true "Calendar token, optionally with .ics suffix"
+// @Success      200  {string}  string
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /calendar/{token} [get]
+func (h *Handler) getCalendar(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param(calendarToken), ".ics")
+
+	feed, err := h.service.RenderCalendar(c.Request.Context(), token)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCalendarToken) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render calendar", "message": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", feed)
+}
+
+// RevokeCalendar
+// @Summary      revoke calendar feed token
+// @Tags         calendar
+// @Produce      json
+// @Param        token path string true "Calendar token"
+// @Success      200  {string}  ""message": "Calendar token revoked""
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /calendar/{token} [delete]
+func (h *Handler) revokeCalendar(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param(calendarToken), ".ics")
+
+	if err := h.service.RevokeCalendarToken(c.Request.Context(), token); err != nil {
+		if errors.Is(err, service.ErrInvalidCalendarToken) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar token revoked"})
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const fieldId = "id"
+
+// CreateCustomField
+// @Summary      define a custom field for the project's tasks
+// @Description  type is one of text, number, date, select, multi_select or user; select fields need options.
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Param        input body entity.CustomField true "Custom field"
+// @Success      201  {object}  entity.CustomField
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/fields [post]
+func (h *Handler) createCustomField(c *gin.Context) {
+	projectId := c.Param("id")
+	if _, err := h.service.GetProjectById(c.Request.Context(), projectId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input entity.CustomField
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	field, err := h.service.CreateField(c.Request.Context(), projectId, input)
+	if err != nil {
+		customFieldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, field)
+}
+
+// GetCustomFields
+// @Summary      custom fields of the project
+// @Tags         projects
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Success      200  {array}   entity.CustomField
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/fields [get]
+func (h *Handler) getCustomFields(c *gin.Context) {
+	fields, err := h.service.GetFields(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"fields": fields})
+}
+
+// UpdateCustomField
+// @Summary      rename a custom field or change its options
+// @Tags         fields
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Field ID"
+// @Param        input body entity.CustomFieldUpdate true "Custom field"
+// @Success      200  {string}  ""message": "Field updated""
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /fields/{id} [put]
+func (h *Handler) updateCustomField(c *gin.Context) {
+	var input entity.CustomFieldUpdate
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateField(c.Request.Context(), c.Param(fieldId), input); err != nil {
+		customFieldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Field updated"})
+}
+
+// DeleteCustomField
+// @Summary      delete a custom field and its values
+// @Tags         fields
+// @Produce      json
+// @Param        id path string true "Field ID"
+// @Success      200  {string}  ""message": "Field deleted""
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /fields/{id} [delete]
+func (h *Handler) deleteCustomField(c *gin.Context) {
+	if err := h.service.DeleteField(c.Request.Context(), c.Param(fieldId)); err != nil {
+		customFieldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Field deleted"})
+}
+
+// SetTaskFields
+// @Summary      set custom field values of a task
+// @Description  Keys are field names or ids; null clears a value.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Task ID"
+// @Param        input body map[string]interface{} true "Values"
+// @Success      200  {string}  ""message": "Fields updated""
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /tasks/{id}/fields [put]
+func (h *Handler) setTaskFields(c *gin.Context) {
+	var input map[string]interface{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.SetTaskFields(c.Request.Context(), c.Param(taskId), input); err != nil {
+		customFieldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fields updated"})
+}
+
+func customFieldError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidField), errors.Is(err, service.ErrUnknownField), errors.Is(err, service.ErrInvalidFieldValue):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrProjectArchived):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GetDashboard
+// @Summary      dashboard of the current user
+// @Tags         me
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Success      200  {object}	entity.Dashboard
+// @Failure      401  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /me/dashboard [get]
+func (h *Handler) getDashboard(c *gin.Context) {
+	dashboard, err := h.service.GetDashboard(c.Request.Context(), currentOrganization(c), currentUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get dashboard", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"html/template"
+	"net/http"
+)
+
+// unsubscribePage asks to confirm the unsubscribe link, so that link
+// scanners and prefetchers following it do not turn emails off.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<body>
+<form method="post" action="?user={{.User}}&amp;token={{.Token}}">
+<p>Stop receiving notification emails?</p>
+<button type="submit">Unsubscribe</button>
+</form>
+</body>
+</html>
+`))
+
+// GetEmailPreference
+// @Summary      email mode of the current user
+// @Tags         me
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Success      200  {object}  entity.EmailPreference
+// @Failure      401  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /me/email [get]
+func (h *Handler) getEmailPreference(c *gin.Context) {
+	mode, err := h.service.GetEmailMode(c.Request.Context(), currentUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.EmailPreference{Mode: mode})
+}
+
+// UpdateEmailPreference
+// @Summary      switch emails of the current user between instant, digest and off
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Param        input body entity.EmailPreference true "Email mode"
+// @Success      200  {string}  ""message": "Email preference updated""
+// @Failure      400  {object}  response.Object
+// @Failure      401  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /me/email [put]
+func (h *Handler) updateEmailPreference(c *gin.Context) {
+	var input entity.EmailPreference
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.SetEmailMode(c.Request.Context(), currentUser(c), input.Mode); err != nil {
+		if errors.Is(err, service.ErrUnknownEmailMode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email preference updated"})
+}
+
+// UnsubscribePage
+// @Summary      confirmation page for the link in a notification email
+// @Tags         me
+// @Produce      html
+// @Param        user query string true "User ID"
+// @Param        token query string true "Unsubscribe token"
+// @Success      200  {string}  string
+// @Router       /unsubscribe [get]
+func (h *Handler) unsubscribePage(c *gin.Context) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	unsubscribePage.Execute(c.Writer, gin.H{"User": c.Query("user"), "Token": c.Query("token")})
+}
+
+// Unsubscribe
+// @Summary      turn emails off from the link in a notification email
+// @Tags         me
+// @Produce      json
+// @Param        user query string true "User ID"
+// @Param        token query string true "Unsubscribe token"
+// @Success      200  {string}  ""message": "Unsubscribed from emails""
+// @Failure      400  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /unsubscribe [post]
+func (h *Handler) unsubscribe(c *gin.Context) {
+	if err := h.service.Unsubscribe(c.Request.Context(), c.Query("user"), c.Query("token")); err != nil {
+		if errors.Is(err, service.ErrInvalidUnsubscribe) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed from emails"})
+}
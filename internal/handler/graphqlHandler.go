@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/gin-gonic/gin"
+)
+
+// GraphQL
+// @Summary      GraphQL endpoint
+// @Description  Queries and mutations for users, projects and tasks of the current organization, see internal/graph/schema.graphqls. Related users and projects are fetched in batches; queries above the configured complexity are rejected.
+// @Tags         graphql
+// @Accept       json
+// @Produce      json
+// @Param        X-Organization header string false "Organization id or slug"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      422  {object}  map[string]interface{}
+// @Router       /graphql [post]
+func (h *Handler) graphqlQuery(c *gin.Context) {
+	ctx := graph.WithOrganization(c.Request.Context(), currentOrganization(c))
+	ctx = graph.WithUser(ctx, currentUser(c))
+	h.graphql.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+}
@@ -1,46 +1,71 @@
 package handler
 
 import (
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/graph"
 	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"log/slog"
 
 	_ "github.com/Aytya/projects-manager-HL/docs"
 )
 
 type Handler struct {
 	service *service.Service
+	domain  string
+	graphql *graph.Server
+	logger  *slog.Logger
 }
 
-func NewHandler(service *service.Service) *Handler {
-	return &Handler{service: service}
+// NewHandler creates the handlers; domain is the base host name whose
+// subdomains select an organization and may be empty, limits bound the
+// GraphQL queries and logger receives the access log.
+func NewHandler(service *service.Service, domain string, limits graph.Limits, logger *slog.Logger) *Handler {
+	return &Handler{service: service, domain: domain, graphql: graph.NewServer(service, limits), logger: logger}
 }
 
 func (h *Handler) InitRoutes() *gin.Engine {
 	router := gin.New()
+	router.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(traced)), h.requestLogger, instrument, h.recovery)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/metrics", gin.WrapH(promhttp.Handler())) //метрики для Prometheus.
+	router.GET("/healthz", h.getHealth)                   //проверить, что процесс жив.
+	router.GET("/readyz", h.getReadiness)                 //проверить готовность принимать запросы.
 
-	user := router.Group("/users")
+	user := router.Group("/users", h.organization, h.scoped(entity.ResourceUser))
 	{
-		user.POST("/", h.createUser)                //создать нового пользователя.
-		user.GET("/", h.getAllUsers)                //получить список всех пользователей.
-		user.GET("/:id", h.getUserById)             //получить данные конкретного пользователя.
-		user.PUT("/:id", h.updateUser)              //обновить данные конкретного пользователя.
-		user.DELETE("/:id", h.deleteUser)           //удалить конкретного пользователя.
-		user.GET("/:id/tasks", h.getTasksByUser)    //получить список задач конкретного пользователя.
-		user.GET("/search/name", h.getUserByName)   //найти пользователей по имени.
-		user.GET("/search/email", h.getUserByEmail) //найти пользователей по электронной почте.
+		user.POST("/", h.createUser)                          //создать нового пользователя.
+		user.GET("/", h.getAllUsers)                          //получить список всех пользователей.
+		user.GET("/:id", h.getUserById)                       //получить данные конкретного пользователя.
+		user.PUT("/:id", h.updateUser)                        //обновить данные конкретного пользователя.
+		user.DELETE("/:id", h.deleteUser)                     //удалить конкретного пользователя.
+		user.GET("/:id/tasks", h.getTasksByUser)              //получить список задач конкретного пользователя.
+		user.POST("/:id/tasks/reassign", h.reassignUserTasks) //передать открытые задачи пользователя другому.
+		user.POST("/:id/calendar", h.createUserCalendar)      //получить ссылку на календарь задач пользователя.
+		user.GET("/search/name", h.getUserByName)             //найти пользователей по имени.
+		user.GET("/search/email", h.getUserByEmail)           //найти пользователей по электронной почте.
 	}
 
-	task := router.Group("/tasks")
+	task := router.Group("/tasks", h.organization, h.scoped(entity.ResourceTask))
 	{
 		task.GET("/", h.getAllTasks)                                //получить список всех задач.
 		task.POST("/", h.createTask)                                //создать новую задачу.
+		task.POST("/bulk", h.bulkTasks)                             //создать, обновить и удалить задачи одной транзакцией.
 		task.GET("/:id", h.getTaskById)                             //получить данные конкретной задачи.
 		task.PUT("/:id", h.updateTaskById)                          //обновить данные конкретной задачи.
 		task.DELETE("/:id", h.deleteTaskById)                       //удалить конкретную задачу.
+		task.POST("/:id/move", h.moveTask)                          //переместить карточку задачи на доске.
+		task.PUT("/:id/fields", h.setTaskFields)                    //задать значения пользовательских полей задачи.
+		task.POST("/:id/recurrence", h.setRecurrence)               //сделать задачу повторяющейся.
+		task.GET("/:id/series", h.getTaskSeries)                    //получить серию повторяющейся задачи.
+		task.PUT("/:id/series", h.updateTaskSeries)                 //изменить серию начиная с этой задачи.
+		task.DELETE("/:id/series", h.stopTaskSeries)                //остановить повторение задачи.
 		task.GET("/search", h.getTaskByTitle)                       //найти задачи по названию.
 		task.GET("/search/status", h.getTaskByStatus)               //найти задачи по состоянию.
 		task.GET("/search/priority", h.getTaskByPriority)           //найти задачи по приоритету.
@@ -48,16 +73,99 @@ func (h *Handler) InitRoutes() *gin.Engine {
 		task.GET("/search/project/:project", h.getTasksByProjectId) //найти задачи по идентификатору проекта.
 	}
 
-	project := router.Group("/projects")
+	project := router.Group("/projects", h.organization, h.scoped(entity.ResourceProject))
 	{
-		project.GET("/", h.getListOfProjects)              //получить список всех проектов.
-		project.POST("/", h.createProject)                 //создать новый проект.
-		project.GET("/:id", h.getProjectById)              //получить данные конкретного проекта.
-		project.PUT("/:id", h.updateProject)               //обновить данные конкретного проекта.
-		project.DELETE("/:id", h.deleteProject)            //удалить конкретный проект.
-		project.GET("/:id/tasks", h.getProjectTasks)       //получить список задач в проекте.
-		project.GET("/search/:title", h.getProjectByTitle) //найти проекты по названию.
-		project.GET("/search", h.getProjectByManagerId)    //найти проекты по идентификатору менеджера.
+		project.GET("/", h.getListOfProjects)                              //получить список всех проектов.
+		project.POST("/", h.createProject)                                 //создать новый проект.
+		project.GET("/templates", h.getTemplates)                          //получить шаблоны проектов.
+		project.GET("/:id", h.getProjectById)                              //получить данные конкретного проекта.
+		project.PUT("/:id", h.updateProject)                               //обновить данные конкретного проекта.
+		project.POST("/:id/clone", h.cloneProject)                         //скопировать проект или создать проект по шаблону.
+		project.POST("/:id/template", h.saveAsTemplate)                    //сохранить проект как шаблон.
+		project.POST("/:id/archive", h.archiveProject)                     //архивировать проект и закрыть его открытые задачи.
+		project.POST("/:id/unarchive", h.userIdentity, h.unarchiveProject) //вернуть проект из архива.
+		project.DELETE("/:id", h.deleteProject)                            //удалить конкретный проект.
+		project.GET("/:id/tasks", h.getProjectTasks)                       //получить список задач в проекте.
+		project.GET("/:id/tasks/export", h.exportProjectTasks)             //выгрузить задачи проекта в csv или xlsx.
+		project.POST("/:id/tasks/import", h.importProjectTasks)            //загрузить задачи проекта из csv или xlsx.
+		project.POST("/:id/calendar", h.createProjectCalendar)             //получить ссылку на календарь задач проекта.
+		project.GET("/:id/reports/burndown", h.getBurndown)                //диаграмма сгорания задач проекта.
+		project.GET("/:id/reports/cfd", h.getCumulativeFlow)               //накопительная диаграмма потока.
+		project.GET("/:id/reports/throughput", h.getThroughput)            //количество завершённых задач за интервал.
+		project.POST("/:id/sprints", h.createSprint)                       //создать спринт в проекте.
+		project.GET("/:id/sprints", h.getProjectSprints)                   //получить спринты проекта.
+		project.POST("/:id/milestones", h.createMilestone)                 //создать веху в проекте.
+		project.GET("/:id/milestones", h.getProjectMilestones)             //получить вехи проекта с прогрессом.
+		project.POST("/:id/fields", h.createCustomField)                   //создать пользовательское поле проекта.
+		project.GET("/:id/fields", h.getCustomFields)                      //получить пользовательские поля проекта.
+		project.GET("/search/:title", h.getProjectByTitle)                 //найти проекты по названию.
+		project.GET("/search", h.getProjectByManagerId)                    //найти проекты по идентификатору менеджера.
+	}
+
+	sprint := router.Group("/sprints", h.organization, h.scoped(entity.ResourceSprint))
+	{
+		sprint.GET("/:id", h.getSprint)                       //получить данные спринта.
+		sprint.PUT("/:id", h.updateSprint)                    //обновить данные спринта.
+		sprint.DELETE("/:id", h.deleteSprint)                 //удалить спринт.
+		sprint.POST("/:id/start", h.startSprint)              //начать спринт.
+		sprint.POST("/:id/close", h.closeSprint)              //закрыть спринт и перенести незавершённые задачи.
+		sprint.GET("/:id/tasks", h.getSprintTasks)            //получить задачи спринта.
+		sprint.POST("/:id/tasks", h.addSprintTasks)           //добавить задачи в спринт.
+		sprint.DELETE("/:id/tasks/:task", h.removeSprintTask) //убрать задачу из спринта.
+		sprint.GET("/:id/report", h.getSprintReport)          //отчёт по спринту.
+	}
+
+	milestone := router.Group("/milestones", h.organization, h.scoped(entity.ResourceMilestone))
+	{
+		milestone.GET("/:id", h.getMilestone)                       //получить веху с прогрессом.
+		milestone.PUT("/:id", h.updateMilestone)                    //обновить веху.
+		milestone.DELETE("/:id", h.deleteMilestone)                 //удалить веху.
+		milestone.GET("/:id/tasks", h.getMilestoneTasks)            //получить задачи вехи.
+		milestone.POST("/:id/tasks", h.addMilestoneTasks)           //добавить задачи в веху.
+		milestone.DELETE("/:id/tasks/:task", h.removeMilestoneTask) //убрать задачу из вехи.
+	}
+
+	field := router.Group("/fields", h.organization, h.scoped(entity.ResourceField))
+	{
+		field.PUT("/:id", h.updateCustomField)    //изменить пользовательское поле.
+		field.DELETE("/:id", h.deleteCustomField) //удалить пользовательское поле.
+	}
+
+	me := router.Group("/me", h.userIdentity)
+	{
+		me.GET("/dashboard", h.organization, h.getDashboard)                  //сводка задач и проектов текущего пользователя.
+		me.GET("/organizations", h.getMyOrganizations)                        //организации текущего пользователя.
+		me.GET("/notifications", h.getNotifications)                          //уведомления текущего пользователя.
+		me.GET("/notifications/unread-count", h.getUnreadCount)               //количество непрочитанных уведомлений.
+		me.POST("/notifications/:id/read", h.markNotificationRead)            //отметить уведомление прочитанным.
+		me.POST("/notifications/read-all", h.markAllNotificationsRead)        //отметить все уведомления прочитанными.
+		me.GET("/notifications/preferences", h.getNotificationPreferences)    //настройки уведомлений.
+		me.PUT("/notifications/preferences", h.updateNotificationPreferences) //изменить настройки уведомлений.
+		me.GET("/email", h.getEmailPreference)                                //режим email-уведомлений.
+		me.PUT("/email", h.updateEmailPreference)                             //изменить режим email-уведомлений.
+	}
+
+	organization := router.Group("/organizations", h.userIdentity)
+	{
+		organization.POST("/", h.createOrganization)                          //создать организацию.
+		organization.GET("/:id", h.getOrganization)                           //получить данные организации.
+		organization.PUT("/:id", h.updateOrganization)                        //обновить организацию.
+		organization.DELETE("/:id", h.deleteOrganization)                     //удалить организацию со всеми проектами.
+		organization.GET("/:id/members", h.getOrganizationMembers)            //получить участников организации.
+		organization.PUT("/:id/members/:user", h.setOrganizationMember)       //добавить участника или изменить его роль.
+		organization.DELETE("/:id/members/:user", h.removeOrganizationMember) //исключить участника.
+	}
+
+	router.GET("/graphql", h.organization, h.graphqlQuery)  //выполнить запрос GraphQL.
+	router.POST("/graphql", h.organization, h.graphqlQuery) //выполнить запрос или мутацию GraphQL.
+
+	router.GET("/unsubscribe", h.unsubscribePage) //подтвердить отписку по ссылке из письма.
+	router.POST("/unsubscribe", h.unsubscribe)    //отписаться от email-уведомлений.
+
+	calendar := router.Group("/calendar")
+	{
+		calendar.GET("/:token", h.getCalendar)       //календарь задач в формате iCalendar.
+		calendar.DELETE("/:token", h.revokeCalendar) //отозвать ссылку на календарь.
 	}
 
 	return router
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GetHealth
+// @Summary      liveness of the process
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Router       /healthz [get]
+func (h *Handler) getHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": entity.HealthOK})
+}
+
+// GetReadiness
+// @Summary      readiness to serve traffic, by component
+// @Description  Fails when the database does not answer, migrations are missing, a background worker stopped or the server is shutting down.
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  entity.Readiness
+// @Failure      503  {object}  entity.Readiness
+// @Router       /readyz [get]
+func (h *Handler) getReadiness(c *gin.Context) {
+	readiness := h.service.GetReadiness(c.Request.Context())
+	if readiness.Status != entity.HealthOK {
+		c.JSON(http.StatusServiceUnavailable, readiness)
+		return
+	}
+
+	c.JSON(http.StatusOK, readiness)
+}
@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/logging"
+	"github.com/Aytya/projects-manager-HL/internal/metrics"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	userIdHeader       = "X-User-ID"
+	organizationHeader = "X-Organization"
+	requestIdHeader    = "X-Request-ID"
+	userCtx            = "userId"
+	organizationCtx    = "organizationId"
+	roleCtx            = "organizationRole"
+)
+
+// requestIdPattern limits the request ids taken over from clients, since they
+// end up in logs.
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestLogger gives every request an id, taken from X-Request-ID or
+// generated, returns it in the same header and puts a logger carrying it into
+// the request context for the service and repository layers. When the
+// request is done it logs one line with its outcome.
+func (h *Handler) requestLogger(c *gin.Context) {
+	start := time.Now()
+
+	id := c.GetHeader(requestIdHeader)
+	if !requestIdPattern.MatchString(id) {
+		id = uuid.NewString()
+	}
+	c.Header(requestIdHeader, id)
+
+	logger := h.logger.With("request_id", id)
+	if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+		logger = logger.With("trace_id", span.TraceID().String())
+	}
+	c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), logger))
+
+	c.Next()
+
+	status := c.Writer.Status()
+	level := slog.LevelInfo
+	switch {
+	case status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case status >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", c.Request.Method),
+		slog.String("route", c.FullPath()),
+		slog.String("path", c.Request.URL.Path),
+		slog.Int("status", status),
+		slog.Duration("latency", time.Since(start)),
+		slog.Int("size", c.Writer.Size()),
+		slog.String("client_ip", c.ClientIP()),
+	}
+	if user := c.GetHeader(userIdHeader); user != "" {
+		attrs = append(attrs, slog.String("user", user))
+	}
+	if len(c.Errors) > 0 {
+		attrs = append(attrs, slog.String("errors", c.Errors.String()))
+	}
+	logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+}
+
+// traced leaves scrapes of /metrics and health probes out of the traces.
+func traced(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/metrics", "/healthz", "/readyz":
+		return false
+	}
+	return true
+}
+
+// instrument counts and times requests by route, so that the paths of one
+// route share their metrics.
+func instrument(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	metrics.ObserveRequest(c.Request.Method, route, strconv.Itoa(c.Writer.Status()), time.Since(start))
+}
+
+// recovery turns a panic in a handler into a JSON 500 and logs it with the
+// stack. Aborted connections are re-panicked as net/http expects.
+func (h *Handler) recovery(c *gin.Context) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+
+		logging.FromContext(c.Request.Context()).Error("panic while handling request",
+			"panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+		if c.Writer.Written() {
+			c.Abort()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}()
+
+	c.Next()
+}
+
+// userIdentity resolves the current user from the X-User-ID header for the
+// routes that act on behalf of the caller.
+func (h *Handler) userIdentity(c *gin.Context) {
+	id := c.GetHeader(userIdHeader)
+	if id == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "X-User-ID header is required"})
+		return
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid UUID format for user"})
+		return
+	}
+
+	c.Set(userCtx, id)
+	c.Next()
+}
+
+func currentUser(c *gin.Context) string {
+	return c.GetString(userCtx)
+}
+
+// organization resolves the current organization from the X-Organization
+// header (id or slug) or the subdomain of the request, falling back to the
+// default organization. Callers must identify themselves with X-User-ID and
+// be members of it.
+func (h *Handler) organization(c *gin.Context) {
+	userId := c.GetHeader(userIdHeader)
+	if userId == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "X-User-ID header is required"})
+		return
+	}
+
+	if _, err := uuid.Parse(userId); err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid UUID format for user"})
+		return
+	}
+
+	key := c.GetHeader(organizationHeader)
+	if key == "" {
+		key = subdomain(c.Request.Host, h.domain)
+	}
+
+	organization, err := h.service.ResolveOrganization(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, service.ErrOrganizationNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.service.GetRole(c.Request.Context(), organization.ID, userId)
+	if err != nil {
+		if errors.Is(err, service.ErrNotMember) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Set(userCtx, userId)
+	c.Set(organizationCtx, organization.ID)
+	c.Set(roleCtx, role)
+	c.Next()
+}
+
+func currentOrganization(c *gin.Context) string {
+	return c.GetString(organizationCtx)
+}
+
+// currentRole is the role of the current user in the current organization.
+func currentRole(c *gin.Context) string {
+	return c.GetString(roleCtx)
+}
+
+// subdomain returns the label in front of domain, e.g. "acme" for
+// acme.example.com when domain is example.com.
+func subdomain(host, domain string) string {
+	if domain == "" {
+		return ""
+	}
+
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+
+	label, found := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(domain))
+	if !found || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// scoped answers 404 for requests whose :id names a resource of another
+// organization. Routes without an id are scoped by their queries instead.
+func (h *Handler) scoped(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := uuid.Parse(id); err != nil {
+			c.Next()
+			return
+		}
+
+		if !h.inOrganization(c, resource, id, http.StatusNotFound) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// inOrganization checks that the resource belongs to the current
+// organization and answers with status when it does not.
+func (h *Handler) inOrganization(c *gin.Context, resource, id string, status int) bool {
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid UUID format for %s", resource)})
+		return false
+	}
+
+	ok, err := h.service.Contains(c.Request.Context(), currentOrganization(c), resource, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if !ok {
+		c.JSON(status, gin.H{"error": fmt.Sprintf("%s %s not found in the organization", resource, id)})
+		return false
+	}
+	return true
+}
+
+// taskReferences checks the project and assignee a task points to.
+func (h *Handler) taskReferences(c *gin.Context, task entity.Task) bool {
+	if task.Project != "" && !h.inOrganization(c, entity.ResourceProject, task.Project, http.StatusBadRequest) {
+		return false
+	}
+	if task.Assignee != "" && !h.inOrganization(c, entity.ResourceUser, task.Assignee, http.StatusBadRequest) {
+		return false
+	}
+	return true
+}
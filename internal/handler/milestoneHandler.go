@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const milestoneId = "id"
+
+// CreateMilestone
+// @Summary      create a milestone in the project
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Param        input body entity.Milestone true "Milestone"
+// @Success      201  {object}  entity.Milestone
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/milestones [post]
+func (h *Handler) createMilestone(c *gin.Context) {
+	projectId := c.Param("id")
+	if _, err := h.service.GetProjectById(c.Request.Context(), projectId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input entity.Milestone
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	milestone, err := h.service.CreateMilestone(c.Request.Context(), projectId, input)
+	if err != nil {
+		milestoneError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, milestone)
+}
+
+// GetProjectMilestones
+// @Summary      milestones of the project with progress
+// @Description  Milestones past their target date with open tasks are flagged as overdue.
+// @Tags         projects
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Param        overdue query bool false "only overdue milestones"
+// @Success      200  {array}   entity.MilestoneProgress
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/milestones [get]
+func (h *Handler) getProjectMilestones(c *gin.Context) {
+	overdueOnly, _ := strconv.ParseBool(c.Query("overdue"))
+
+	milestones, err := h.service.GetProjectMilestones(c.Request.Context(), c.Param("id"), overdueOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"milestones": milestones})
+}
+
+// GetMilestone
+// @Summary      get milestone with progress
+// @Tags         milestones
+// @Produce      json
+// @Param        id path string true "Milestone ID"
+// @Success      200  {object}  entity.MilestoneProgress
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /milestones/{id} [get]
+func (h *Handler) getMilestone(c *gin.Context) {
+	milestone, err := h.service.GetMilestone(c.Request.Context(), c.Param(milestoneId))
+	if err != nil {
+		milestoneError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, milestone)
+}
+
+// UpdateMilestone
+// @Summary      update title, description or target date of a milestone
+// @Tags         milestones
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Milestone ID"
+// @Param        input body entity.Milestone true "Milestone"
+// @Success      200  {string}  ""message": "Milestone updated""
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /milestones/{id} [put]
+func (h *Handler) updateMilestone(c *gin.Context) {
+	var input entity.Milestone
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateMilestone(c.Request.Context(), c.Param(milestoneId), input); err != nil {
+		milestoneError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Milestone updated"})
+}
+
+// DeleteMilestone
+// @Summary      delete milestone; its tasks are kept
+// @Tags         milestones
+// @Produce      json
+// @Param        id path string true "Milestone ID"
+// @Success      200  {string}  ""message": "Milestone deleted""
+// @Failure      500  {object}  response.Object
+// @Router       /milestones/{id} [delete]
+func (h *Handler) deleteMilestone(c *gin.Context) {
+	if err := h.service.DeleteMilestone(c.Request.Context(), c.Param(milestoneId)); err != nil {
+		milestoneError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Milestone deleted"})
+}
+
+// GetMilestoneTasks
+// @Summary      tasks in the milestone
+// @Tags         milestones
+// @Produce      json
+// @Param        id path string true "Milestone ID"
+// @Success      200  {array}   entity.Task
+// @Failure      500  {object}  response.Object
+// @Router       /milestones/{id}/tasks [get]
+func (h *Handler) getMilestoneTasks(c *gin.Context) {
+	tasks, err := h.service.GetMilestoneTasks(c.Request.Context(), c.Param(milestoneId))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+// AddMilestoneTasks
+// @Summary      put tasks of the milestone's project into the milestone
+// @Tags         milestones
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Milestone ID"
+// @Param        input body entity.MilestoneTasks true "Task IDs"
+// @Success      200  {object}  map[string]int64
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /milestones/{id}/tasks [post]
+func (h *Handler) addMilestoneTasks(c *gin.Context) {
+	var input entity.MilestoneTasks
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	added, err := h.service.AddMilestoneTasks(c.Request.Context(), c.Param(milestoneId), input.Tasks)
+	if err != nil {
+		milestoneError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"added": added})
+}
+
+// RemoveMilestoneTask
+// @Summary      take a task out of the milestone
+// @Tags         milestones
+// @Produce      json
+// @Param        id path string true "Milestone ID"
+// @Param        task path string true "Task ID"
+// @Success      200  {string}  ""message": "Task removed from milestone""
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /milestones/{id}/tasks/{task} [delete]
+func (h *Handler) removeMilestoneTask(c *gin.Context) {
+	if err := h.service.RemoveMilestoneTask(c.Request.Context(), c.Param(milestoneId), c.Param("task")); err != nil {
+		milestoneError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task removed from milestone"})
+}
+
+func milestoneError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMilestone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTaskNotInMilestone), errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+func pagination(c *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
+
+// GetNotifications
+// @Summary      notifications of the current user
+// @Tags         me
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Param        unread query bool false "only unread notifications"
+// @Param        limit query int false "page size" default(50)
+// @Param        offset query int false "page offset"
+// @Success      200  {array}	entity.Notification
+// @Failure      401  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /me/notifications [get]
+func (h *Handler) getNotifications(c *gin.Context) {
+	unreadOnly, _ := strconv.ParseBool(c.Query("unread"))
+	limit, offset := pagination(c)
+
+	notifications, err := h.service.GetNotifications(c.Request.Context(), currentUser(c), unreadOnly, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": notifications})
+}
+
+// GetUnreadCount
+// @Summary      number of unread notifications of the current user
+// @Tags         me
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Success      200  {object}  map[string]int
+// @Failure      401  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /me/notifications/unread-count [get]
+func (h *Handler) getUnreadCount(c *gin.Context) {
+	count, err := h.service.CountUnread(c.Request.Context(), currentUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": count})
+}
+
+// MarkNotificationRead
+// @Summary      mark notification as read
+// @Tags         me
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Param        id path string true "Notification ID"
+// @Success      200  {string}  ""message": "Notification marked as read""
+// @Failure      401  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /me/notifications/{id}/read [post]
+func (h *Handler) markNotificationRead(c *gin.Context) {
+	if err := h.service.MarkRead(c.Request.Context(), currentUser(c), c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllNotificationsRead
+// @Summary      mark all notifications as read
+// @Tags         me
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Success      200  {object}  map[string]int64
+// @Failure      401  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /me/notifications/read-all [post]
+func (h *Handler) markAllNotificationsRead(c *gin.Context) {
+	count, err := h.service.MarkAllRead(c.Request.Context(), currentUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"read": count})
+}
+
+// GetNotificationPreferences
+// @Summary      notification preferences of the current user
+// @Tags         me
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Success      200  {array}	entity.NotificationPreference
+// @Failure      401  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /me/notifications/preferences [get]
+func (h *Handler) getNotificationPreferences(c *gin.Context) {
+	preferences, err := h.service.GetPreferences(c.Request.Context(), currentUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": preferences})
+}
+
+// UpdateNotificationPreferences
+// @Summary      update notification preferences of the current user
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Param        input body []entity.NotificationPreference true "Preferences"
+// @Success      200  {string}  ""message": "Preferences updated""
+// @Failure      400  {object}  response.Object
+// @Failure      401  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /me/notifications/preferences [put]
+func (h *Handler) updateNotificationPreferences(c *gin.Context) {
+	var input []entity.NotificationPreference
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.SetPreferences(c.Request.Context(), currentUser(c), input); err != nil {
+		if errors.Is(err, service.ErrUnknownNotification) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Preferences updated"})
+}
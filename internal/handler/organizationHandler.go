@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+const memberId = "user"
+
+// CreateOrganization
+// @Summary      create organization
+// @Description  The caller becomes its owner. The slug, used as subdomain and in the X-Organization header, is derived from the name when empty.
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Param        input body entity.Organization true "Organization"
+// @Success      201  {object}  entity.Organization
+// @Failure      400  {object}  response.Object
+// @Failure      401  {object}  response.Object
+// @Failure      409  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /organizations [post]
+func (h *Handler) createOrganization(c *gin.Context) {
+	var input entity.Organization
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	organization, err := h.service.CreateOrganization(c.Request.Context(), currentUser(c), input)
+	if err != nil {
+		organizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, organization)
+}
+
+// GetOrganization
+// @Summary      organization the caller is a member of
+// @Tags         organizations
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Param        id path string true "Organization ID"
+// @Success      200  {object}  entity.Organization
+// @Failure      401  {object}  response.Object
+// @Failure      403  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /organizations/{id} [get]
+func (h *Handler) getOrganization(c *gin.Context) {
+	if !h.member(c) {
+		return
+	}
+
+	organization, err := h.service.GetOrganization(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		organizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, organization)
+}
+
+// UpdateOrganization
+// @Summary      rename organization or change its slug
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Param        id path string true "Organization ID"
+// @Param        input body entity.Organization true "Organization"
+// @Success      200  {string}  ""message": "Organization updated""
+// @Failure      400  {object}  response.Object
+// @Failure      403  {object}  response.Object
+// @Failure      409  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /organizations/{id} [put]
+func (h *Handler) updateOrganization(c *gin.Context) {
+	var input entity.Organization
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.member(c) {
+		return
+	}
+
+	if err := h.service.UpdateOrganization(c.Request.Context(), c.Param("id"), currentUser(c), input); err != nil {
+		organizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Organization updated"})
+}
+
+// DeleteOrganization
+// @Summary      delete organization with its projects
+// @Tags         organizations
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Param        id path string true "Organization ID"
+// @Success      200  {string}  ""message": "Organization deleted""
+// @Failure      403  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /organizations/{id} [delete]
+func (h *Handler) deleteOrganization(c *gin.Context) {
+	if !h.member(c) {
+		return
+	}
+
+	if err := h.service.DeleteOrganization(c.Request.Context(), c.Param("id"), currentUser(c)); err != nil {
+		organizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Organization deleted"})
+}
+
+// GetOrganizationMembers
+// @Summary      members of the organization with their roles
+// @Tags         organizations
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Param        id path string true "Organization ID"
+// @Success      200  {array}   entity.Member
+// @Failure      403  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /organizations/{id}/members [get]
+func (h *Handler) getOrganizationMembers(c *gin.Context) {
+	if !h.member(c) {
+		return
+	}
+
+	members, err := h.service.GetMembers(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// SetOrganizationMember
+// @Summary      add a user to the organization or change their role
+// @Description  role is owner, admin or member. Admins manage members; only owners grant or revoke ownership.
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Param        id path string true "Organization ID"
+// @Param        user path string true "User ID"
+// @Param        input body entity.SetMember true "Role"
+// @Success      200  {string}  ""message": "Member saved""
+// @Failure      400  {object}  response.Object
+// @Failure      403  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /organizations/{id}/members/{user} [put]
+func (h *Handler) setOrganizationMember(c *gin.Context) {
+	var input entity.SetMember
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.member(c) {
+		return
+	}
+
+	if _, err := h.service.GetUserById(c.Request.Context(), c.Param(memberId)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.SetMember(c.Request.Context(), c.Param("id"), currentUser(c), c.Param(memberId), input.Role); err != nil {
+		organizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member saved"})
+}
+
+// RemoveOrganizationMember
+// @Summary      remove a user from the organization
+// @Tags         organizations
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Param        id path string true "Organization ID"
+// @Param        user path string true "User ID"
+// @Success      200  {string}  ""message": "Member removed""
+// @Failure      403  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /organizations/{id}/members/{user} [delete]
+func (h *Handler) removeOrganizationMember(c *gin.Context) {
+	if !h.member(c) {
+		return
+	}
+
+	if err := h.service.RemoveMember(c.Request.Context(), c.Param("id"), currentUser(c), c.Param(memberId)); err != nil {
+		organizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// GetMyOrganizations
+// @Summary      organizations of the current user with their role
+// @Tags         me
+// @Produce      json
+// @Param        X-User-ID header string true "Current user ID"
+// @Success      200  {array}   entity.UserOrganization
+// @Failure      401  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /me/organizations [get]
+func (h *Handler) getMyOrganizations(c *gin.Context) {
+	organizations, err := h.service.GetUserOrganizations(c.Request.Context(), currentUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"organizations": organizations})
+}
+
+// member lets through callers that belong to the organization in the path
+// and makes it the current organization.
+func (h *Handler) member(c *gin.Context) bool {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format for organization"})
+		return false
+	}
+
+	c.Set(organizationCtx, id)
+	return h.inOrganization(c, entity.ResourceUser, currentUser(c), http.StatusForbidden)
+}
+
+func organizationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidOrganization), errors.Is(err, service.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOrganizationNotFound), errors.Is(err, service.ErrNotMember):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrSlugTaken), errors.Is(err, service.ErrLastOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -22,9 +22,15 @@ func (h *Handler) createProject(c *gin.Context) {
 	var project entity.Project
 	if err := c.ShouldBindJSON(&project); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.inOrganization(c, entity.ResourceUser, project.Manager, http.StatusBadRequest) {
+		return
 	}
 
-	id, createdAt, err := h.service.Project.CreateProject(project)
+	project.Organization = currentOrganization(c)
+	id, createdAt, err := h.service.Project.CreateProject(c.Request.Context(), project)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
@@ -45,6 +51,7 @@ func (h *Handler) createProject(c *gin.Context) {
 // @Success      200  {string}  ""message": "Task updated""
 // @Failure      400  {object}  response.Object
 // @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
 // @Failure      500  {object}  response.Object
 // @Router       /projects/{id} [put]
 func (h *Handler) updateProject(c *gin.Context) {
@@ -55,7 +62,14 @@ func (h *Handler) updateProject(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UpdateProject(id, updatedProject); err != nil {
+	if updatedProject.Manager != "" && !h.inOrganization(c, entity.ResourceUser, updatedProject.Manager, http.StatusBadRequest) {
+		return
+	}
+
+	if err := h.service.UpdateProject(c.Request.Context(), id, updatedProject); err != nil {
+		if archivedProject(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -79,7 +93,7 @@ func (h *Handler) deleteProject(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
 	}
 
-	if err := h.service.DeleteProject(id); err != nil {
+	if err := h.service.DeleteProject(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
 
@@ -102,7 +116,7 @@ func (h *Handler) getProjectById(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
 	}
 
-	project, err := h.service.GetProjectById(id)
+	project, err := h.service.GetProjectById(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
@@ -114,13 +128,14 @@ func (h *Handler) getProjectById(c *gin.Context) {
 // @Summary      get all projects
 // @Tags         projects
 // @Produce      json
+// @Param        include_archived query bool false "include archived projects"
 // @Success      200  {array}	entity.Project
 // @Failure      400  {object}  response.Object
 // @Failure      404  {object}  response.Object
 // @Failure      500  {object}  response.Object
 // @Router       /projects [get]
 func (h *Handler) getListOfProjects(c *gin.Context) {
-	lists, err := h.service.GetAllProjects()
+	lists, err := h.service.GetAllProjects(c.Request.Context(), currentOrganization(c), includeArchived(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -134,6 +149,9 @@ func (h *Handler) getListOfProjects(c *gin.Context) {
 // @Tags         projects
 // @Produce      json
 // @Param        id path string true "Project ID"
+// @Param        field[name] query string false "custom field filter, e.g. field[severity]=high"
+// @Param        sort query string false "custom field to sort by"
+// @Param        order query string false "asc or desc"
 // @Success      200  {object}	entity.Task
 // @Failure      400  {object}  response.Object
 // @Failure      404  {object}  response.Object
@@ -159,7 +177,7 @@ func (h *Handler) getProjectByTitle(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "title is required"})
 	}
 
-	project, err := h.service.GetProjectByTitle(title)
+	project, err := h.service.GetProjectByTitle(c.Request.Context(), currentOrganization(c), title)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
@@ -177,6 +195,7 @@ func (h *Handler) getProjectByTitle(c *gin.Context) {
 // @Tags         projects
 // @Produce      json
 // @Param        manager query string true "Project Manager"
+// @Param        include_archived query bool false "include archived projects"
 // @Success      200  {object}	entity.Project
 // @Failure      400  {object}  response.Object
 // @Failure      404  {object}  response.Object
@@ -189,7 +208,7 @@ func (h *Handler) getProjectByManagerId(c *gin.Context) {
 
 	}
 
-	project, err := h.service.GetProjectByManagerId(id)
+	project, err := h.service.GetProjectByManagerId(c.Request.Context(), currentOrganization(c), id, includeArchived(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+const reportDateFormat = "2006-01-02"
+
+// reportRange reads interval, from and to query parameters. Without a range
+// the report covers the last 30 days or the last 12 weeks.
+func reportRange(c *gin.Context) (entity.ReportRange, error) {
+	period := entity.ReportRange{Interval: c.DefaultQuery("interval", entity.IntervalDay)}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	period.To = today
+	if to := c.Query("to"); to != "" {
+		parsed, err := time.Parse(reportDateFormat, to)
+		if err != nil {
+			return period, err
+		}
+		period.To = parsed
+	}
+
+	if period.Interval == entity.IntervalWeek {
+		period.From = period.To.AddDate(0, 0, -7*11)
+	} else {
+		period.From = period.To.AddDate(0, 0, -29)
+	}
+	if from := c.Query("from"); from != "" {
+		parsed, err := time.Parse(reportDateFormat, from)
+		if err != nil {
+			return period, err
+		}
+		period.From = parsed
+	}
+
+	return period, nil
+}
+
+func (h *Handler) projectReport(c *gin.Context, build func(projectId string, period entity.ReportRange) (interface{}, error)) {
+	id := c.Param("id")
+	period, err := reportRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dates must be in YYYY-MM-DD format", "message": err.Error()})
+		return
+	}
+
+	points, err := build(id, period)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidReportRange) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"range": period, "points": points})
+}
+
+// GetBurndown
+// @Summary      burndown of project's tasks
+// @Tags         reports
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Param        interval query string false "day or week" default(day)
+// @Param        from query string false "YYYY-MM-DD"
+// @Param        to query string false "YYYY-MM-DD"
+// @Success      200  {array}	entity.BurndownPoint
+// @Failure      400  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/reports/burndown [get]
+func (h *Handler) getBurndown(c *gin.Context) {
+	h.projectReport(c, func(projectId string, period entity.ReportRange) (interface{}, error) {
+		return h.service.Burndown(c.Request.Context(), projectId, period)
+	})
+}
+
+// GetCumulativeFlow
+// @Summary      cumulative flow of project's tasks by status
+// @Tags         reports
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Param        interval query string false "day or week" default(day)
+// @Param        from query string false "YYYY-MM-DD"
+// @Param        to query string false "YYYY-MM-DD"
+// @Success      200  {array}	entity.CumulativeFlowPoint
+// @Failure      400  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/reports/cfd [get]
+func (h *Handler) getCumulativeFlow(c *gin.Context) {
+	h.projectReport(c, func(projectId string, period entity.ReportRange) (interface{}, error) {
+		return h.service.CumulativeFlow(c.Request.Context(), projectId, period)
+	})
+}
+
+// GetThroughput
+// @Summary      number of project's tasks finished per interval
+// @Tags         reports
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Param        interval query string false "day or week" default(day)
+// @Param        from query string false "YYYY-MM-DD"
+// @Param        to query string false "YYYY-MM-DD"
+// @Success      200  {array}	entity.ThroughputPoint
+// @Failure      400  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/reports/throughput [get]
+func (h *Handler) getThroughput(c *gin.Context) {
+	h.projectReport(c, func(projectId string, period entity.ReportRange) (interface{}, error) {
+		return h.service.Throughput(c.Request.Context(), projectId, period)
+	})
+}
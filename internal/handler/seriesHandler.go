@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// SetRecurrence
+// @Summary      make the task the first occurrence of a recurring series
+// @Description  rule is an RRULE subset: FREQ=DAILY|WEEKLY|MONTHLY with INTERVAL, BYDAY, UNTIL or COUNT. trigger is "finish" (default) to create the next occurrence when this one is finished, or "schedule" to create it ahead of its date.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Task ID"
+// @Param        input body entity.Recurrence true "Recurrence"
+// @Success      201  {object}  entity.TaskSeries
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /tasks/{id}/recurrence [post]
+func (h *Handler) setRecurrence(c *gin.Context) {
+	var input entity.Recurrence
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series, err := h.service.SetRecurrence(c.Request.Context(), c.Param(taskId), input)
+	if err != nil {
+		seriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, series)
+}
+
+// GetTaskSeries
+// @Summary      recurring series the task belongs to
+// @Tags         tasks
+// @Produce      json
+// @Param        id path string true "Task ID"
+// @Success      200  {object}  entity.TaskSeries
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /tasks/{id}/series [get]
+func (h *Handler) getTaskSeries(c *gin.Context) {
+	series, err := h.service.GetTaskSeries(c.Request.Context(), c.Param(taskId))
+	if err != nil {
+		seriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// UpdateTaskSeries
+// @Summary      edit the series from this occurrence on
+// @Description  Unlike PUT /tasks/{id}, which only edits this occurrence, this changes every future occurrence and the unfinished ones already created from this one on.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Task ID"
+// @Param        input body entity.SeriesUpdate true "Series changes"
+// @Success      200  {string}  ""message": "Series updated""
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /tasks/{id}/series [put]
+func (h *Handler) updateTaskSeries(c *gin.Context) {
+	var input entity.SeriesUpdate
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Assignee != "" && !h.inOrganization(c, entity.ResourceUser, input.Assignee, http.StatusBadRequest) {
+		return
+	}
+
+	if err := h.service.UpdateSeries(c.Request.Context(), c.Param(taskId), input); err != nil {
+		seriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Series updated"})
+}
+
+// StopTaskSeries
+// @Summary      stop the series the task belongs to
+// @Description  Occurrences created so far are kept as ordinary tasks.
+// @Tags         tasks
+// @Produce      json
+// @Param        id path string true "Task ID"
+// @Success      200  {string}  ""message": "Series stopped""
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /tasks/{id}/series [delete]
+func (h *Handler) stopTaskSeries(c *gin.Context) {
+	if err := h.service.StopSeries(c.Request.Context(), c.Param(taskId)); err != nil {
+		seriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Series stopped"})
+}
+
+func seriesError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRecurrence):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyRecurring):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotRecurring), errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
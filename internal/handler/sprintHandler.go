@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const sprintId = "id"
+
+// CreateSprint
+// @Summary      create a sprint in the project
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Param        input body entity.Sprint true "Sprint"
+// @Success      201  {object}  entity.Sprint
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/sprints [post]
+func (h *Handler) createSprint(c *gin.Context) {
+	projectId := c.Param("id")
+	if _, err := h.service.GetProjectById(c.Request.Context(), projectId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input entity.Sprint
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sprint, err := h.service.CreateSprint(c.Request.Context(), projectId, input)
+	if err != nil {
+		sprintError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, sprint)
+}
+
+// GetProjectSprints
+// @Summary      sprints of the project ordered by start
+// @Tags         projects
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Success      200  {array}   entity.Sprint
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/sprints [get]
+func (h *Handler) getProjectSprints(c *gin.Context) {
+	sprints, err := h.service.GetProjectSprints(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sprints": sprints})
+}
+
+// GetSprint
+// @Summary      get sprint by id
+// @Tags         sprints
+// @Produce      json
+// @Param        id path string true "Sprint ID"
+// @Success      200  {object}  entity.Sprint
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /sprints/{id} [get]
+func (h *Handler) getSprint(c *gin.Context) {
+	sprint, err := h.service.GetSprint(c.Request.Context(), c.Param(sprintId))
+	if err != nil {
+		sprintError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sprint)
+}
+
+// UpdateSprint
+// @Summary      update name, goal, dates or capacity of a sprint
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Sprint ID"
+// @Param        input body entity.Sprint true "Sprint"
+// @Success      200  {string}  ""message": "Sprint updated""
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /sprints/{id} [put]
+func (h *Handler) updateSprint(c *gin.Context) {
+	var input entity.Sprint
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateSprint(c.Request.Context(), c.Param(sprintId), input); err != nil {
+		sprintError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sprint updated"})
+}
+
+// DeleteSprint
+// @Summary      delete sprint; its tasks go back to the backlog
+// @Tags         sprints
+// @Produce      json
+// @Param        id path string true "Sprint ID"
+// @Success      200  {string}  ""message": "Sprint deleted""
+// @Failure      500  {object}  response.Object
+// @Router       /sprints/{id} [delete]
+func (h *Handler) deleteSprint(c *gin.Context) {
+	if err := h.service.DeleteSprint(c.Request.Context(), c.Param(sprintId)); err != nil {
+		sprintError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sprint deleted"})
+}
+
+// StartSprint
+// @Summary      start a planned sprint
+// @Tags         sprints
+// @Produce      json
+// @Param        id path string true "Sprint ID"
+// @Success      200  {string}  ""message": "Sprint started""
+// @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /sprints/{id}/start [post]
+func (h *Handler) startSprint(c *gin.Context) {
+	if err := h.service.StartSprint(c.Request.Context(), c.Param(sprintId)); err != nil {
+		sprintError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sprint started"})
+}
+
+// CloseSprint
+// @Summary      close the active sprint and carry unfinished tasks over
+// @Description  Unfinished tasks move to the sprint given in "next", else to the project's next planned sprint, else back to the backlog.
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Sprint ID"
+// @Param        input body entity.CloseSprint false "Next sprint"
+// @Success      200  {object}  entity.CloseSprintResult
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /sprints/{id}/close [post]
+func (h *Handler) closeSprint(c *gin.Context) {
+	var input entity.CloseSprint
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.service.CloseSprint(c.Request.Context(), c.Param(sprintId), input)
+	if err != nil {
+		sprintError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetSprintTasks
+// @Summary      tasks in the sprint
+// @Tags         sprints
+// @Produce      json
+// @Param        id path string true "Sprint ID"
+// @Success      200  {array}   entity.Task
+// @Failure      500  {object}  response.Object
+// @Router       /sprints/{id}/tasks [get]
+func (h *Handler) getSprintTasks(c *gin.Context) {
+	tasks, err := h.service.GetSprintTasks(c.Request.Context(), c.Param(sprintId))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+// AddSprintTasks
+// @Summary      put tasks of the sprint's project into the sprint
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Sprint ID"
+// @Param        input body entity.SprintTasks true "Task IDs"
+// @Success      200  {object}  map[string]int64
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /sprints/{id}/tasks [post]
+func (h *Handler) addSprintTasks(c *gin.Context) {
+	var input entity.SprintTasks
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	added, err := h.service.AddSprintTasks(c.Request.Context(), c.Param(sprintId), input.Tasks)
+	if err != nil {
+		sprintError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"added": added})
+}
+
+// RemoveSprintTask
+// @Summary      move a task from the sprint back to the backlog
+// @Tags         sprints
+// @Produce      json
+// @Param        id path string true "Sprint ID"
+// @Param        task path string true "Task ID"
+// @Success      200  {string}  ""message": "Task removed from sprint""
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /sprints/{id}/tasks/{task} [delete]
+func (h *Handler) removeSprintTask(c *gin.Context) {
+	if err := h.service.RemoveSprintTask(c.Request.Context(), c.Param(sprintId), c.Param("task")); err != nil {
+		sprintError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task removed from sprint"})
+}
+
+// GetSprintReport
+// @Summary      committed vs completed work and scope changes of a sprint
+// @Tags         sprints
+// @Produce      json
+// @Param        id path string true "Sprint ID"
+// @Success      200  {object}  entity.SprintReport
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /sprints/{id}/report [get]
+func (h *Handler) getSprintReport(c *gin.Context) {
+	report, err := h.service.GetSprintReport(c.Request.Context(), c.Param(sprintId))
+	if err != nil {
+		sprintError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func sprintError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidSprint):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrSprintState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTaskNotInSprint), errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
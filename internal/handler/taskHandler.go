@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
@@ -14,6 +16,8 @@ const (
 	taskStatus   = "status"
 	taskPriority = "priority"
 	taskProject  = "project"
+	// maxBulkItems bounds a bulk request, which runs in one transaction.
+	maxBulkItems = 500
 )
 
 // CreateTask
@@ -25,6 +29,7 @@ const (
 // @Success      201  {object}	entity.Task
 // @Failure      400  {object}  response.Object
 // @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
 // @Failure      500  {object}  response.Object
 // @Router       /tasks [post]
 func (h *Handler) createTask(c *gin.Context) {
@@ -39,8 +44,19 @@ func (h *Handler) createTask(c *gin.Context) {
 		return
 	}
 
-	id, createdAt, err := h.service.Task.CreateTask(task)
+	if !h.taskReferences(c, task) {
+		return
+	}
+
+	id, createdAt, err := h.service.Task.CreateTask(c.Request.Context(), task)
 	if err != nil {
+		if errors.Is(err, service.ErrUnknownField) || errors.Is(err, service.ErrInvalidFieldValue) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if archivedProject(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task", "message": err.Error()})
 		return
 	}
@@ -65,7 +81,7 @@ func (h *Handler) getTaskById(c *gin.Context) {
 		return
 	}
 
-	task, err := h.service.GetTaskById(id)
+	task, err := h.service.GetTaskById(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get task", "message": err.Error()})
 		return
@@ -92,7 +108,7 @@ func (h *Handler) getTaskByTitle(c *gin.Context) {
 		return
 	}
 
-	task, err := h.service.GetTaskByTitle(title)
+	task, err := h.service.GetTaskByTitle(c.Request.Context(), currentOrganization(c), title)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get task", "message": err.Error()})
 		return
@@ -106,6 +122,7 @@ func (h *Handler) getTaskByTitle(c *gin.Context) {
 // @Tags         tasks
 // @Produce      json
 // @Param        status query string true "Status"
+// @Param        include_archived query bool false "include tasks of archived projects"
 // @Success      200  {object}	entity.Task
 // @Failure      400  {object}  response.Object
 // @Failure      404  {object}  response.Object
@@ -118,7 +135,7 @@ func (h *Handler) getTaskByStatus(c *gin.Context) {
 		return
 	}
 
-	task, err := h.service.GetTaskByStatus(status)
+	task, err := h.service.GetTaskByStatus(c.Request.Context(), currentOrganization(c), status, includeArchived(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get task", "message": err.Error()})
 		return
@@ -132,6 +149,7 @@ func (h *Handler) getTaskByStatus(c *gin.Context) {
 // @Tags         tasks
 // @Produce      json
 // @Param        priority query string true "Priority"
+// @Param        include_archived query bool false "include tasks of archived projects"
 // @Success      200  {object}	entity.Task
 // @Failure      400  {object}  response.Object
 // @Failure      404  {object}  response.Object
@@ -144,7 +162,7 @@ func (h *Handler) getTaskByPriority(c *gin.Context) {
 		return
 	}
 
-	task, err := h.service.GetTaskByPriority(priority)
+	task, err := h.service.GetTaskByPriority(c.Request.Context(), currentOrganization(c), priority, includeArchived(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get task", "message": err.Error()})
 		return
@@ -165,7 +183,7 @@ func (h *Handler) getTasksByUserId(c *gin.Context, column string, value string)
 		return
 	}
 
-	tasks, err := h.service.GetTasksByUserId(assignee, column)
+	tasks, err := h.service.GetTasksByUserId(c.Request.Context(), currentOrganization(c), assignee, column, includeArchived(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tasks", "message": err.Error()})
 		return
@@ -180,6 +198,7 @@ func (h *Handler) getTasksByUserId(c *gin.Context, column string, value string)
 // @Tags         tasks
 // @Produce      json
 // @Param        assignee path string true "Assignee ID"
+// @Param        include_archived query bool false "include tasks of archived projects"
 // @Success      200  {object}	entity.Task
 // @Failure      400  {object}  response.Object
 // @Failure      404  {object}  response.Object
@@ -196,8 +215,17 @@ func (h *Handler) getTaskByProjectId(c *gin.Context, paramName string) {
 		return
 	}
 
-	tasks, err := h.service.GetTasksByProjectId(projectId)
+	if !h.inOrganization(c, entity.ResourceProject, projectId, http.StatusNotFound) {
+		return
+	}
+
+	query := entity.TaskListQuery{Fields: c.QueryMap("field"), Sort: c.Query("sort"), Desc: c.Query("order") == "desc"}
+	tasks, err := h.service.GetProjectTasks(c.Request.Context(), projectId, query)
 	if err != nil {
+		if errors.Is(err, service.ErrUnknownField) || errors.Is(err, service.ErrInvalidFieldValue) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tasks", "message": err.Error()})
 		return
 	}
@@ -223,13 +251,14 @@ func (h *Handler) getTasksByProjectId(c *gin.Context) {
 // @Summary      get all tasks
 // @Tags         tasks
 // @Produce      json
+// @Param        include_archived query bool false "include tasks of archived projects"
 // @Success      200  {array}	entity.Task
 // @Failure      400  {object}  response.Object
 // @Failure      404  {object}  response.Object
 // @Failure      500  {object}  response.Object
 // @Router       /tasks [get]
 func (h *Handler) getAllTasks(c *gin.Context) {
-	lists, err := h.service.GetAllTasks()
+	lists, err := h.service.GetAllTasks(c.Request.Context(), currentOrganization(c), includeArchived(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get all tasks", "message": err.Error()})
 		return
@@ -248,6 +277,7 @@ func (h *Handler) getAllTasks(c *gin.Context) {
 // @Success      200  {string}  ""message": "Task updated""
 // @Failure      400  {object}  response.Object
 // @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
 // @Failure      500  {object}  response.Object
 // @Router       /tasks/{id} [put]
 func (h *Handler) updateTaskById(c *gin.Context) {
@@ -259,9 +289,17 @@ func (h *Handler) updateTaskById(c *gin.Context) {
 	var input entity.Task
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := h.service.UpdateTask(id, input); err != nil {
+	if !h.taskReferences(c, input) {
+		return
+	}
+
+	if err := h.service.UpdateTask(c.Request.Context(), id, input); err != nil {
+		if archivedProject(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task", "message": err.Error()})
 		return
 	}
@@ -277,6 +315,7 @@ func (h *Handler) updateTaskById(c *gin.Context) {
 // @Success      200  "No content"
 // @Failure      400  {object}  response.Object
 // @Failure      404  {object}  response.Object
+// @Failure      409  {object}  response.Object
 // @Failure      500  {object}  response.Object
 // @Router       /tasks/{id} [delete]
 func (h *Handler) deleteTaskById(c *gin.Context) {
@@ -285,9 +324,61 @@ func (h *Handler) deleteTaskById(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
 	}
 
-	if err := h.service.DeleteTask(id); err != nil {
+	if err := h.service.DeleteTask(c.Request.Context(), id); err != nil {
+		if archivedProject(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task", "message": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusNoContent, gin.H{})
 }
+
+// BulkTasks
+// @Summary      create, update and delete tasks in one transaction
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Param        input body entity.BulkTaskRequest true "Bulk operations"
+// @Success      200  {object}  object{results=[]entity.BulkTaskResult}
+// @Failure      400  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /tasks/bulk [post]
+func (h *Handler) bulkTasks(c *gin.Context) {
+	var input entity.BulkTaskRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(input.Items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "items are required"})
+		return
+	}
+	if len(input.Items) > maxBulkItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d items are allowed", maxBulkItems)})
+		return
+	}
+
+	for _, item := range input.Items {
+		if item.ID != "" && !h.inOrganization(c, entity.ResourceTask, item.ID, http.StatusBadRequest) {
+			return
+		}
+		if !h.taskReferences(c, item.Task) {
+			return
+		}
+	}
+
+	results, err := h.service.BulkTasks(c.Request.Context(), input.Items, input.Atomic)
+	if err != nil {
+		if results != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bulk operation rolled back", "message": err.Error(), "results": results})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply bulk operation", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var exportContentTypes = map[string]string{
+	entity.FormatCSV:  "text/csv",
+	entity.FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportProjectTasks
+// @Summary      export project's tasks as csv or xlsx
+// @Description  csv is streamed as tasks are read; xlsx is built in memory and sent once complete.
+// @Tags         projects
+// @Produce      octet-stream
+// @Param        id path string true "Project ID"
+// @Param        format query string false "csv or xlsx" default(csv)
+// @Success      200  {file}    file
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/tasks/export [get]
+func (h *Handler) exportProjectTasks(c *gin.Context) {
+	id := c.Param("id")
+	format := c.DefaultQuery("format", entity.FormatCSV)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrUnsupportedFormat.Error()})
+		return
+	}
+
+	if _, err := h.service.GetProjectById(c.Request.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	w := &attachmentWriter{c: c, contentType: contentType, filename: fmt.Sprintf("tasks-%s.%s", id, format)}
+	if err := h.service.ExportTasks(c.Request.Context(), id, format, w); err != nil {
+		if !c.Writer.Written() {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export tasks", "message": err.Error()})
+			return
+		}
+		c.Error(err)
+	}
+}
+
+// attachmentWriter sets the download headers on the first write, so an
+// export that fails before producing anything is still answered with JSON.
+type attachmentWriter struct {
+	c           *gin.Context
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (w *attachmentWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, w.filename))
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// ImportProjectTasks
+// @Summary      import tasks into project from csv or xlsx
+// @Tags         projects
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Param        file formData file true "CSV or XLSX file"
+// @Param        columns formData string false "JSON object mapping file headers to task fields"
+// @Param        format query string false "csv or xlsx, taken from the file extension by default"
+// @Param        dry_run query bool false "validate without importing"
+// @Success      201  {object}	entity.TaskImportReport
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      422  {object}  entity.TaskImportReport
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/tasks/import [post]
+func (h *Handler) importProjectTasks(c *gin.Context) {
+	id := c.Param("id")
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required", "message": err.Error()})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	}
+
+	columns := make(map[string]string)
+	if raw := c.PostForm("columns"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &columns); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid columns mapping", "message": err.Error()})
+			return
+		}
+	}
+
+	if _, err := h.service.GetProjectById(c.Request.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	report, err := h.service.ImportTasks(c.Request.Context(), currentOrganization(c), id, format, file, columns, dryRun)
+	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedFormat) || errors.Is(err, service.ErrInvalidFile) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import tasks", "message": err.Error()})
+		return
+	}
+
+	switch {
+	case len(report.Errors) > 0:
+		c.JSON(http.StatusUnprocessableEntity, report)
+	case dryRun:
+		c.JSON(http.StatusOK, report)
+	default:
+		c.JSON(http.StatusCreated, report)
+	}
+}
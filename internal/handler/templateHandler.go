@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GetTemplates
+// @Summary      project templates of the organization
+// @Tags         projects
+// @Produce      json
+// @Success      200  {array}   entity.Project
+// @Failure      500  {object}  response.Object
+// @Router       /projects/templates [get]
+func (h *Handler) getTemplates(c *gin.Context) {
+	templates, err := h.service.GetTemplates(c.Request.Context(), currentOrganization(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+// SaveAsTemplate
+// @Summary      save project as template
+// @Description  Copies the project with its tasks, custom fields and milestones into a template.
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Param        input body entity.SaveTemplate false "Template title"
+// @Success      201  {object}  entity.Project
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/template [post]
+func (h *Handler) saveAsTemplate(c *gin.Context) {
+	var input entity.SaveTemplate
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	template, err := h.service.SaveAsTemplate(c.Request.Context(), c.Param("id"), input.Title)
+	if err != nil {
+		cloneError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// CloneProject
+// @Summary      clone project or instantiate template
+// @Description  Copies the project, its tasks, custom fields and milestones in one transaction. Due dates keep their distance from the project start, counted from start_at (today by default); assignees maps old assignees to new ones.
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Project ID"
+// @Param        input body entity.CloneProject false "Clone options"
+// @Success      201  {object}  entity.Project
+// @Failure      400  {object}  response.Object
+// @Failure      404  {object}  response.Object
+// @Failure      500  {object}  response.Object
+// @Router       /projects/{id}/clone [post]
+func (h *Handler) cloneProject(c *gin.Context) {
+	var input entity.CloneProject
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if input.Manager != "" && !h.inOrganization(c, entity.ResourceUser, input.Manager, http.StatusBadRequest) {
+		return
+	}
+	for _, assignee := range input.Assignees {
+		if !h.inOrganization(c, entity.ResourceUser, assignee, http.StatusBadRequest) {
+			return
+		}
+	}
+
+	project, err := h.service.CloneProject(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		cloneError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, project)
+}
+
+func cloneError(c *gin.Context, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
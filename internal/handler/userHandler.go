@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"net/http"
 )
 
@@ -22,6 +25,7 @@ const (
 // @Param		request	body		entity.User	true	"body param"
 // @Success	200		{object}	entity.User
 // @Failure	404	{object}	response.Object
+// @Failure	409	{object}	response.Object
 // @Failure	500		{object}	response.Object
 // @Router       /users [post]
 func (h *Handler) createUser(c *gin.Context) {
@@ -31,7 +35,11 @@ func (h *Handler) createUser(c *gin.Context) {
 		return
 	}
 
-	id, createdAt, err := h.service.User.CreateUser(newUser)
+	id, createdAt, err := h.service.User.CreateUser(c.Request.Context(), currentOrganization(c), newUser)
+	if errors.Is(err, service.ErrUserExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user", "message": err.Error()})
 		return
@@ -70,7 +78,7 @@ func (h *Handler) getUserById(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.User.GetUserById(id)
+	user, err := h.service.User.GetUserById(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -79,14 +87,14 @@ func (h *Handler) getUserById(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
-func (h *Handler) getUser(c *gin.Context, queryParam, errorMessage string, getUserFunc func(string) (entity.User, error)) {
+func (h *Handler) getUser(c *gin.Context, queryParam, errorMessage string, getUserFunc func(context.Context, string, string) (entity.User, error)) {
 	value := c.Query(queryParam)
 	if value == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage})
 		return
 	}
 
-	user, err := getUserFunc(value)
+	user, err := getUserFunc(c.Request.Context(), currentOrganization(c), value)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
@@ -145,8 +153,8 @@ func (h *Handler) updateUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.User.UpdateUser(id, input); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.User.UpdateUser(c.Request.Context(), currentOrganization(c), currentUser(c), id, input); err != nil {
+		organizationError(c, err)
 		return
 	}
 
@@ -168,11 +176,16 @@ func (h *Handler) deleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.User.DeleteUser(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	deleted, err := h.service.User.DeleteUser(c.Request.Context(), currentOrganization(c), currentUser(c), id)
+	if err != nil {
+		organizationError(c, err)
 		return
 	}
 
+	if !deleted {
+		c.JSON(http.StatusOK, gin.H{"message": "User removed from the organization"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
 
@@ -184,7 +197,7 @@ func (h *Handler) deleteUser(c *gin.Context) {
 // @Failure	500		{object}	response.Object
 // @Router       /users [get]
 func (h *Handler) getAllUsers(c *gin.Context) {
-	lists, err := h.service.User.GetAllUsers()
+	lists, err := h.service.User.GetAllUsers(c.Request.Context(), currentOrganization(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -192,3 +205,47 @@ func (h *Handler) getAllUsers(c *gin.Context) {
 
 	c.JSON(http.StatusOK, lists)
 }
+
+// @Summary reassign user's open tasks
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "user id"
+// @Param request body entity.ReassignTasks true "new assignee"
+// @Success 200 {object} map[string]int64
+// @Failure 400 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /users/{id}/tasks/reassign [post]
+func (h *Handler) reassignUserTasks(c *gin.Context) {
+	id := c.Param(userId)
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format for user"})
+		return
+	}
+
+	var input entity.ReassignTasks
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "message": err.Error()})
+		return
+	}
+
+	if _, err := uuid.Parse(input.Assignee); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format for assignee"})
+		return
+	}
+
+	if !h.inOrganization(c, entity.ResourceUser, input.Assignee, http.StatusBadRequest) {
+		return
+	}
+	if input.Project != "" && !h.inOrganization(c, entity.ResourceProject, input.Project, http.StatusBadRequest) {
+		return
+	}
+
+	count, err := h.service.Task.ReassignTasks(c.Request.Context(), currentOrganization(c), id, input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reassigned": count})
+}
@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+const DigestTemplate = "digest"
+
+var subjects = map[string]string{
+	"task_assigned":       "You were assigned a task",
+	"task_status_changed": "Task status changed",
+	"task_due_soon":       "Task is due soon",
+	DigestTemplate:        "Your daily summary",
+}
+
+type Item struct {
+	Message   string
+	TaskURL   string
+	CreatedAt time.Time
+}
+
+type Data struct {
+	Name           string
+	Items          []Item
+	UnsubscribeURL string
+}
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/*.html"))
+)
+
+// Render builds the message for a notification type, or for the digest when
+// name is DigestTemplate.
+func Render(name, to string, data Data) (Message, error) {
+	subject, ok := subjects[name]
+	if !ok {
+		return Message{}, fmt.Errorf("no email template for %q", name)
+	}
+
+	var text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return Message{}, err
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{To: to, Subject: subject, Text: text.String(), HTML: html.String()}, nil
+}
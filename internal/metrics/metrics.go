@@ -0,0 +1,97 @@
+// Package metrics holds the Prometheus metrics of the service. They are
+// registered on the default registry, which the /metrics route serves.
+package metrics
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"log/slog"
+	"time"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests by method, route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Duration of SQL statements by repository method.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"method"})
+
+	queryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "Failed SQL statements by repository method.",
+	}, []string{"method"})
+)
+
+// ObserveRequest records a handled HTTP request.
+func ObserveRequest(method, route, status string, elapsed time.Duration) {
+	httpRequests.WithLabelValues(method, route, status).Inc()
+	httpDuration.WithLabelValues(method, route, status).Observe(elapsed.Seconds())
+}
+
+// ObserveQuery records an SQL statement run on behalf of a repository method.
+func ObserveQuery(method string, elapsed time.Duration, err error) {
+	queryDuration.WithLabelValues(method).Observe(elapsed.Seconds())
+	if err != nil {
+		queryErrors.WithLabelValues(method).Inc()
+	}
+}
+
+type UsageSource interface {
+	GetUsage(ctx context.Context) (entity.Usage, error)
+}
+
+// UsageCollector reports the open tasks and active projects. The numbers
+// are queried on every scrape, so they are never stale.
+type UsageCollector struct {
+	source  UsageSource
+	timeout time.Duration
+
+	openTasks      *prometheus.Desc
+	activeProjects *prometheus.Desc
+}
+
+func NewUsageCollector(source UsageSource, timeout time.Duration) *UsageCollector {
+	return &UsageCollector{
+		source:  source,
+		timeout: timeout,
+		openTasks: prometheus.NewDesc("pm_open_tasks",
+			"Open tasks of unfinished projects by status and priority, templates left out.", []string{"status", "priority"}, nil),
+		activeProjects: prometheus.NewDesc("pm_active_projects",
+			"Unfinished projects, templates left out.", nil, nil),
+	}
+}
+
+func (c *UsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.openTasks
+	ch <- c.activeProjects
+}
+
+func (c *UsageCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	usage, err := c.source.GetUsage(ctx)
+	if err != nil {
+		slog.Error("Failed to collect usage metrics", "error", err)
+		ch <- prometheus.NewInvalidMetric(c.activeProjects, err)
+		return
+	}
+
+	for _, count := range usage.OpenTasks {
+		ch <- prometheus.MustNewConstMetric(c.openTasks, prometheus.GaugeValue, float64(count.Count), count.Status, count.Priority)
+	}
+	ch <- prometheus.MustNewConstMetric(c.activeProjects, prometheus.GaugeValue, float64(usage.ActiveProjects))
+}
@@ -0,0 +1,127 @@
+// Package rank generates lexicographic sort keys (fractional indexing) so a
+// task can be placed between two others by rewriting only its own key.
+//
+// Keys use the digits 0-9a-z, which sort the same bytewise as numerically,
+// and never end in '0' so there is always room before any key.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+// Digits are the characters of a key in sort order.
+const Digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// MaxLength is the key length after which a column should be rebalanced.
+// Keys grow by roughly one character every five inserts at the same spot.
+const MaxLength = 16
+
+var ErrOrder = errors.New("rank: keys are not in order")
+
+func digit(key string, i int, past int) int {
+	if i >= len(key) {
+		return past
+	}
+	return strings.IndexByte(Digits, key[i])
+}
+
+// Between returns a key that sorts strictly after prev and before next. An
+// empty prev means the start of the column, an empty next its end.
+func Between(prev, next string) (string, error) {
+	if next != "" && prev >= next {
+		return "", ErrOrder
+	}
+
+	base := len(Digits)
+	p, n, pos := 0, 0, 0
+	for p == n {
+		p = digit(prev, pos, -1)
+		if next == "" {
+			n = base
+		} else {
+			n = digit(next, pos, base)
+		}
+		pos++
+	}
+
+	var sb strings.Builder
+	sb.WriteString(prev[:min(pos-1, len(prev))])
+
+	switch {
+	case p == -1:
+		// prev is a prefix of next: match next's leading zeros, then take
+		// the middle of what is left.
+		for n == 0 {
+			sb.WriteByte(Digits[0])
+			n = digit(next, pos, base)
+			pos++
+		}
+		if n == 1 {
+			sb.WriteByte(Digits[0])
+			n = base
+		}
+	case p+1 == n:
+		// Adjacent digits: keep prev's digit and find room after prev.
+		sb.WriteByte(Digits[p])
+		n = base
+		for {
+			p = digit(prev, pos, -1)
+			pos++
+			if p != base-1 {
+				break
+			}
+			sb.WriteByte(Digits[base-1])
+		}
+	}
+
+	sb.WriteByte(Digits[(p+n+1)/2])
+	return sb.String(), nil
+}
+
+// After returns a key that sorts after key, the last of its column, by
+// incrementing its last character. Keys grow by one character every 35
+// calls, much slower than with Between. The database places new tasks with
+// the same rule.
+func After(key string) string {
+	if key == "" {
+		return Digits[len(Digits)/2 : len(Digits)/2+1]
+	}
+
+	last := strings.IndexByte(Digits, key[len(key)-1])
+	if last == len(Digits)-1 {
+		return key + Digits[1:2]
+	}
+	return key[:len(key)-1] + Digits[last+1:last+2]
+}
+
+// Spread returns count evenly spaced keys of equal length in ascending
+// order, used to rebalance a column.
+func Spread(count int) []string {
+	base := len(Digits)
+	width, capacity := 1, base
+	for capacity < 2*(count+1) {
+		width++
+		capacity *= base
+	}
+
+	step := capacity / (count + 1)
+	keys := make([]string, 0, count)
+	for i := 1; i <= count; i++ {
+		value := i * step
+		if value%base == 0 {
+			value++
+		}
+		keys = append(keys, encode(value, width))
+	}
+	return keys
+}
+
+func encode(value, width int) string {
+	key := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		key[i] = Digits[value%len(Digits)]
+		value /= len(Digits)
+	}
+	return string(key)
+}
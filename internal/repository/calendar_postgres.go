@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type CalendarPostgres struct {
+	db *sqlx.DB
+}
+
+func NewCalendarPostgres(db *sqlx.DB) *CalendarPostgres {
+	return &CalendarPostgres{db: db}
+}
+
+func (repo *CalendarPostgres) CreateCalendarToken(ctx context.Context, token entity.CalendarToken) (string, time.Time, error) {
+	query := fmt.Sprintf("INSERT INTO %s (token, scope, target, organization) VALUES ($1, $2, $3, $4) RETURNING id, created_at", calendarTable)
+	return Create(ctx, repo.db, query, token.Token, token.Scope, token.Target, token.Organization)
+}
+
+func (repo *CalendarPostgres) GetCalendarToken(ctx context.Context, token string) (entity.CalendarToken, error) {
+	var calendar entity.CalendarToken
+	query := fmt.Sprintf("SELECT * FROM %s WHERE token = $1 AND revoked_at IS NULL", calendarTable)
+	err := repo.db.GetContext(ctx, &calendar, query, token)
+
+	return calendar, err
+}
+
+func (repo *CalendarPostgres) RevokeCalendarToken(ctx context.Context, token string) (int64, error) {
+	query := fmt.Sprintf("UPDATE %s SET revoked_at = NOW() WHERE token = $1 AND revoked_at IS NULL", calendarTable)
+	res, err := repo.db.ExecContext(ctx, query, token)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"strings"
+	"time"
+)
+
+type CustomFieldPostgres struct {
+	db *sqlx.DB
+}
+
+func NewCustomFieldPostgres(db *sqlx.DB) *CustomFieldPostgres {
+	return &CustomFieldPostgres{db: db}
+}
+
+func (repo *CustomFieldPostgres) CreateField(ctx context.Context, field entity.CustomField) (string, time.Time, error) {
+	query := fmt.Sprintf("INSERT INTO %s (project, name, type, options, required) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at", customFieldsTable)
+	return Create(ctx, repo.db, query, field.Project, field.Name, field.Type, field.Options, field.Required)
+}
+
+func (repo *CustomFieldPostgres) GetField(ctx context.Context, id string) (entity.CustomField, error) {
+	var field entity.CustomField
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", customFieldsTable)
+	err := repo.db.GetContext(ctx, &field, query, id)
+
+	return field, err
+}
+
+func (repo *CustomFieldPostgres) GetFields(ctx context.Context, projectId string) ([]entity.CustomField, error) {
+	var fields []entity.CustomField
+	query := fmt.Sprintf("SELECT * FROM %s WHERE project = $1 ORDER BY created_at", customFieldsTable)
+	err := repo.db.SelectContext(ctx, &fields, query, projectId)
+
+	return fields, err
+}
+
+func (repo *CustomFieldPostgres) UpdateField(ctx context.Context, field entity.CustomField) error {
+	query := fmt.Sprintf("UPDATE %s SET name = $1, options = $2, required = $3 WHERE id = $4", customFieldsTable)
+	_, err := repo.db.ExecContext(ctx, query, field.Name, field.Options, field.Required, field.ID)
+	return err
+}
+
+// DeleteField removes the field and its values from the project's tasks.
+func (repo *CustomFieldPostgres) DeleteField(ctx context.Context, field entity.CustomField) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range []string{tasksTable, seriesTable} {
+		query := fmt.Sprintf("UPDATE %s SET custom_fields = custom_fields - $1 WHERE project = $2 AND custom_fields ? $1", table)
+		if _, err := tx.ExecContext(ctx, query, field.ID, field.Project); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", customFieldsTable)
+	if _, err := tx.ExecContext(ctx, query, field.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountTasksWithoutField counts the project's tasks that have no value for
+// the field.
+func (repo *CustomFieldPostgres) CountTasksWithoutField(ctx context.Context, projectId, fieldId string) (int, error) {
+	var count int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE project = $1 AND NOT custom_fields ? $2", tasksTable)
+	err := repo.db.GetContext(ctx, &count, query, projectId, fieldId)
+
+	return count, err
+}
+
+// SetTaskFields merges values into the task's custom fields and drops the
+// fields listed in remove.
+func (repo *CustomFieldPostgres) SetTaskFields(ctx context.Context, taskId string, values entity.CustomValues, remove []string) error {
+	query := fmt.Sprintf("UPDATE %s SET custom_fields = (custom_fields || $1::jsonb) - $2::text[] WHERE id = $3", tasksTable)
+	return execAffected(ctx, repo.db, query, values, pq.Array(remove), taskId)
+}
+
+// GetTasksByFields lists the project's tasks matching every filter, sorted
+// by a custom field when one is given and in board order otherwise.
+func (repo *CustomFieldPostgres) GetTasksByFields(ctx context.Context, projectId string, filters []entity.FieldFilter, sort *entity.FieldSort) ([]entity.Task, error) {
+	conditions := []string{"project = $1"}
+	args := []interface{}{projectId}
+
+	for _, filter := range filters {
+		key, value := len(args)+1, len(args)+2
+		args = append(args, filter.Field.ID, filter.Value)
+
+		switch filter.Field.Type {
+		case entity.FieldMultiSelect:
+			conditions = append(conditions, fmt.Sprintf("custom_fields->$%d @> to_jsonb($%d::text)", key, value))
+		case entity.FieldNumber:
+			conditions = append(conditions, fmt.Sprintf("(custom_fields->>$%d)::numeric = $%d::numeric", key, value))
+		default:
+			conditions = append(conditions, fmt.Sprintf("custom_fields->>$%d = $%d", key, value))
+		}
+	}
+
+	order := "status, rank NULLS LAST, created_at"
+	if sort != nil {
+		args = append(args, sort.Field.ID)
+		value := fmt.Sprintf("custom_fields->>$%d", len(args))
+		if sort.Field.Type == entity.FieldNumber {
+			value = "(" + value + ")::numeric"
+		}
+
+		direction := "ASC"
+		if sort.Desc {
+			direction = "DESC"
+		}
+		order = fmt.Sprintf("%s %s NULLS LAST, created_at", value, direction)
+	}
+
+	var tasks []entity.Task
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s ORDER BY %s", tasksTable, strings.Join(conditions, " AND "), order)
+	err := repo.db.SelectContext(ctx, &tasks, query, args...)
+
+	return tasks, err
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type DashboardPostgres struct {
+	db *sqlx.DB
+}
+
+func NewDashboardPostgres(db *sqlx.DB) *DashboardPostgres {
+	return &DashboardPostgres{db: db}
+}
+
+func (repo *DashboardPostgres) OpenTaskCounts(ctx context.Context, orgId, userId string) ([]entity.TaskCount, error) {
+	var counts []entity.TaskCount
+	query := fmt.Sprintf(`SELECT status, priority, COUNT(*) AS count FROM %s
+		WHERE assignee = $1 AND finished_at IS NULL AND %s
+		GROUP BY status, priority ORDER BY status, priority`, tasksTable, inActiveOrganization("project", 2, true))
+	err := repo.db.SelectContext(ctx, &counts, query, userId, orgId)
+
+	return counts, err
+}
+
+// DueSoonTasks returns open tasks due before the given moment, overdue ones
+// included, earliest first.
+func (repo *DashboardPostgres) DueSoonTasks(ctx context.Context, orgId, userId string, before time.Time, limit int) ([]entity.Task, error) {
+	var tasks []entity.Task
+	query := fmt.Sprintf(`SELECT * FROM %s
+		WHERE assignee = $1 AND finished_at IS NULL AND due_at IS NOT NULL AND due_at <= $2 AND %s
+		ORDER BY due_at LIMIT $3`, tasksTable, inActiveOrganization("project", 4, true))
+	err := repo.db.SelectContext(ctx, &tasks, query, userId, before, limit, orgId)
+
+	return tasks, err
+}
+
+// RecentlyChangedTasks returns the user's tasks whose status changed since
+// the given moment, latest change first. Only the history of those tasks is
+// read, through the (task, changed_at) index.
+func (repo *DashboardPostgres) RecentlyChangedTasks(ctx context.Context, orgId, userId string, since time.Time, limit int) ([]entity.Task, error) {
+	var tasks []entity.Task
+	query := fmt.Sprintf(`SELECT t.* FROM %s t
+		JOIN LATERAL (SELECT MAX(changed_at) AS changed_at FROM %s WHERE task = t.id AND changed_at >= $2) h ON h.changed_at IS NOT NULL
+		WHERE t.assignee = $1 AND %s
+		ORDER BY h.changed_at DESC LIMIT $3`, tasksTable, historyTable, inActiveOrganization("t.project", 4, true))
+	err := repo.db.SelectContext(ctx, &tasks, query, userId, since, limit, orgId)
+
+	return tasks, err
+}
+
+func (repo *DashboardPostgres) ManagedProjectProgress(ctx context.Context, orgId, userId string) ([]entity.ProjectProgress, error) {
+	var projects []entity.ProjectProgress
+	query := fmt.Sprintf(`SELECT p.*, COUNT(t.id) AS total, COUNT(t.finished_at) AS finished
+		FROM %s p LEFT JOIN %s t ON t.project = p.id
+		WHERE p.manager = $1 AND p.organization = $2 AND p.finished_at IS NULL AND NOT p.is_template
+		GROUP BY p.id ORDER BY p.created_at DESC`, projectsTable, tasksTable)
+	err := repo.db.SelectContext(ctx, &projects, query, userId, orgId)
+
+	return projects, err
+}
+
+// CountOpenTasks counts the open tasks of unfinished projects across all
+// organizations, by status and priority. Tasks of templates are left out.
+func (repo *DashboardPostgres) CountOpenTasks(ctx context.Context) ([]entity.TaskCount, error) {
+	var counts []entity.TaskCount
+	query := fmt.Sprintf(`SELECT t.status, t.priority, COUNT(*) AS count FROM %s t
+		JOIN %s p ON p.id = t.project
+		WHERE t.finished_at IS NULL AND p.finished_at IS NULL AND NOT p.is_template
+		GROUP BY t.status, t.priority ORDER BY t.status, t.priority`, tasksTable, projectsTable)
+	err := repo.db.SelectContext(ctx, &counts, query)
+
+	return counts, err
+}
+
+// CountActiveProjects counts the unfinished projects that are not templates.
+func (repo *DashboardPostgres) CountActiveProjects(ctx context.Context) (int, error) {
+	var count int
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE finished_at IS NULL AND NOT is_template`, projectsTable)
+	err := repo.db.GetContext(ctx, &count, query)
+
+	return count, err
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"time"
+)
+
+type EmailPostgres struct {
+	db *sqlx.DB
+}
+
+func NewEmailPostgres(db *sqlx.DB) *EmailPostgres {
+	return &EmailPostgres{db: db}
+}
+
+func (repo *EmailPostgres) GetEmailMode(ctx context.Context, userId string) (string, error) {
+	var mode string
+	query := fmt.Sprintf("SELECT mode FROM %s WHERE user_id = $1", emailTable)
+	err := repo.db.GetContext(ctx, &mode, query, userId)
+
+	return mode, err
+}
+
+func (repo *EmailPostgres) SetEmailMode(ctx context.Context, userId, mode string) error {
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, mode) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET mode = EXCLUDED.mode`, emailTable)
+	_, err := repo.db.ExecContext(ctx, query, userId, mode)
+	return err
+}
+
+// PendingNotifications returns notifications created since the given moment
+// that were not emailed yet, for users whose email mode is mode. Users
+// without a stored preference get instant emails.
+func (repo *EmailPostgres) PendingNotifications(ctx context.Context, mode string, since time.Time, limit int) ([]entity.PendingNotification, error) {
+	var notifications []entity.PendingNotification
+	query := fmt.Sprintf(`SELECT n.*, u.name AS user_name, u.email AS user_email
+		FROM %s n
+		JOIN %s u ON u.id = n.user_id
+		LEFT JOIN %s p ON p.user_id = n.user_id
+		WHERE n.emailed_at IS NULL AND n.created_at >= $2 AND COALESCE(p.mode, $3) = $1
+		ORDER BY n.user_id, n.created_at
+		LIMIT $4`, notificationsTable, usersTable, emailTable)
+	err := repo.db.SelectContext(ctx, &notifications, query, mode, since, entity.EmailModeInstant, limit)
+
+	return notifications, err
+}
+
+// QueueEmail stores the email in the outbox and marks the notifications it
+// carries as emailed, both in one transaction.
+func (repo *EmailPostgres) QueueEmail(ctx context.Context, email entity.Email, notificationIds []string) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (user_id, recipient, subject, text_body, html_body) VALUES ($1, $2, $3, $4, $5)", outboxTable)
+	if _, err := tx.ExecContext(ctx, query, email.UserID, email.Recipient, email.Subject, email.TextBody, email.HTMLBody); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET emailed_at = NOW() WHERE id = ANY($1)", notificationsTable)
+	if _, err := tx.ExecContext(ctx, query, pq.Array(notificationIds)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ClaimEmails leases up to limit due emails by pushing their next attempt
+// forward, so concurrent workers never pick the same email.
+func (repo *EmailPostgres) ClaimEmails(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]entity.Email, error) {
+	var emails []entity.Email
+	query := fmt.Sprintf(`UPDATE %[1]s SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM %[1]s
+			WHERE sent_at IS NULL AND attempts < $2 AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, outboxTable)
+	err := repo.db.SelectContext(ctx, &emails, query, time.Now().Add(lease), maxAttempts, limit)
+
+	return emails, err
+}
+
+func (repo *EmailPostgres) MarkEmailSent(ctx context.Context, id string) error {
+	query := fmt.Sprintf("UPDATE %s SET sent_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1", outboxTable)
+	_, err := repo.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (repo *EmailPostgres) MarkEmailFailed(ctx context.Context, id, lastError string, nextAttempt time.Time) error {
+	query := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1", outboxTable)
+	_, err := repo.db.ExecContext(ctx, query, id, lastError, nextAttempt)
+	return err
+}
+
+// GetLastDigest returns when digests were last queued.
+func (repo *EmailPostgres) GetLastDigest(ctx context.Context) (time.Time, error) {
+	var queuedAt time.Time
+	query := fmt.Sprintf("SELECT queued_at FROM %s", digestTable)
+	err := repo.db.GetContext(ctx, &queuedAt, query)
+
+	return queuedAt, err
+}
+
+func (repo *EmailPostgres) SetLastDigest(ctx context.Context, queuedAt time.Time) error {
+	query := fmt.Sprintf(`INSERT INTO %s (queued_at) VALUES ($1)
+		ON CONFLICT (id) DO UPDATE SET queued_at = EXCLUDED.queued_at`, digestTable)
+	_, err := repo.db.ExecContext(ctx, query, queuedAt)
+	return err
+}
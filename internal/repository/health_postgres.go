@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+type HealthPostgres struct {
+	db *sqlx.DB
+}
+
+func NewHealthPostgres(db *sqlx.DB) *HealthPostgres {
+	return &HealthPostgres{db: db}
+}
+
+func (repo *HealthPostgres) Ping(ctx context.Context) error {
+	return repo.db.PingContext(ctx)
+}
+
+// GetSchemaVersion returns the schema version recorded by the last Migrate.
+func (repo *HealthPostgres) GetSchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	query := fmt.Sprintf(`SELECT version FROM %s`, schemaTable)
+	err := repo.db.GetContext(ctx, &version, query)
+
+	return version, err
+}
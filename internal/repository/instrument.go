@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/metrics"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/ngrok/sqlmw"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// instrumentedDriver is the postgres driver wrapped to time and trace every
+// statement.
+const instrumentedDriver = "postgres-instrumented"
+
+func init() {
+	sql.Register(instrumentedDriver, Instrument(&pq.Driver{}))
+	sqlx.BindDriver(instrumentedDriver, sqlx.DOLLAR)
+}
+
+// Instrument wraps a driver so that the statements the repositories run
+// through it are timed and traced.
+func Instrument(d driver.Driver) driver.Driver {
+	return sqlmw.Driver(d, new(queryInterceptor))
+}
+
+var (
+	repositoryPackage = reflect.TypeOf(TaskPostgres{}).PkgPath() + "."
+	repositoryMethod  = regexp.MustCompile(`^\(?\*?(\w+Postgres)\)?\.(\w+)`)
+)
+
+// queryMethod names the repository method that runs the current statement,
+// e.g. "TaskPostgres.GetTaskById", by walking up the stack of the caller.
+// Methods with a pointer receiver appear as "(*TaskPostgres).GetTaskById" in
+// the stack, the ones with a value receiver as "TaskPostgres.GetTaskById".
+func queryMethod() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if name, ok := strings.CutPrefix(frame.Function, repositoryPackage); ok {
+			if m := repositoryMethod.FindStringSubmatch(name); m != nil {
+				return m[1] + "." + m[2]
+			}
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+var (
+	quotedLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numericLiteral = regexp.MustCompile(`(\$?)\b\d+(?:\.\d+)?\b`)
+	whitespace     = regexp.MustCompile(`\s+`)
+)
+
+// sanitizeQuery prepares a statement for a span. Arguments are sent apart as
+// $n parameters, but literals formatted into the text are replaced with ?
+// as well, and the indentation is collapsed.
+func sanitizeQuery(query string) string {
+	query = quotedLiteral.ReplaceAllString(query, "?")
+	query = numericLiteral.ReplaceAllStringFunc(query, func(literal string) string {
+		if strings.HasPrefix(literal, "$") {
+			return literal
+		}
+		return "?"
+	})
+	return strings.TrimSpace(whitespace.ReplaceAllString(query, " "))
+}
+
+// statement is an SQL statement being run, timed and traced under the
+// repository method that runs it.
+type statement struct {
+	method string
+	start  time.Time
+	span   trace.Span
+}
+
+func startStatement(ctx context.Context, query string) (context.Context, statement) {
+	method := queryMethod()
+	ctx, span := tracing.Start(ctx, method,
+		semconv.DBSystemPostgreSQL,
+		semconv.DBQueryText(sanitizeQuery(query)),
+	)
+	return ctx, statement{method: method, start: time.Now(), span: span}
+}
+
+func (s statement) end(err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		s.span.End()
+		return
+	}
+	metrics.ObserveQuery(s.method, time.Since(s.start), err)
+	tracing.End(s.span, err)
+}
+
+// queryInterceptor reports the duration and failures of statements to the
+// metrics package and traces them.
+type queryInterceptor struct {
+	sqlmw.NullInterceptor
+}
+
+func (queryInterceptor) ConnExecContext(ctx context.Context, conn driver.ExecerContext, query string, args []driver.NamedValue) (driver.Result, error) {
+	ctx, stmt := startStatement(ctx, query)
+	result, err := conn.ExecContext(ctx, query, args)
+	stmt.end(err)
+	return result, err
+}
+
+func (queryInterceptor) ConnQueryContext(ctx context.Context, conn driver.QueryerContext, query string, args []driver.NamedValue) (context.Context, driver.Rows, error) {
+	ctx, stmt := startStatement(ctx, query)
+	rows, err := conn.QueryContext(ctx, query, args)
+	stmt.end(err)
+	return ctx, rows, err
+}
+
+func (queryInterceptor) StmtExecContext(ctx context.Context, driverStmt driver.StmtExecContext, query string, args []driver.NamedValue) (driver.Result, error) {
+	ctx, stmt := startStatement(ctx, query)
+	result, err := driverStmt.ExecContext(ctx, args)
+	stmt.end(err)
+	return result, err
+}
+
+func (queryInterceptor) StmtQueryContext(ctx context.Context, driverStmt driver.StmtQueryContext, query string, args []driver.NamedValue) (context.Context, driver.Rows, error) {
+	ctx, stmt := startStatement(ctx, query)
+	rows, err := driverStmt.QueryContext(ctx, args)
+	stmt.end(err)
+	return ctx, rows, err
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// maintenanceSetting lets the repairs of FixIntegrity through the archive
+// trigger. It is only ever set for the repair transaction.
+const maintenanceSetting = "pm.maintenance"
+
+// integrityCheck pairs the query that finds the rows breaking a rule with the
+// statement that repairs them. find selects an id and a readable detail.
+type integrityCheck struct {
+	name string
+	find string
+	fix  string
+}
+
+// integrityChecks are run in this order. Repairs rely on it: managers become
+// members before tasks of strangers are handed to them.
+var integrityChecks = []integrityCheck{
+	{
+		name: entity.CheckManagerNotMember,
+		find: fmt.Sprintf(`SELECT p.id, 'manager ' || p.manager || ' is not a member of organization ' || p.organization AS detail
+			FROM %[1]s p WHERE NOT EXISTS (SELECT 1 FROM %[2]s m WHERE m.organization = p.organization AND m.user_id = p.manager)
+			ORDER BY p.id`, projectsTable, membershipsTable),
+		fix: fmt.Sprintf(`INSERT INTO %[2]s (organization, user_id, role)
+			SELECT DISTINCT p.organization, p.manager, '%[3]s' FROM %[1]s p
+			WHERE NOT EXISTS (SELECT 1 FROM %[2]s m WHERE m.organization = p.organization AND m.user_id = p.manager)
+			ON CONFLICT DO NOTHING`, projectsTable, membershipsTable, entity.OrgRoleMember),
+	},
+	{
+		name: entity.CheckAssigneeNotMember,
+		find: fmt.Sprintf(`SELECT t.id, 'assignee ' || t.assignee || ' is not a member of organization ' || p.organization AS detail
+			FROM %[1]s t JOIN %[2]s p ON p.id = t.project
+			WHERE NOT EXISTS (SELECT 1 FROM %[3]s m WHERE m.organization = p.organization AND m.user_id = t.assignee)
+			ORDER BY t.id`, tasksTable, projectsTable, membershipsTable),
+		fix: fmt.Sprintf(`UPDATE %[1]s t SET assignee = p.manager FROM %[2]s p
+			WHERE p.id = t.project
+			AND NOT EXISTS (SELECT 1 FROM %[3]s m WHERE m.organization = p.organization AND m.user_id = t.assignee)`,
+			tasksTable, projectsTable, membershipsTable),
+	},
+	{
+		name: entity.CheckUserWithoutOrg,
+		find: fmt.Sprintf(`SELECT u.id, u.email || ' belongs to no organization' AS detail
+			FROM %[1]s u WHERE NOT EXISTS (SELECT 1 FROM %[2]s m WHERE m.user_id = u.id)
+			ORDER BY u.id`, usersTable, membershipsTable),
+		fix: fmt.Sprintf(`INSERT INTO %[2]s (organization, user_id, role)
+			SELECT o.id, u.id, '%[5]s' FROM %[1]s u, %[3]s o
+			WHERE o.slug = '%[4]s' AND NOT EXISTS (SELECT 1 FROM %[2]s m WHERE m.user_id = u.id)`,
+			usersTable, membershipsTable, organizationsTable, entity.DefaultOrganization, entity.OrgRoleMember),
+	},
+	{
+		name: entity.CheckArchivedOpenTask,
+		find: fmt.Sprintf(`SELECT t.id, 'project ' || p.id || ' is finished but the task is still ' || t.status AS detail
+			FROM %[1]s t JOIN %[2]s p ON p.id = t.project
+			WHERE p.finished_at IS NOT NULL AND t.finished_at IS NULL
+			ORDER BY t.id`, tasksTable, projectsTable),
+		fix: fmt.Sprintf(`UPDATE %[1]s t SET finished_at = p.finished_at,
+				status = CASE WHEN t.status IN ('%[3]s', '%[4]s') THEN t.status ELSE '%[4]s' END
+			FROM %[2]s p
+			WHERE p.id = t.project AND p.finished_at IS NOT NULL AND t.finished_at IS NULL`,
+			tasksTable, projectsTable, entity.TaskStatusDone, entity.TaskStatusCancelled),
+	},
+	{
+		name: entity.CheckForeignSprint,
+		find: fmt.Sprintf(`SELECT t.id, 'sprint ' || s.id || ' belongs to project ' || s.project AS detail
+			FROM %[1]s t JOIN %[2]s s ON s.id = t.sprint_id
+			WHERE s.project <> t.project
+			ORDER BY t.id`, tasksTable, sprintsTable),
+		fix: fmt.Sprintf(`UPDATE %[1]s t SET sprint_id = NULL FROM %[2]s s
+			WHERE s.id = t.sprint_id AND s.project <> t.project`, tasksTable, sprintsTable),
+	},
+	{
+		name: entity.CheckForeignMilestone,
+		find: fmt.Sprintf(`SELECT t.id, 'milestone ' || ms.id || ' belongs to project ' || ms.project AS detail
+			FROM %[1]s t JOIN %[2]s ms ON ms.id = t.milestone_id
+			WHERE ms.project <> t.project
+			ORDER BY t.id`, tasksTable, milestonesTable),
+		fix: fmt.Sprintf(`UPDATE %[1]s t SET milestone_id = NULL FROM %[2]s ms
+			WHERE ms.id = t.milestone_id AND ms.project <> t.project`, tasksTable, milestonesTable),
+	},
+	{
+		name: entity.CheckOrphanCalendarLink,
+		find: fmt.Sprintf(`SELECT c.id, c.scope || ' ' || c.target || ' no longer exists' AS detail
+			FROM %[1]s c WHERE %[4]s
+			ORDER BY c.id`, calendarTable, usersTable, projectsTable, orphanCalendarToken),
+		fix: fmt.Sprintf(`DELETE FROM %[1]s c WHERE %[4]s`, calendarTable, usersTable, projectsTable, orphanCalendarToken),
+	},
+}
+
+// orphanCalendarToken matches calendar tokens whose target is gone; the
+// target column has no foreign key because it points at users or projects.
+var orphanCalendarToken = fmt.Sprintf(`(c.scope = '%[3]s' AND NOT EXISTS (SELECT 1 FROM %[1]s WHERE id = c.target))
+	OR (c.scope = '%[4]s' AND NOT EXISTS (SELECT 1 FROM %[2]s WHERE id = c.target))`,
+	usersTable, projectsTable, entity.CalendarScopeUser, entity.CalendarScopeProject)
+
+type IntegrityPostgres struct {
+	db *sqlx.DB
+}
+
+func NewIntegrityPostgres(db *sqlx.DB) *IntegrityPostgres {
+	return &IntegrityPostgres{db: db}
+}
+
+// CheckIntegrity lists the rows breaking each check, in check order.
+func (repo *IntegrityPostgres) CheckIntegrity(ctx context.Context) ([]entity.Inconsistency, error) {
+	var inconsistencies []entity.Inconsistency
+	for _, check := range integrityChecks {
+		var found []entity.Inconsistency
+		if err := repo.db.SelectContext(ctx, &found, check.find); err != nil {
+			return nil, fmt.Errorf("%s: %w", check.name, err)
+		}
+
+		for _, inconsistency := range found {
+			inconsistency.Check = check.name
+			inconsistencies = append(inconsistencies, inconsistency)
+		}
+	}
+
+	return inconsistencies, nil
+}
+
+// FixIntegrity repairs every check in one transaction and returns how many
+// rows each repair touched.
+func (repo *IntegrityPostgres) FixIntegrity(ctx context.Context) ([]entity.IntegrityFix, error) {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL %s = 'on'", maintenanceSetting)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	fixes := make([]entity.IntegrityFix, 0, len(integrityChecks))
+	for _, check := range integrityChecks {
+		res, err := tx.ExecContext(ctx, check.fix)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("%s: %w", check.name, err)
+		}
+
+		fixed, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		fixes = append(fixes, entity.IntegrityFix{Check: check.name, Fixed: fixed})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return fixes, nil
+}
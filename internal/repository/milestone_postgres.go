@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"time"
+)
+
+// milestoneProgress selects milestones together with counts of their tasks.
+const milestoneProgress = `SELECT m.*, COUNT(t.id) AS total, COUNT(t.finished_at) AS done, MAX(t.finished_at) AS last_finished_at
+	FROM %s m
+	LEFT JOIN %s t ON t.milestone_id = m.id`
+
+type MilestonePostgres struct {
+	db *sqlx.DB
+}
+
+func NewMilestonePostgres(db *sqlx.DB) *MilestonePostgres {
+	return &MilestonePostgres{db: db}
+}
+
+func (repo *MilestonePostgres) CreateMilestone(ctx context.Context, milestone entity.Milestone) (string, time.Time, error) {
+	query := fmt.Sprintf("INSERT INTO %s (project, title, description, target_at) VALUES ($1, $2, $3, $4) RETURNING id, created_at", milestonesTable)
+	return Create(ctx, repo.db, query, milestone.Project, milestone.Title, milestone.Description, milestone.TargetAt)
+}
+
+func (repo *MilestonePostgres) GetMilestone(ctx context.Context, id string) (entity.MilestoneProgress, error) {
+	var milestone entity.MilestoneProgress
+	query := fmt.Sprintf(milestoneProgress+" WHERE m.id = $1 GROUP BY m.id", milestonesTable, tasksTable)
+	err := repo.db.GetContext(ctx, &milestone, query, id)
+
+	return milestone, err
+}
+
+func (repo *MilestonePostgres) GetMilestonesByProject(ctx context.Context, projectId string) ([]entity.MilestoneProgress, error) {
+	var milestones []entity.MilestoneProgress
+	query := fmt.Sprintf(milestoneProgress+" WHERE m.project = $1 GROUP BY m.id ORDER BY m.target_at", milestonesTable, tasksTable)
+	err := repo.db.SelectContext(ctx, &milestones, query, projectId)
+
+	return milestones, err
+}
+
+func (repo *MilestonePostgres) UpdateMilestone(ctx context.Context, milestone entity.Milestone) error {
+	query := fmt.Sprintf("UPDATE %s SET title = $1, description = $2, target_at = $3 WHERE id = $4", milestonesTable)
+	_, err := repo.db.ExecContext(ctx, query, milestone.Title, milestone.Description, milestone.TargetAt, milestone.ID)
+	return err
+}
+
+func (repo *MilestonePostgres) DeleteMilestone(ctx context.Context, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", milestonesTable)
+	_, err := repo.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// AddMilestoneTasks puts the tasks into the milestone. Tasks of other
+// projects are left alone.
+func (repo *MilestonePostgres) AddMilestoneTasks(ctx context.Context, id, projectId string, taskIds []string) (int64, error) {
+	query := fmt.Sprintf("UPDATE %s SET milestone_id = $1 WHERE id = ANY($2) AND project = $3", tasksTable)
+	res, err := repo.db.ExecContext(ctx, query, id, pq.Array(taskIds), projectId)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (repo *MilestonePostgres) RemoveMilestoneTask(ctx context.Context, id, taskId string) (int64, error) {
+	query := fmt.Sprintf("UPDATE %s SET milestone_id = NULL WHERE id = $1 AND milestone_id = $2", tasksTable)
+	res, err := repo.db.ExecContext(ctx, query, taskId, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (repo *MilestonePostgres) GetMilestoneTasks(ctx context.Context, id string) ([]entity.Task, error) {
+	var tasks []entity.Task
+	query := fmt.Sprintf("SELECT * FROM %s WHERE milestone_id = $1 ORDER BY created_at", tasksTable)
+	err := repo.db.SelectContext(ctx, &tasks, query, id)
+
+	return tasks, err
+}
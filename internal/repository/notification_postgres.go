@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type NotificationPostgres struct {
+	db *sqlx.DB
+}
+
+func NewNotificationPostgres(db *sqlx.DB) *NotificationPostgres {
+	return &NotificationPostgres{db: db}
+}
+
+func (repo *NotificationPostgres) CreateNotification(ctx context.Context, notification entity.Notification) (string, time.Time, error) {
+	query := fmt.Sprintf("INSERT INTO %s (user_id, type, task, message) VALUES ($1, $2, $3, $4) RETURNING id, created_at", notificationsTable)
+	return Create(ctx, repo.db, query, notification.UserID, notification.Type, notification.Task, notification.Message)
+}
+
+// CreateDueSoonNotifications notifies assignees of open tasks due before the
+// given moment, leaving out the tasks of templates. A task is announced once per assignee and users who turned
+// the notification off are skipped.
+func (repo *NotificationPostgres) CreateDueSoonNotifications(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf(`
+	INSERT INTO %[1]s (user_id, type, task, message)
+	SELECT t.assignee, $1, t.id, 'Task "' || t.title || '" is due ' || to_char(t.due_at, 'YYYY-MM-DD HH24:MI')
+	FROM %[2]s t
+	WHERE t.finished_at IS NULL AND t.due_at IS NOT NULL AND t.due_at <= $2
+		AND NOT EXISTS (SELECT 1 FROM %[4]s pr WHERE pr.id = t.project AND pr.is_template)
+		AND NOT EXISTS (SELECT 1 FROM %[1]s n WHERE n.task = t.id AND n.user_id = t.assignee AND n.type = $1)
+		AND NOT EXISTS (SELECT 1 FROM %[3]s p WHERE p.user_id = t.assignee AND p.type = $1 AND NOT p.enabled)`,
+		notificationsTable, tasksTable, preferencesTable, projectsTable)
+
+	res, err := repo.db.ExecContext(ctx, query, entity.NotificationDueSoon, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (repo *NotificationPostgres) GetNotifications(ctx context.Context, userId string, unreadOnly bool, limit, offset int) ([]entity.Notification, error) {
+	var notifications []entity.Notification
+	filter := ""
+	if unreadOnly {
+		filter = " AND read_at IS NULL"
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1%s ORDER BY created_at DESC LIMIT $2 OFFSET $3", notificationsTable, filter)
+	err := repo.db.SelectContext(ctx, &notifications, query, userId, limit, offset)
+
+	return notifications, err
+}
+
+func (repo *NotificationPostgres) CountUnread(ctx context.Context, userId string) (int, error) {
+	var count int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE user_id = $1 AND read_at IS NULL", notificationsTable)
+	err := repo.db.GetContext(ctx, &count, query, userId)
+
+	return count, err
+}
+
+func (repo *NotificationPostgres) MarkRead(ctx context.Context, userId, id string) (int64, error) {
+	query := fmt.Sprintf("UPDATE %s SET read_at = NOW() WHERE id = $1 AND user_id = $2 AND read_at IS NULL", notificationsTable)
+	res, err := repo.db.ExecContext(ctx, query, id, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (repo *NotificationPostgres) MarkAllRead(ctx context.Context, userId string) (int64, error) {
+	query := fmt.Sprintf("UPDATE %s SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL", notificationsTable)
+	res, err := repo.db.ExecContext(ctx, query, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (repo *NotificationPostgres) GetPreferences(ctx context.Context, userId string) ([]entity.NotificationPreference, error) {
+	var preferences []entity.NotificationPreference
+	query := fmt.Sprintf("SELECT type, enabled FROM %s WHERE user_id = $1", preferencesTable)
+	err := repo.db.SelectContext(ctx, &preferences, query, userId)
+
+	return preferences, err
+}
+
+func (repo *NotificationPostgres) SetPreference(ctx context.Context, userId string, preference entity.NotificationPreference) error {
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, type, enabled) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled`, preferencesTable)
+	_, err := repo.db.ExecContext(ctx, query, userId, preference.Type, preference.Enabled)
+	return err
+}
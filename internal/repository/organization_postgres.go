@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type OrganizationPostgres struct {
+	db *sqlx.DB
+}
+
+func NewOrganizationPostgres(db *sqlx.DB) *OrganizationPostgres {
+	return &OrganizationPostgres{db: db}
+}
+
+// CreateOrganization inserts the organization and makes the owner its first
+// member.
+func (repo *OrganizationPostgres) CreateOrganization(ctx context.Context, organization entity.Organization, ownerId string) (string, time.Time, error) {
+	query := fmt.Sprintf(`WITH o AS (
+			INSERT INTO %s (name, slug) VALUES ($1, $2) RETURNING id, created_at
+		), m AS (
+			INSERT INTO %s (organization, user_id, role) SELECT id, $3, $4 FROM o
+		)
+		SELECT id, created_at FROM o`, organizationsTable, membershipsTable)
+	return Create(ctx, repo.db, query, organization.Name, organization.Slug, ownerId, entity.OrgRoleOwner)
+}
+
+func (repo *OrganizationPostgres) GetOrganization(ctx context.Context, id string) (entity.Organization, error) {
+	var organization entity.Organization
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", organizationsTable)
+	err := repo.db.GetContext(ctx, &organization, query, id)
+
+	return organization, err
+}
+
+func (repo *OrganizationPostgres) GetOrganizationBySlug(ctx context.Context, slug string) (entity.Organization, error) {
+	var organization entity.Organization
+	query := fmt.Sprintf("SELECT * FROM %s WHERE slug = $1", organizationsTable)
+	err := repo.db.GetContext(ctx, &organization, query, slug)
+
+	return organization, err
+}
+
+func (repo *OrganizationPostgres) UpdateOrganization(ctx context.Context, organization entity.Organization) error {
+	query := fmt.Sprintf("UPDATE %s SET name = $1, slug = $2 WHERE id = $3", organizationsTable)
+	_, err := repo.db.ExecContext(ctx, query, organization.Name, organization.Slug, organization.ID)
+	return err
+}
+
+func (repo *OrganizationPostgres) DeleteOrganization(ctx context.Context, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", organizationsTable)
+	_, err := repo.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (repo *OrganizationPostgres) GetUserOrganizations(ctx context.Context, userId string) ([]entity.UserOrganization, error) {
+	var organizations []entity.UserOrganization
+	query := fmt.Sprintf(`SELECT o.*, m.role FROM %s o JOIN %s m ON m.organization = o.id
+		WHERE m.user_id = $1 ORDER BY o.name`, organizationsTable, membershipsTable)
+	err := repo.db.SelectContext(ctx, &organizations, query, userId)
+
+	return organizations, err
+}
+
+func (repo *OrganizationPostgres) GetMembership(ctx context.Context, orgId, userId string) (entity.Membership, error) {
+	var membership entity.Membership
+	query := fmt.Sprintf("SELECT * FROM %s WHERE organization = $1 AND user_id = $2", membershipsTable)
+	err := repo.db.GetContext(ctx, &membership, query, orgId, userId)
+
+	return membership, err
+}
+
+func (repo *OrganizationPostgres) GetMembers(ctx context.Context, orgId string) ([]entity.Member, error) {
+	var members []entity.Member
+	query := fmt.Sprintf(`SELECT u.*, m.role AS org_role, m.joined_at FROM %s u JOIN %s m ON m.user_id = u.id
+		WHERE m.organization = $1 ORDER BY u.name`, usersTable, membershipsTable)
+	err := repo.db.SelectContext(ctx, &members, query, orgId)
+
+	return members, err
+}
+
+// CountOwners is used to keep at least one owner in every organization.
+func (repo *OrganizationPostgres) CountOwners(ctx context.Context, orgId string) (int, error) {
+	var count int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE organization = $1 AND role = $2", membershipsTable)
+	err := repo.db.GetContext(ctx, &count, query, orgId, entity.OrgRoleOwner)
+
+	return count, err
+}
+
+// SetMember adds the user to the organization or changes their role.
+func (repo *OrganizationPostgres) SetMember(ctx context.Context, orgId, userId, role string) error {
+	query := fmt.Sprintf(`INSERT INTO %s (organization, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (organization, user_id) DO UPDATE SET role = EXCLUDED.role`, membershipsTable)
+	_, err := repo.db.ExecContext(ctx, query, orgId, userId, role)
+	return err
+}
+
+func (repo *OrganizationPostgres) RemoveMember(ctx context.Context, orgId, userId string) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE organization = $1 AND user_id = $2", membershipsTable)
+	result, err := repo.db.ExecContext(ctx, query, orgId, userId)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Contains reports whether the resource with the given id belongs to the
+// organization.
+func (repo *OrganizationPostgres) Contains(ctx context.Context, orgId, resource, id string) (bool, error) {
+	var condition string
+	switch resource {
+	case entity.ResourceUser:
+		condition = fmt.Sprintf("SELECT 1 FROM %s WHERE organization = $1 AND user_id = $2", membershipsTable)
+	case entity.ResourceProject:
+		condition = fmt.Sprintf("SELECT 1 FROM %s WHERE organization = $1 AND id = $2", projectsTable)
+	case entity.ResourceTask:
+		condition = fmt.Sprintf("SELECT 1 FROM %s WHERE id = $2 AND %s", tasksTable, inOrganization("project", 1))
+	case entity.ResourceSprint:
+		condition = fmt.Sprintf("SELECT 1 FROM %s WHERE id = $2 AND %s", sprintsTable, inOrganization("project", 1))
+	case entity.ResourceMilestone:
+		condition = fmt.Sprintf("SELECT 1 FROM %s WHERE id = $2 AND %s", milestonesTable, inOrganization("project", 1))
+	case entity.ResourceField:
+		condition = fmt.Sprintf("SELECT 1 FROM %s WHERE id = $2 AND %s", customFieldsTable, inOrganization("project", 1))
+	default:
+		return false, fmt.Errorf("unknown resource %q", resource)
+	}
+
+	var exists bool
+	err := repo.db.GetContext(ctx, &exists, fmt.Sprintf("SELECT EXISTS (%s)", condition), orgId, id)
+
+	return exists, err
+}
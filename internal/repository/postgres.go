@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"context"
 	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/rank"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"time"
@@ -11,6 +14,22 @@ const (
 	usersTable    = "users"
 	tasksTable    = "tasks"
 	projectsTable = "projects"
+	calendarTable = "calendar_tokens"
+	historyTable  = "task_status_changes"
+
+	notificationsTable = "notifications"
+	preferencesTable   = "notification_preferences"
+	emailTable         = "email_preferences"
+	outboxTable        = "email_outbox"
+	digestTable        = "email_digests"
+	seriesTable        = "task_series"
+	sprintsTable       = "sprints"
+	sprintTasksTable   = "sprint_tasks"
+	milestonesTable    = "milestones"
+	customFieldsTable  = "custom_fields"
+	organizationsTable = "organizations"
+	membershipsTable   = "memberships"
+	schemaTable        = "schema_version"
 )
 
 type Config struct {
@@ -22,30 +41,34 @@ type Config struct {
 	SSLMode  string
 }
 
-func NewPostgresDB(config Config) (*sqlx.DB, error) {
-	db, err := sqlx.Open("postgres", fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
-		config.Host, config.Port, config.Username, config.Database, config.Password, config.SSLMode))
+func (c Config) dataSource() string {
+	return fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
+		c.Host, c.Port, c.Username, c.Database, c.Password, c.SSLMode)
+}
 
+// NewPostgresDB opens the database and brings its schema up to date.
+func NewPostgresDB(config Config) (*sqlx.DB, error) {
+	db, err := OpenPostgresDB(config)
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.Ping()
-	if err != nil {
+	if err := Migrate(db); err != nil {
 		return nil, err
 	}
 
-	err = createUsersTable(db)
-	if err != nil {
-		return nil, err
-	}
+	return db, nil
+}
+
+// OpenPostgresDB opens the database without touching its schema.
+func OpenPostgresDB(config Config) (*sqlx.DB, error) {
+	db, err := sqlx.Open(instrumentedDriver, config.dataSource())
 
-	err = createProjectsTable(db)
 	if err != nil {
 		return nil, err
 	}
 
-	err = createTasksTable(db)
+	err = db.Ping()
 	if err != nil {
 		return nil, err
 	}
@@ -53,12 +76,49 @@ func NewPostgresDB(config Config) (*sqlx.DB, error) {
 	return db, nil
 }
 
-func Create(db *sqlx.DB, query string, args ...interface{}) (string, time.Time, error) {
+// migrations create the tables, columns and triggers that are missing. New
+// steps are appended, since their count is the schema version.
+var migrations = []func(*sqlx.DB) error{
+	createUsersTable,
+	createProjectsTable,
+	createTasksTable,
+	createCalendarTable,
+	createHistoryTable,
+	createNotificationsTables,
+	createEmailTables,
+	createSeriesTable,
+	createSprintTables,
+	createMilestonesTable,
+	createRankColumn,
+	createCustomFieldsTable,
+	createOrganizationsTables,
+	createTemplateColumns,
+	createArchiveTrigger,
+	createTaskEventsTrigger,
+}
+
+// SchemaVersion is the schema version that Migrate brings the database to.
+func SchemaVersion() int {
+	return len(migrations)
+}
+
+// Migrate runs the migrations and records the schema version. Every step is
+// idempotent, so it is safe to run on every start.
+func Migrate(db *sqlx.DB) error {
+	for _, migrate := range migrations {
+		if err := migrate(db); err != nil {
+			return err
+		}
+	}
+	return recordSchemaVersion(db)
+}
+
+func Create(ctx context.Context, db *sqlx.DB, query string, args ...interface{}) (string, time.Time, error) {
 	var id string
 	var timestamp time.Time
-	row := db.QueryRow(query, args...)
+	row := db.QueryRowContext(ctx, query, args...)
 	if err := row.Scan(&id, &timestamp); err != nil {
-		return "", time.Time{}, fmt.Errorf("error scanning row: %v", err)
+		return "", time.Time{}, fmt.Errorf("error scanning row: %w", err)
 	}
 	return id, timestamp, nil
 }
@@ -94,7 +154,11 @@ func createTasksTable(db *sqlx.DB) error {
 	    )
     `, tasksTable)
 
-	_, err := db.Exec(query)
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS due_at TIMESTAMP NULL", tasksTable))
 	return err
 }
 
@@ -113,3 +177,490 @@ func createProjectsTable(db *sqlx.DB) error {
 	_, err := db.Exec(query)
 	return err
 }
+
+func createCalendarTable(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %s (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		token VARCHAR(64) UNIQUE NOT NULL,
+		scope VARCHAR(50) NOT NULL,
+		target UUID NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		revoked_at TIMESTAMP NULL
+	)`, calendarTable)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+// createHistoryTable keeps a row per status a task has been in. A trigger
+// fills it so every code path that writes tasks is covered.
+func createHistoryTable(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s (
+		id BIGSERIAL PRIMARY KEY,
+		task UUID NOT NULL,
+		status VARCHAR(255) NOT NULL,
+		changed_at TIMESTAMP DEFAULT NOW(),
+		FOREIGN KEY (task) REFERENCES %[2]s(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS %[1]s_task_idx ON %[1]s (task, changed_at);
+
+	CREATE OR REPLACE FUNCTION record_task_status() RETURNS TRIGGER AS $$
+	BEGIN
+		IF TG_OP = 'INSERT' OR NEW.status IS DISTINCT FROM OLD.status THEN
+			INSERT INTO %[1]s (task, status) VALUES (NEW.id, NEW.status);
+		END IF;
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS task_status_history ON %[2]s;
+	CREATE TRIGGER task_status_history AFTER INSERT OR UPDATE OF status ON %[2]s
+		FOR EACH ROW EXECUTE FUNCTION record_task_status();
+	`, historyTable, tasksTable)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+// createNotificationsTables stores notifications and the types users turned
+// off. Messages quote task titles, so they are TEXT; the ALTER widens tables
+// created with VARCHAR(255).
+func createNotificationsTables(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL,
+		type VARCHAR(50) NOT NULL,
+		task UUID NULL,
+		message TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		read_at TIMESTAMP NULL,
+		FOREIGN KEY (user_id) REFERENCES %[3]s(id) ON DELETE CASCADE,
+		FOREIGN KEY (task) REFERENCES %[4]s(id) ON DELETE CASCADE
+	);
+
+	ALTER TABLE %[1]s ALTER COLUMN message TYPE TEXT;
+
+	CREATE INDEX IF NOT EXISTS %[1]s_user_idx ON %[1]s (user_id, created_at DESC);
+
+	CREATE TABLE IF NOT EXISTS %[2]s (
+		user_id UUID NOT NULL,
+		type VARCHAR(50) NOT NULL,
+		enabled BOOLEAN NOT NULL,
+		PRIMARY KEY (user_id, type),
+		FOREIGN KEY (user_id) REFERENCES %[3]s(id) ON DELETE CASCADE
+	)`, notificationsTable, preferencesTable, usersTable, tasksTable)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+func createEmailTables(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	ALTER TABLE %[3]s ADD COLUMN IF NOT EXISTS emailed_at TIMESTAMP NULL;
+
+	CREATE TABLE IF NOT EXISTS %[1]s (
+		user_id UUID PRIMARY KEY,
+		mode VARCHAR(20) NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES %[4]s(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS %[2]s (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL,
+		recipient VARCHAR(255) NOT NULL,
+		subject VARCHAR(255) NOT NULL,
+		text_body TEXT NOT NULL,
+		html_body TEXT NOT NULL,
+		attempts INT NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+		sent_at TIMESTAMP NULL,
+		last_error TEXT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		FOREIGN KEY (user_id) REFERENCES %[4]s(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS %[2]s_pending_idx ON %[2]s (next_attempt_at) WHERE sent_at IS NULL;
+
+	CREATE TABLE IF NOT EXISTS %[5]s (
+		id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+		queued_at TIMESTAMP NOT NULL
+	)`,
+		emailTable, outboxTable, notificationsTable, usersTable, digestTable)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+// createSeriesTable stores recurring tasks. Occurrences point back at their
+// series and keep existing as plain tasks when the series is stopped.
+func createSeriesTable(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		rule VARCHAR(255) NOT NULL,
+		trigger VARCHAR(20) NOT NULL,
+		start_at TIMESTAMP NOT NULL,
+		next_at TIMESTAMP NULL,
+		title VARCHAR(255) NOT NULL,
+		description VARCHAR(255) NOT NULL,
+		priority VARCHAR(255) NOT NULL,
+		status VARCHAR(255) NOT NULL,
+		assignee UUID NOT NULL,
+		project UUID NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		FOREIGN KEY (assignee) REFERENCES %[3]s(id) ON DELETE CASCADE,
+		FOREIGN KEY (project) REFERENCES %[4]s(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS %[1]s_next_idx ON %[1]s (next_at) WHERE next_at IS NOT NULL;
+
+	ALTER TABLE %[2]s ADD COLUMN IF NOT EXISTS series_id UUID NULL REFERENCES %[1]s(id) ON DELETE SET NULL;
+	ALTER TABLE %[2]s ADD COLUMN IF NOT EXISTS occurs_at TIMESTAMP NULL`,
+		seriesTable, tasksTable, usersTable, projectsTable)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+// createSprintTables adds sprints and a log of when each task entered and
+// left a sprint. Like the status history, the log is written by a trigger so
+// the sprint report sees every change of scope.
+func createSprintTables(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		project UUID NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		goal TEXT NOT NULL DEFAULT '',
+		start_at TIMESTAMP NOT NULL,
+		end_at TIMESTAMP NOT NULL,
+		capacity INT NOT NULL DEFAULT 0,
+		state VARCHAR(20) NOT NULL DEFAULT 'planned',
+		started_at TIMESTAMP NULL,
+		closed_at TIMESTAMP NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		FOREIGN KEY (project) REFERENCES %[4]s(id) ON DELETE CASCADE
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS %[1]s_active_idx ON %[1]s (project) WHERE state = 'active';
+
+	ALTER TABLE %[3]s ADD COLUMN IF NOT EXISTS sprint_id UUID NULL REFERENCES %[1]s(id) ON DELETE SET NULL;
+
+	CREATE TABLE IF NOT EXISTS %[2]s (
+		id BIGSERIAL PRIMARY KEY,
+		sprint UUID NOT NULL,
+		task UUID NOT NULL,
+		added_at TIMESTAMP NOT NULL DEFAULT NOW(),
+		removed_at TIMESTAMP NULL,
+		FOREIGN KEY (sprint) REFERENCES %[1]s(id) ON DELETE CASCADE,
+		FOREIGN KEY (task) REFERENCES %[3]s(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS %[2]s_sprint_idx ON %[2]s (sprint);
+
+	CREATE OR REPLACE FUNCTION record_task_sprint() RETURNS TRIGGER AS $$
+	BEGIN
+		IF TG_OP = 'UPDATE' AND OLD.sprint_id IS NOT NULL AND NEW.sprint_id IS DISTINCT FROM OLD.sprint_id THEN
+			UPDATE %[2]s SET removed_at = NOW() WHERE task = NEW.id AND sprint = OLD.sprint_id AND removed_at IS NULL;
+		END IF;
+		IF NEW.sprint_id IS NOT NULL AND (TG_OP = 'INSERT' OR NEW.sprint_id IS DISTINCT FROM OLD.sprint_id) THEN
+			INSERT INTO %[2]s (sprint, task) VALUES (NEW.sprint_id, NEW.id);
+		END IF;
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS task_sprint_history ON %[3]s;
+	CREATE TRIGGER task_sprint_history AFTER INSERT OR UPDATE OF sprint_id ON %[3]s
+		FOR EACH ROW EXECUTE FUNCTION record_task_sprint();
+	`, sprintsTable, sprintTasksTable, tasksTable, projectsTable)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+func createMilestonesTable(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		project UUID NOT NULL,
+		title VARCHAR(255) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		target_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		FOREIGN KEY (project) REFERENCES %[3]s(id) ON DELETE CASCADE
+	);
+
+	ALTER TABLE %[2]s ADD COLUMN IF NOT EXISTS milestone_id UUID NULL REFERENCES %[1]s(id) ON DELETE SET NULL;
+	CREATE INDEX IF NOT EXISTS %[2]s_milestone_idx ON %[2]s (milestone_id) WHERE milestone_id IS NOT NULL`,
+		milestonesTable, tasksTable, projectsTable)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+// createRankColumn adds the board position of a task within its project and
+// status. Ranks compare bytewise and are unique per column (deferrable so a
+// rebalance can reassign them). A task inserted without a rank, or moved to
+// another column without a new one, is placed at the bottom of its column
+// by next_task_rank, which mirrors rank.After. Moves that set placedSetting
+// chose their rank and are left alone even when it equals the old one; the appends to a column are
+// serialized by an advisory lock. Tasks left unranked by earlier
+// versions are placed the same way, bypassing the archive trigger.
+func createRankColumn(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS rank VARCHAR(255) COLLATE "C" NULL;
+
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = '%[1]s_rank_key') THEN
+			ALTER TABLE %[1]s ADD CONSTRAINT %[1]s_rank_key UNIQUE (project, status, rank) DEFERRABLE INITIALLY IMMEDIATE;
+		END IF;
+	END;
+	$$;
+
+	CREATE OR REPLACE FUNCTION next_task_rank(last TEXT) RETURNS TEXT AS $$
+	DECLARE
+		digits CONSTANT TEXT := '%[2]s';
+	BEGIN
+		IF last IS NULL OR last = '' THEN
+			RETURN '%[3]s';
+		END IF;
+		IF right(last, 1) = right(digits, 1) THEN
+			RETURN last || substr(digits, 2, 1);
+		END IF;
+		RETURN left(last, -1) || substr(digits, strpos(digits, right(last, 1)) + 1, 1);
+	END;
+	$$ LANGUAGE plpgsql IMMUTABLE;
+
+	CREATE OR REPLACE FUNCTION place_task_rank() RETURNS TRIGGER AS $$
+	BEGIN
+		IF (TG_OP = 'INSERT' AND NEW.rank IS NULL)
+			OR (TG_OP = 'UPDATE' AND (NEW.status IS DISTINCT FROM OLD.status OR NEW.project IS DISTINCT FROM OLD.project)
+				AND NEW.rank IS NOT DISTINCT FROM OLD.rank AND current_setting('%[5]s', true) IS DISTINCT FROM 'on') THEN
+			PERFORM pg_advisory_xact_lock(hashtext(NEW.project::text), hashtext(NEW.status));
+			NEW.rank := next_task_rank((SELECT max(rank) FROM %[1]s WHERE project = NEW.project AND status = NEW.status));
+		END IF;
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS task_rank_reset ON %[1]s;
+	DROP FUNCTION IF EXISTS reset_task_rank();
+	DROP TRIGGER IF EXISTS task_rank_place ON %[1]s;
+	CREATE TRIGGER task_rank_place BEFORE INSERT OR UPDATE OF status, project ON %[1]s
+		FOR EACH ROW EXECUTE FUNCTION place_task_rank();
+
+	DO $$
+	DECLARE
+		unranked RECORD;
+	BEGIN
+		PERFORM set_config('%[4]s', 'on', true);
+		FOR unranked IN SELECT id, project, status FROM %[1]s WHERE rank IS NULL ORDER BY created_at, id LOOP
+			PERFORM pg_advisory_xact_lock(hashtext(unranked.project::text), hashtext(unranked.status));
+			UPDATE %[1]s SET rank = next_task_rank((SELECT max(rank) FROM %[1]s WHERE project = unranked.project AND status = unranked.status))
+				WHERE id = unranked.id;
+		END LOOP;
+		PERFORM set_config('%[4]s', '', true);
+	END;
+	$$;
+	`, tasksTable, rank.Digits, rank.After(""), maintenanceSetting, placedSetting)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+// createCustomFieldsTable stores the field definitions of each project. The
+// values live on the task as a JSON object keyed by field id, and on a series
+// for the occurrences it creates.
+func createCustomFieldsTable(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		project UUID NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		type VARCHAR(20) NOT NULL,
+		options JSONB NOT NULL DEFAULT '[]',
+		required BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP DEFAULT NOW(),
+		UNIQUE (project, name),
+		FOREIGN KEY (project) REFERENCES %[3]s(id) ON DELETE CASCADE
+	);
+
+	ALTER TABLE %[2]s ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
+	CREATE INDEX IF NOT EXISTS %[2]s_custom_fields_idx ON %[2]s USING GIN (custom_fields);
+	ALTER TABLE %[4]s ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}'`,
+		customFieldsTable, tasksTable, projectsTable, seriesTable)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+// createOrganizationsTables adds tenants. Projects and calendar tokens that
+// predate organizations, and users without any membership on the first run,
+// are moved into the default organization.
+func createOrganizationsTables(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		name VARCHAR(255) NOT NULL,
+		slug VARCHAR(63) UNIQUE NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW()
+	);
+
+	CREATE TABLE IF NOT EXISTS %[2]s (
+		organization UUID NOT NULL,
+		user_id UUID NOT NULL,
+		role VARCHAR(20) NOT NULL,
+		joined_at TIMESTAMP DEFAULT NOW(),
+		PRIMARY KEY (organization, user_id),
+		FOREIGN KEY (organization) REFERENCES %[1]s(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES %[3]s(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS %[2]s_user_idx ON %[2]s (user_id);
+
+	INSERT INTO %[1]s (name, slug) VALUES ('Default', '%[6]s') ON CONFLICT (slug) DO NOTHING;
+
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM %[2]s) THEN
+			INSERT INTO %[2]s (organization, user_id, role)
+			SELECT o.id, u.id, '%[7]s' FROM %[3]s u, %[1]s o WHERE o.slug = '%[6]s';
+		END IF;
+	END $$;
+
+	ALTER TABLE %[4]s ADD COLUMN IF NOT EXISTS organization UUID NULL REFERENCES %[1]s(id) ON DELETE CASCADE;
+	UPDATE %[4]s SET organization = (SELECT id FROM %[1]s WHERE slug = '%[6]s') WHERE organization IS NULL;
+	ALTER TABLE %[4]s ALTER COLUMN organization SET NOT NULL;
+	CREATE INDEX IF NOT EXISTS %[4]s_organization_idx ON %[4]s (organization);
+
+	ALTER TABLE %[5]s ADD COLUMN IF NOT EXISTS organization UUID NULL REFERENCES %[1]s(id) ON DELETE CASCADE;
+	UPDATE %[5]s SET organization = (SELECT id FROM %[1]s WHERE slug = '%[6]s') WHERE organization IS NULL;
+	ALTER TABLE %[5]s ALTER COLUMN organization SET NOT NULL`,
+		organizationsTable, membershipsTable, usersTable, projectsTable, calendarTable,
+		entity.DefaultOrganization, entity.OrgRoleMember)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+// createTemplateColumns lets projects serve as templates and records the
+// date their schedule starts from.
+func createTemplateColumns(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS start_at TIMESTAMP NULL;
+	ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS is_template BOOLEAN NOT NULL DEFAULT FALSE`, projectsTable)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+// createArchiveTrigger freezes the tasks of archived projects, i.e. projects
+// with finished_at set. Changes made by foreign key actions, which run as
+// nested triggers, are let through: deleting an assignee, a sprint, a
+// milestone or a series, or the project itself, cascades to its tasks. So are
+// the repairs of FixIntegrity, which set the maintenance setting for their
+// transaction.
+func createArchiveTrigger(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	CREATE OR REPLACE FUNCTION freeze_archived_task() RETURNS TRIGGER AS $$
+	BEGIN
+		IF pg_trigger_depth() = 1 AND current_setting('%[4]s', true) IS DISTINCT FROM 'on' AND (
+				(TG_OP <> 'INSERT' AND EXISTS (SELECT 1 FROM %[2]s WHERE id = OLD.project AND finished_at IS NOT NULL))
+				OR (TG_OP <> 'DELETE' AND EXISTS (SELECT 1 FROM %[2]s WHERE id = NEW.project AND finished_at IS NOT NULL))) THEN
+			RAISE EXCEPTION 'project is archived' USING ERRCODE = '%[3]s';
+		END IF;
+
+		IF TG_OP = 'DELETE' THEN
+			RETURN OLD;
+		END IF;
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS task_archive_freeze ON %[1]s;
+	CREATE TRIGGER task_archive_freeze BEFORE INSERT OR UPDATE OR DELETE ON %[1]s
+		FOR EACH ROW EXECUTE FUNCTION freeze_archived_task();
+	`, tasksTable, projectsTable, archivedErrorCode, maintenanceSetting)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+// createTaskEventsTrigger announces every change to a task on the
+// task_events channel for ListenTaskEvents.
+func createTaskEventsTrigger(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	CREATE OR REPLACE FUNCTION notify_task_event() RETURNS TRIGGER AS $$
+	DECLARE
+		task RECORD;
+	BEGIN
+		IF TG_OP = 'DELETE' THEN
+			task := OLD;
+		ELSE
+			task := NEW;
+		END IF;
+
+		PERFORM pg_notify('%[3]s', json_build_object(
+			'type', lower(TG_OP),
+			'id', task.id,
+			'project', task.project,
+			'assignee', task.assignee,
+			'organization', (SELECT organization FROM %[2]s WHERE id = task.project)
+		)::text);
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS task_events ON %[1]s;
+	CREATE TRIGGER task_events AFTER INSERT OR UPDATE OR DELETE ON %[1]s
+		FOR EACH ROW EXECUTE FUNCTION notify_task_event();
+	`, tasksTable, projectsTable, taskEventsChannel)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+// inOrganization restricts a project column to the projects of the
+// organization bound to the given placeholder.
+func inOrganization(column string, arg int) string {
+	return fmt.Sprintf("%s IN (SELECT id FROM %s WHERE organization = $%d)", column, projectsTable, arg)
+}
+
+// inActiveOrganization is inOrganization that leaves out templates, whose
+// tasks are no real work, and archived projects unless includeArchived is
+// set.
+func inActiveOrganization(column string, arg int, includeArchived bool) string {
+	filter := "NOT is_template"
+	if !includeArchived {
+		filter += " AND finished_at IS NULL"
+	}
+	return fmt.Sprintf("%s IN (SELECT id FROM %s WHERE organization = $%d AND %s)", column, projectsTable, arg, filter)
+}
+
+// isMember restricts a user column to the members of the organization bound
+// to the given placeholder.
+func isMember(column string, arg int) string {
+	return fmt.Sprintf("%s IN (SELECT user_id FROM %s WHERE organization = $%d)", column, membershipsTable, arg)
+}
+
+// recordSchemaVersion keeps the version of the last migration in a table of
+// one row, for readiness checks.
+func recordSchemaVersion(db *sqlx.DB) error {
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s (
+		id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+		version INT NOT NULL,
+		migrated_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+	INSERT INTO %[1]s (version) VALUES (%[2]d)
+		ON CONFLICT (id) DO UPDATE SET version = EXCLUDED.version, migrated_at = NOW()`, schemaTable, SchemaVersion())
+
+	_, err := db.Exec(query)
+	return err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+)
+
+// archivedErrorCode is raised by the task_archive_freeze trigger.
+const archivedErrorCode = "PM409"
+
+var ErrProjectArchived = errors.New("project is archived")
+
+// archived translates the error of a task write rejected by the archive
+// trigger into ErrProjectArchived.
+func archived(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == archivedErrorCode {
+		return ErrProjectArchived
+	}
+	return err
+}
+
+// ArchiveProject gives the open tasks of the project the given status,
+// finishes them and then marks the project as finished, all in one
+// transaction. It returns the number of tasks closed.
+func (repo ProjectPostgres) ArchiveProject(ctx context.Context, id, status string, at time.Time) (int64, error) {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET status = $1, finished_at = $2 WHERE project = $3 AND finished_at IS NULL", tasksTable)
+	res, err := tx.ExecContext(ctx, query, status, at, id)
+	if err != nil {
+		tx.Rollback()
+		return 0, archived(err)
+	}
+
+	closed, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET finished_at = $1 WHERE id = $2 AND finished_at IS NULL", projectsTable)
+	if err := execAffected(ctx, tx, query, at, id); err != nil {
+		tx.Rollback()
+		if errors.Is(err, ErrTaskNotFound) {
+			return 0, ErrProjectArchived
+		}
+		return 0, err
+	}
+
+	return closed, tx.Commit()
+}
+
+func (repo ProjectPostgres) UnarchiveProject(ctx context.Context, id string) error {
+	query := fmt.Sprintf("UPDATE %s SET finished_at = NULL WHERE id = $1", projectsTable)
+	_, err := repo.db.ExecContext(ctx, query, id)
+	return err
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+)
+
+// CloneProject writes the copy in one transaction. Custom fields and
+// milestones are inserted first so that the tasks can point at the new ones.
+// Tasks are inserted unfinished and without a rank, in the order given, so
+// the rank trigger appends each one to its column.
+func (repo *ProjectPostgres) CloneProject(ctx context.Context, clone entity.ProjectCopy) (string, error) {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	project := clone.Project
+	var projectId string
+	query := fmt.Sprintf(`INSERT INTO %s (title, description, manager, organization, start_at, is_template)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`, projectsTable)
+	err = tx.QueryRowContext(ctx, query, project.Title, project.Description, project.Manager, project.Organization, project.StartAt, project.Template).Scan(&projectId)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	fieldIds := make(map[string]string, len(clone.Fields))
+	query = fmt.Sprintf("INSERT INTO %s (project, name, type, options, required) VALUES ($1, $2, $3, $4, $5) RETURNING id", customFieldsTable)
+	for _, field := range clone.Fields {
+		var id string
+		if err := tx.QueryRowContext(ctx, query, projectId, field.Name, field.Type, field.Options, field.Required).Scan(&id); err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		fieldIds[field.ID] = id
+	}
+
+	milestoneIds := make(map[string]string, len(clone.Milestones))
+	query = fmt.Sprintf("INSERT INTO %s (project, title, description, target_at) VALUES ($1, $2, $3, $4) RETURNING id", milestonesTable)
+	for _, milestone := range clone.Milestones {
+		var id string
+		if err := tx.QueryRowContext(ctx, query, projectId, milestone.Title, milestone.Description, milestone.TargetAt).Scan(&id); err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("milestone %s: %w", milestone.Title, err)
+		}
+		milestoneIds[milestone.ID] = id
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (title, description, priority, status, assignee, project, due_at, milestone_id, custom_fields)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`, tasksTable)
+	for i, task := range clone.Tasks {
+		values := make(entity.CustomValues, len(task.Fields))
+		for key, value := range task.Fields {
+			if id, ok := fieldIds[key]; ok {
+				values[id] = value
+			}
+		}
+
+		var milestone sql.NullString
+		if id, ok := milestoneIds[task.Milestone.String]; ok && task.Milestone.Valid {
+			milestone = sql.NullString{String: id, Valid: true}
+		}
+
+		_, err := tx.ExecContext(ctx, query, task.Title, task.Description, task.Priority, task.Status, task.Assignee, projectId, task.DueAt, milestone, values)
+		if err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("task %d: %w", i, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+
+	return projectId, nil
+}
@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"strings"
 	"time"
 )
@@ -12,12 +15,14 @@ type ProjectPostgres struct {
 	db *sqlx.DB
 }
 
-func (repo *ProjectPostgres) CreateProject(project entity.Project) (string, time.Time, error) {
-	query := fmt.Sprintf("INSERT INTO %s (title,description,manager) VALUES ($1,$2,$3) RETURNING id, created_at", projectsTable)
-	return Create(repo.db, query, project.Title, project.Description, project.Manager)
+func (repo *ProjectPostgres) CreateProject(ctx context.Context, project entity.Project) (string, time.Time, error) {
+	query := fmt.Sprintf("INSERT INTO %s (title,description,manager,organization) VALUES ($1,$2,$3,$4) RETURNING id, created_at", projectsTable)
+	return Create(ctx, repo.db, query, project.Title, project.Description, project.Manager, project.Organization)
 }
 
-func (repo ProjectPostgres) UpdateProject(id string, project entity.Project) error {
+// UpdateProject edits an unarchived project and returns ErrProjectArchived
+// otherwise. Projects are finished only by ArchiveProject.
+func (repo ProjectPostgres) UpdateProject(ctx context.Context, id string, project entity.Project) error {
 	setValues := make([]string, 0)
 	args := make([]interface{}, 0)
 	argId := 1
@@ -40,59 +45,94 @@ func (repo ProjectPostgres) UpdateProject(id string, project entity.Project) err
 		argId++
 	}
 
-	if project.FinishedAt.Valid {
-		setValues = append(setValues, fmt.Sprintf("finished_at=$%d", argId))
-		args = append(args, project.FinishedAt)
+	if project.StartAt.Valid {
+		setValues = append(setValues, fmt.Sprintf("start_at=$%d", argId))
+		args = append(args, project.StartAt)
 		argId++
 	}
 
 	setQuery := strings.Join(setValues, ", ")
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", projectsTable, setQuery, argId)
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d AND finished_at IS NULL", projectsTable, setQuery, argId)
 	args = append(args, id)
 
-	_, err := repo.db.Exec(query, args...)
-	return err
+	if err := execAffected(ctx, repo.db, query, args...); err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			return ErrProjectArchived
+		}
+		return err
+	}
+	return nil
 }
 
-func (repo ProjectPostgres) DeleteProject(id string) error {
+func (repo ProjectPostgres) DeleteProject(ctx context.Context, id string) error {
 	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", projectsTable)
-	_, err := repo.db.Exec(query, id)
+	_, err := repo.db.ExecContext(ctx, query, id)
 	return err
 }
 
-func (repo ProjectPostgres) GetByColumn(column, value string) (entity.Project, error) {
+func (repo ProjectPostgres) GetByColumn(ctx context.Context, column, value string) (entity.Project, error) {
 	var project entity.Project
 	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = $1", projectsTable, column)
-	err := repo.db.Get(&project, query, value)
+	err := repo.db.GetContext(ctx, &project, query, value)
 	if err != nil {
 		return entity.Project{}, err
 	}
 	return project, err
 }
 
-func (repo ProjectPostgres) GetProjectById(id string) (entity.Project, error) {
-	return repo.GetByColumn("id", id)
+func (repo ProjectPostgres) GetProjectById(ctx context.Context, id string) (entity.Project, error) {
+	return repo.GetByColumn(ctx, "id", id)
 }
 
-func (repo ProjectPostgres) GetProjectByTitle(title string) (entity.Project, error) {
-	return repo.GetByColumn("title", title)
+// GetProjectsByIds returns the projects of the organization among the given
+// ones, in no particular order.
+func (repo ProjectPostgres) GetProjectsByIds(ctx context.Context, orgId string, ids []string) ([]entity.Project, error) {
+	var projects []entity.Project
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = ANY($1) AND organization = $2", projectsTable)
+	err := repo.db.SelectContext(ctx, &projects, query, pq.Array(ids), orgId)
+
+	return projects, err
 }
 
-func (repo ProjectPostgres) GetProjectByManagerId(managerId string) ([]entity.Project, error) {
+func (repo ProjectPostgres) GetProjectByTitle(ctx context.Context, orgId, title string) (entity.Project, error) {
+	var project entity.Project
+	query := fmt.Sprintf("SELECT * FROM %s WHERE title = $1 AND organization = $2", projectsTable)
+	err := repo.db.GetContext(ctx, &project, query, title, orgId)
+
+	return project, err
+}
+
+func (repo ProjectPostgres) GetProjectByManagerId(ctx context.Context, orgId, managerId string, includeArchived bool) ([]entity.Project, error) {
 	var projects []entity.Project
-	query := fmt.Sprintf("SELECT * FROM %s WHERE Manager = $1", projectsTable)
-	err := repo.db.Select(&projects, query, managerId)
+	query := fmt.Sprintf("SELECT * FROM %s WHERE Manager = $1 AND organization = $2%s", projectsTable, activeProjects(includeArchived))
+	err := repo.db.SelectContext(ctx, &projects, query, managerId, orgId)
+
+	return projects, err
+}
 
+func (repo ProjectPostgres) GetAllProjects(ctx context.Context, orgId string, includeArchived bool) ([]entity.Project, error) {
+	var projects []entity.Project
+	query := fmt.Sprintf("SELECT * FROM %s WHERE organization = $1 AND NOT is_template%s", projectsTable, activeProjects(includeArchived))
+	err := repo.db.SelectContext(ctx, &projects, query, orgId)
 	return projects, err
 }
 
-func (repo ProjectPostgres) GetAllProjects() ([]entity.Project, error) {
+func (repo ProjectPostgres) GetTemplates(ctx context.Context, orgId string) ([]entity.Project, error) {
 	var projects []entity.Project
-	query := fmt.Sprintf("SELECT * FROM %s", projectsTable)
-	err := repo.db.Select(&projects, query)
+	query := fmt.Sprintf("SELECT * FROM %s WHERE organization = $1 AND is_template ORDER BY title", projectsTable)
+	err := repo.db.SelectContext(ctx, &projects, query, orgId)
 	return projects, err
 }
 
+// activeProjects is the condition leaving out archived projects, unless
+// includeArchived is set.
+func activeProjects(includeArchived bool) string {
+	if includeArchived {
+		return ""
+	}
+	return " AND finished_at IS NULL"
+}
+
 func NewProjectPostgres(db *sqlx.DB) *ProjectPostgres {
 	return &ProjectPostgres{db: db}
 }
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// Every report buckets time with generate_series; a bucket starts at point
+// and the figures describe the project as of the end of the bucket.
+const reportSeries = "generate_series($2::timestamp, $3::timestamp, ('1 ' || $4)::interval) AS p(point)"
+
+type ReportPostgres struct {
+	db *sqlx.DB
+}
+
+func NewReportPostgres(db *sqlx.DB) *ReportPostgres {
+	return &ReportPostgres{db: db}
+}
+
+func (repo *ReportPostgres) Burndown(ctx context.Context, projectId string, period entity.ReportRange) ([]entity.BurndownPoint, error) {
+	var points []entity.BurndownPoint
+	query := fmt.Sprintf(`
+	SELECT p.point,
+		COUNT(t.id) FILTER (WHERE t.created_at < p.point + ('1 ' || $4)::interval) AS total,
+		COUNT(t.id) FILTER (WHERE t.created_at < p.point + ('1 ' || $4)::interval
+			AND (t.finished_at IS NULL OR t.finished_at >= p.point + ('1 ' || $4)::interval)) AS remaining
+	FROM %s
+	LEFT JOIN %s t ON t.project = $1
+	GROUP BY p.point
+	ORDER BY p.point`, reportSeries, tasksTable)
+
+	err := repo.db.SelectContext(ctx, &points, query, projectId, period.From, period.To, period.Interval)
+	return points, err
+}
+
+// CumulativeFlow counts tasks per status at the end of each bucket. The
+// status comes from the status history; tasks created before history was
+// recorded fall back to their current status.
+func (repo *ReportPostgres) CumulativeFlow(ctx context.Context, projectId string, period entity.ReportRange) ([]entity.StatusCount, error) {
+	var counts []entity.StatusCount
+	query := fmt.Sprintf(`
+	SELECT p.point, s.status, COUNT(*) AS count
+	FROM %s
+	JOIN %s t ON t.project = $1 AND t.created_at < p.point + ('1 ' || $4)::interval
+	CROSS JOIN LATERAL (
+		SELECT COALESCE((
+			SELECT h.status FROM %s h
+			WHERE h.task = t.id AND h.changed_at < p.point + ('1 ' || $4)::interval
+			ORDER BY h.changed_at DESC LIMIT 1
+		), t.status) AS status
+	) s
+	GROUP BY p.point, s.status
+	ORDER BY p.point, s.status`, reportSeries, tasksTable, historyTable)
+
+	err := repo.db.SelectContext(ctx, &counts, query, projectId, period.From, period.To, period.Interval)
+	return counts, err
+}
+
+func (repo *ReportPostgres) Throughput(ctx context.Context, projectId string, period entity.ReportRange) ([]entity.ThroughputPoint, error) {
+	var points []entity.ThroughputPoint
+	query := fmt.Sprintf(`
+	SELECT p.point, COUNT(t.id) AS finished
+	FROM %s
+	LEFT JOIN %s t ON t.project = $1
+		AND t.finished_at >= p.point AND t.finished_at < p.point + ('1 ' || $4)::interval
+	GROUP BY p.point
+	ORDER BY p.point`, reportSeries, tasksTable)
+
+	err := repo.db.SelectContext(ctx, &points, query, projectId, period.From, period.To, period.Interval)
+	return points, err
+}
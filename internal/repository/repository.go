@@ -1,54 +1,211 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
 	"github.com/jmoiron/sqlx"
 	"time"
 )
 
 type User interface {
-	CreateUser(user entity.User) (string, time.Time, error)
-	UpdateUser(id string, user entity.User) error
-	DeleteUser(id string) error
-	GetUserById(id string) (entity.User, error)
-	GetUserByName(name string) (entity.User, error)
-	GetAllUsers() ([]entity.User, error)
-	GetUserByEmail(email string) (entity.User, error)
+	CreateUser(ctx context.Context, orgId string, user entity.User) (string, time.Time, error)
+	UpdateUser(ctx context.Context, id string, user entity.User) error
+	DeleteUser(ctx context.Context, id string) error
+	GetUserById(ctx context.Context, id string) (entity.User, error)
+	GetUsersByIds(ctx context.Context, orgId string, ids []string) ([]entity.User, error)
+	GetUserByName(ctx context.Context, orgId, name string) (entity.User, error)
+	GetAllUsers(ctx context.Context, orgId string) ([]entity.User, error)
+	GetUserByEmail(ctx context.Context, orgId, email string) (entity.User, error)
+	GetProjectMember(ctx context.Context, projectId, id string) (entity.User, error)
 }
 
 type Task interface {
-	CreateTask(task entity.Task) (string, time.Time, error)
-	UpdateTask(id string, task entity.Task) error
-	DeleteTask(id string) error
-	GetTaskById(id string) (entity.Task, error)
-	GetAllTasks() ([]entity.Task, error)
-	GetTaskByTitle(title string) (entity.Task, error)
-	GetTaskByStatus(status string) ([]entity.Task, error)
-	GetTaskByPriority(priority string) ([]entity.Task, error)
-	GetTasksByUserId(userId, column string) ([]entity.Task, error)
-	GetTasksByProjectId(projectId string) ([]entity.Task, error)
+	CreateTask(ctx context.Context, task entity.Task) (string, time.Time, error)
+	UpdateTask(ctx context.Context, id string, task entity.Task) error
+	DeleteTask(ctx context.Context, id string) error
+	GetTaskById(ctx context.Context, id string) (entity.Task, error)
+	GetAllTasks(ctx context.Context, orgId string, includeArchived bool) ([]entity.Task, error)
+	GetTaskByTitle(ctx context.Context, orgId, title string) (entity.Task, error)
+	GetTaskByStatus(ctx context.Context, orgId, status string, includeArchived bool) ([]entity.Task, error)
+	GetTaskByPriority(ctx context.Context, orgId, priority string, includeArchived bool) ([]entity.Task, error)
+	GetTasksByUserId(ctx context.Context, orgId, userId, column string, includeArchived bool) ([]entity.Task, error)
+	GetTasksByProjectId(ctx context.Context, projectId string) ([]entity.Task, error)
+	GetTasksByProjectIds(ctx context.Context, ids []string) ([]entity.Task, error)
+	GetTasksByAssignees(ctx context.Context, orgId string, ids []string, includeArchived bool) ([]entity.Task, error)
+	BulkTasks(ctx context.Context, items []entity.BulkTaskItem, atomic bool) ([]entity.BulkTaskResult, error)
+	ReassignTasks(ctx context.Context, orgId, from string, input entity.ReassignTasks) (int64, error)
+	StreamTasksByProjectId(ctx context.Context, projectId string, fn func(entity.TaskExport) error) error
+	CreateTasks(ctx context.Context, tasks []entity.Task) ([]string, error)
+	GetColumn(ctx context.Context, projectId, status string) ([]entity.TaskRank, error)
+	MoveTask(ctx context.Context, id, status, key string) error
+	RebalanceColumn(ctx context.Context, projectId, status string) error
 }
 
 type Project interface {
-	CreateProject(project entity.Project) (string, time.Time, error)
-	UpdateProject(id string, project entity.Project) error
-	DeleteProject(id string) error
-	GetProjectById(id string) (entity.Project, error)
-	GetProjectByTitle(title string) (entity.Project, error)
-	GetProjectByManagerId(managerId string) ([]entity.Project, error)
-	GetAllProjects() ([]entity.Project, error)
+	CreateProject(ctx context.Context, project entity.Project) (string, time.Time, error)
+	UpdateProject(ctx context.Context, id string, project entity.Project) error
+	DeleteProject(ctx context.Context, id string) error
+	GetProjectById(ctx context.Context, id string) (entity.Project, error)
+	GetProjectsByIds(ctx context.Context, orgId string, ids []string) ([]entity.Project, error)
+	GetProjectByTitle(ctx context.Context, orgId, title string) (entity.Project, error)
+	GetProjectByManagerId(ctx context.Context, orgId, managerId string, includeArchived bool) ([]entity.Project, error)
+	GetAllProjects(ctx context.Context, orgId string, includeArchived bool) ([]entity.Project, error)
+	GetTemplates(ctx context.Context, orgId string) ([]entity.Project, error)
+	CloneProject(ctx context.Context, clone entity.ProjectCopy) (string, error)
+	ArchiveProject(ctx context.Context, id, status string, at time.Time) (int64, error)
+	UnarchiveProject(ctx context.Context, id string) error
+}
+
+type Calendar interface {
+	CreateCalendarToken(ctx context.Context, token entity.CalendarToken) (string, time.Time, error)
+	GetCalendarToken(ctx context.Context, token string) (entity.CalendarToken, error)
+	RevokeCalendarToken(ctx context.Context, token string) (int64, error)
+}
+
+type Report interface {
+	Burndown(ctx context.Context, projectId string, period entity.ReportRange) ([]entity.BurndownPoint, error)
+	CumulativeFlow(ctx context.Context, projectId string, period entity.ReportRange) ([]entity.StatusCount, error)
+	Throughput(ctx context.Context, projectId string, period entity.ReportRange) ([]entity.ThroughputPoint, error)
+}
+
+type Dashboard interface {
+	OpenTaskCounts(ctx context.Context, orgId, userId string) ([]entity.TaskCount, error)
+	DueSoonTasks(ctx context.Context, orgId, userId string, before time.Time, limit int) ([]entity.Task, error)
+	RecentlyChangedTasks(ctx context.Context, orgId, userId string, since time.Time, limit int) ([]entity.Task, error)
+	ManagedProjectProgress(ctx context.Context, orgId, userId string) ([]entity.ProjectProgress, error)
+	CountOpenTasks(ctx context.Context) ([]entity.TaskCount, error)
+	CountActiveProjects(ctx context.Context) (int, error)
+}
+
+type Notification interface {
+	CreateNotification(ctx context.Context, notification entity.Notification) (string, time.Time, error)
+	CreateDueSoonNotifications(ctx context.Context, before time.Time) (int64, error)
+	GetNotifications(ctx context.Context, userId string, unreadOnly bool, limit, offset int) ([]entity.Notification, error)
+	CountUnread(ctx context.Context, userId string) (int, error)
+	MarkRead(ctx context.Context, userId, id string) (int64, error)
+	MarkAllRead(ctx context.Context, userId string) (int64, error)
+	GetPreferences(ctx context.Context, userId string) ([]entity.NotificationPreference, error)
+	SetPreference(ctx context.Context, userId string, preference entity.NotificationPreference) error
+}
+
+type Email interface {
+	GetEmailMode(ctx context.Context, userId string) (string, error)
+	SetEmailMode(ctx context.Context, userId, mode string) error
+	PendingNotifications(ctx context.Context, mode string, since time.Time, limit int) ([]entity.PendingNotification, error)
+	QueueEmail(ctx context.Context, email entity.Email, notificationIds []string) error
+	ClaimEmails(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]entity.Email, error)
+	MarkEmailSent(ctx context.Context, id string) error
+	MarkEmailFailed(ctx context.Context, id, lastError string, nextAttempt time.Time) error
+	GetLastDigest(ctx context.Context) (time.Time, error)
+	SetLastDigest(ctx context.Context, queuedAt time.Time) error
+}
+
+type Series interface {
+	CreateSeries(ctx context.Context, taskId string, series entity.TaskSeries) (string, error)
+	GetSeries(ctx context.Context, id string) (entity.TaskSeries, error)
+	DueSeries(ctx context.Context, before time.Time, limit int) ([]entity.TaskSeries, error)
+	CreateOccurrence(ctx context.Context, series entity.TaskSeries, occursAt time.Time, next sql.NullTime) (string, error)
+	UpdateSeries(ctx context.Context, series entity.TaskSeries, from time.Time, task entity.Task) error
+	LatestOccurrence(ctx context.Context, id string) (time.Time, error)
+	DeleteSeries(ctx context.Context, id string) error
+}
+
+type Sprint interface {
+	CreateSprint(ctx context.Context, sprint entity.Sprint) (string, time.Time, error)
+	GetSprint(ctx context.Context, id string) (entity.Sprint, error)
+	GetSprintsByProject(ctx context.Context, projectId string) ([]entity.Sprint, error)
+	UpdateSprint(ctx context.Context, sprint entity.Sprint) error
+	DeleteSprint(ctx context.Context, id string) error
+	StartSprint(ctx context.Context, id string) (int64, error)
+	CloseSprint(ctx context.Context, id string, next sql.NullString) (int64, error)
+	AddSprintTasks(ctx context.Context, id, projectId string, taskIds []string) (int64, error)
+	RemoveSprintTask(ctx context.Context, id, taskId string) (int64, error)
+	GetSprintTasks(ctx context.Context, id string) ([]entity.Task, error)
+	SprintReport(ctx context.Context, id string, start, end time.Time) (entity.SprintReport, error)
+}
+
+type Milestone interface {
+	CreateMilestone(ctx context.Context, milestone entity.Milestone) (string, time.Time, error)
+	GetMilestone(ctx context.Context, id string) (entity.MilestoneProgress, error)
+	GetMilestonesByProject(ctx context.Context, projectId string) ([]entity.MilestoneProgress, error)
+	UpdateMilestone(ctx context.Context, milestone entity.Milestone) error
+	DeleteMilestone(ctx context.Context, id string) error
+	AddMilestoneTasks(ctx context.Context, id, projectId string, taskIds []string) (int64, error)
+	RemoveMilestoneTask(ctx context.Context, id, taskId string) (int64, error)
+	GetMilestoneTasks(ctx context.Context, id string) ([]entity.Task, error)
+}
+
+type CustomField interface {
+	CreateField(ctx context.Context, field entity.CustomField) (string, time.Time, error)
+	GetField(ctx context.Context, id string) (entity.CustomField, error)
+	GetFields(ctx context.Context, projectId string) ([]entity.CustomField, error)
+	UpdateField(ctx context.Context, field entity.CustomField) error
+	DeleteField(ctx context.Context, field entity.CustomField) error
+	CountTasksWithoutField(ctx context.Context, projectId, fieldId string) (int, error)
+	SetTaskFields(ctx context.Context, taskId string, values entity.CustomValues, remove []string) error
+	GetTasksByFields(ctx context.Context, projectId string, filters []entity.FieldFilter, sort *entity.FieldSort) ([]entity.Task, error)
+}
+
+type Organization interface {
+	CreateOrganization(ctx context.Context, organization entity.Organization, ownerId string) (string, time.Time, error)
+	GetOrganization(ctx context.Context, id string) (entity.Organization, error)
+	GetOrganizationBySlug(ctx context.Context, slug string) (entity.Organization, error)
+	UpdateOrganization(ctx context.Context, organization entity.Organization) error
+	DeleteOrganization(ctx context.Context, id string) error
+	GetUserOrganizations(ctx context.Context, userId string) ([]entity.UserOrganization, error)
+	GetMembership(ctx context.Context, orgId, userId string) (entity.Membership, error)
+	GetMembers(ctx context.Context, orgId string) ([]entity.Member, error)
+	CountOwners(ctx context.Context, orgId string) (int, error)
+	SetMember(ctx context.Context, orgId, userId, role string) error
+	RemoveMember(ctx context.Context, orgId, userId string) (int64, error)
+	Contains(ctx context.Context, orgId, resource, id string) (bool, error)
+}
+
+type Integrity interface {
+	CheckIntegrity(ctx context.Context) ([]entity.Inconsistency, error)
+	FixIntegrity(ctx context.Context) ([]entity.IntegrityFix, error)
+}
+
+type Health interface {
+	Ping(ctx context.Context) error
+	GetSchemaVersion(ctx context.Context) (int, error)
 }
 
 type Repository struct {
 	User
 	Task
 	Project
+	Calendar
+	Report
+	Dashboard
+	Notification
+	Email
+	Series
+	Sprint
+	Milestone
+	CustomField
+	Organization
+	Integrity
+	Health
 }
 
 func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{
-		User:    NewUserPostgres(db),
-		Task:    NewTaskPostgres(db),
-		Project: NewProjectPostgres(db),
+		User:         NewUserPostgres(db),
+		Task:         NewTaskPostgres(db),
+		Project:      NewProjectPostgres(db),
+		Calendar:     NewCalendarPostgres(db),
+		Report:       NewReportPostgres(db),
+		Dashboard:    NewDashboardPostgres(db),
+		Notification: NewNotificationPostgres(db),
+		Email:        NewEmailPostgres(db),
+		Series:       NewSeriesPostgres(db),
+		Sprint:       NewSprintPostgres(db),
+		Milestone:    NewMilestonePostgres(db),
+		CustomField:  NewCustomFieldPostgres(db),
+		Organization: NewOrganizationPostgres(db),
+		Integrity:    NewIntegrityPostgres(db),
+		Health:       NewHealthPostgres(db),
 	}
 }
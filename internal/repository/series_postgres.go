@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/jmoiron/sqlx"
+	"strings"
+	"time"
+)
+
+// ErrSeriesChanged is returned when another worker advanced the series
+// first, so the occurrence must not be created twice.
+var ErrSeriesChanged = errors.New("series was changed concurrently")
+
+type SeriesPostgres struct {
+	db *sqlx.DB
+}
+
+func NewSeriesPostgres(db *sqlx.DB) *SeriesPostgres {
+	return &SeriesPostgres{db: db}
+}
+
+// CreateSeries stores the series and makes the task its first occurrence.
+func (repo *SeriesPostgres) CreateSeries(ctx context.Context, taskId string, series entity.TaskSeries) (string, error) {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	query := fmt.Sprintf(`INSERT INTO %s (rule, trigger, start_at, next_at, title, description, priority, status, assignee, project, custom_fields)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`, seriesTable)
+	err = tx.QueryRowContext(ctx, query, series.Rule, series.Trigger, series.StartAt, series.NextAt, series.Title, series.Description,
+		series.Priority, series.Status, series.Assignee, series.Project, series.Fields).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET series_id = $1, occurs_at = $2 WHERE id = $3", tasksTable)
+	if err := execAffected(ctx, tx, query, id, series.StartAt, taskId); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	return id, tx.Commit()
+}
+
+func (repo *SeriesPostgres) GetSeries(ctx context.Context, id string) (entity.TaskSeries, error) {
+	var series entity.TaskSeries
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", seriesTable)
+	err := repo.db.GetContext(ctx, &series, query, id)
+
+	return series, err
+}
+
+// DueSeries lists scheduled series whose next occurrence is due before the
+// given moment. Series of archived projects are paused.
+func (repo *SeriesPostgres) DueSeries(ctx context.Context, before time.Time, limit int) ([]entity.TaskSeries, error) {
+	var series []entity.TaskSeries
+	query := fmt.Sprintf("SELECT * FROM %s WHERE trigger = $1 AND next_at <= $2 AND project NOT IN (SELECT id FROM %s WHERE finished_at IS NOT NULL) ORDER BY next_at LIMIT $3",
+		seriesTable, projectsTable)
+	err := repo.db.SelectContext(ctx, &series, query, entity.RecurOnSchedule, before, limit)
+
+	return series, err
+}
+
+// CreateOccurrence inserts the occurrence at the given moment from the
+// series template and moves the series on to next. The series row is only
+// advanced if nobody else did it since it was read.
+func (repo *SeriesPostgres) CreateOccurrence(ctx context.Context, series entity.TaskSeries, occursAt time.Time, next sql.NullTime) (string, error) {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET next_at = $1 WHERE id = $2 AND next_at = $3", seriesTable)
+	if err := execAffected(ctx, tx, query, next, series.ID, series.NextAt); err != nil {
+		tx.Rollback()
+		if errors.Is(err, ErrTaskNotFound) {
+			return "", ErrSeriesChanged
+		}
+		return "", err
+	}
+
+	var id string
+	query = fmt.Sprintf(`INSERT INTO %s (title, description, priority, status, assignee, project, due_at, series_id, occurs_at, custom_fields)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $7, $9) RETURNING id`, tasksTable)
+	err = tx.QueryRowContext(ctx, query, series.Title, series.Description, series.Priority, series.Status, series.Assignee, series.Project,
+		occursAt, series.ID, series.Fields).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	return id, tx.Commit()
+}
+
+// UpdateSeries saves the series and applies the changed task fields to every
+// unfinished occurrence from the given moment on.
+func (repo *SeriesPostgres) UpdateSeries(ctx context.Context, series entity.TaskSeries, from time.Time, task entity.Task) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET rule = $1, trigger = $2, start_at = $3, next_at = $4, title = $5, description = $6,
+		priority = $7, status = $8, assignee = $9 WHERE id = $10`, seriesTable)
+	_, err = tx.ExecContext(ctx, query, series.Rule, series.Trigger, series.StartAt, series.NextAt, series.Title, series.Description,
+		series.Priority, series.Status, series.Assignee, series.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if setValues, args := taskAssignments(task); len(setValues) > 0 {
+		query = fmt.Sprintf("UPDATE %s SET %s WHERE series_id = $%d AND occurs_at >= $%d AND finished_at IS NULL",
+			tasksTable, strings.Join(setValues, ", "), len(args)+1, len(args)+2)
+		if _, err := tx.ExecContext(ctx, query, append(args, series.ID, from)...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// LatestOccurrence returns when the most recently generated occurrence of
+// the series takes place.
+func (repo *SeriesPostgres) LatestOccurrence(ctx context.Context, id string) (time.Time, error) {
+	var latest time.Time
+	query := fmt.Sprintf("SELECT MAX(occurs_at) FROM %s WHERE series_id = $1", tasksTable)
+	err := repo.db.GetContext(ctx, &latest, query, id)
+
+	return latest, err
+}
+
+func (repo *SeriesPostgres) DeleteSeries(ctx context.Context, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", seriesTable)
+	_, err := repo.db.ExecContext(ctx, query, id)
+	return err
+}
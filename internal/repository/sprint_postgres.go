@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"time"
+)
+
+type SprintPostgres struct {
+	db *sqlx.DB
+}
+
+func NewSprintPostgres(db *sqlx.DB) *SprintPostgres {
+	return &SprintPostgres{db: db}
+}
+
+func (repo *SprintPostgres) CreateSprint(ctx context.Context, sprint entity.Sprint) (string, time.Time, error) {
+	query := fmt.Sprintf("INSERT INTO %s (project, name, goal, start_at, end_at, capacity) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at", sprintsTable)
+	return Create(ctx, repo.db, query, sprint.Project, sprint.Name, sprint.Goal, sprint.StartAt, sprint.EndAt, sprint.Capacity)
+}
+
+func (repo *SprintPostgres) GetSprint(ctx context.Context, id string) (entity.Sprint, error) {
+	var sprint entity.Sprint
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", sprintsTable)
+	err := repo.db.GetContext(ctx, &sprint, query, id)
+
+	return sprint, err
+}
+
+func (repo *SprintPostgres) GetSprintsByProject(ctx context.Context, projectId string) ([]entity.Sprint, error) {
+	var sprints []entity.Sprint
+	query := fmt.Sprintf("SELECT * FROM %s WHERE project = $1 ORDER BY start_at", sprintsTable)
+	err := repo.db.SelectContext(ctx, &sprints, query, projectId)
+
+	return sprints, err
+}
+
+func (repo *SprintPostgres) UpdateSprint(ctx context.Context, sprint entity.Sprint) error {
+	query := fmt.Sprintf("UPDATE %s SET name = $1, goal = $2, start_at = $3, end_at = $4, capacity = $5 WHERE id = $6", sprintsTable)
+	_, err := repo.db.ExecContext(ctx, query, sprint.Name, sprint.Goal, sprint.StartAt, sprint.EndAt, sprint.Capacity, sprint.ID)
+	return err
+}
+
+func (repo *SprintPostgres) DeleteSprint(ctx context.Context, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", sprintsTable)
+	_, err := repo.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// StartSprint activates a planned sprint. The unique index on active
+// sprints rejects a second active sprint in the same project, which is
+// reported like a sprint that is not planned: as no sprint started.
+func (repo *SprintPostgres) StartSprint(ctx context.Context, id string) (int64, error) {
+	query := fmt.Sprintf("UPDATE %s SET state = $1, started_at = NOW() WHERE id = $2 AND state = $3", sprintsTable)
+	res, err := repo.db.ExecContext(ctx, query, entity.SprintActive, id, entity.SprintPlanned)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// CloseSprint closes an active sprint and moves its unfinished tasks to the
+// next sprint, or to the backlog when next is not set. Both happen in one
+// transaction so the moved tasks leave the sprint exactly when it closes.
+func (repo *SprintPostgres) CloseSprint(ctx context.Context, id string, next sql.NullString) (int64, error) {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET state = $1, closed_at = NOW() WHERE id = $2 AND state = $3", sprintsTable)
+	if err := execAffected(ctx, tx, query, entity.SprintClosed, id, entity.SprintActive); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET sprint_id = $1 WHERE sprint_id = $2 AND finished_at IS NULL", tasksTable)
+	res, err := tx.ExecContext(ctx, query, next, id)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	moved, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return moved, tx.Commit()
+}
+
+// AddSprintTasks puts the tasks into the sprint. Tasks of other projects are
+// left alone.
+func (repo *SprintPostgres) AddSprintTasks(ctx context.Context, id, projectId string, taskIds []string) (int64, error) {
+	query := fmt.Sprintf("UPDATE %s SET sprint_id = $1 WHERE id = ANY($2) AND project = $3", tasksTable)
+	res, err := repo.db.ExecContext(ctx, query, id, pq.Array(taskIds), projectId)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (repo *SprintPostgres) RemoveSprintTask(ctx context.Context, id, taskId string) (int64, error) {
+	query := fmt.Sprintf("UPDATE %s SET sprint_id = NULL WHERE id = $1 AND sprint_id = $2", tasksTable)
+	res, err := repo.db.ExecContext(ctx, query, taskId, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (repo *SprintPostgres) GetSprintTasks(ctx context.Context, id string) ([]entity.Task, error) {
+	var tasks []entity.Task
+	query := fmt.Sprintf("SELECT * FROM %s WHERE sprint_id = $1 ORDER BY created_at", tasksTable)
+	err := repo.db.SelectContext(ctx, &tasks, query, id)
+
+	return tasks, err
+}
+
+// SprintReport counts the sprint's scope between start and end from the
+// membership log. Committed tasks were in the sprint when it started; a task
+// counts as completed if it was finished while it belonged to the sprint.
+func (repo *SprintPostgres) SprintReport(ctx context.Context, id string, start, end time.Time) (entity.SprintReport, error) {
+	var report entity.SprintReport
+	query := fmt.Sprintf(`
+	WITH membership AS (
+		SELECT m.task, t.finished_at,
+			bool_or(m.added_at <= $2 AND (m.removed_at IS NULL OR m.removed_at > $2)) AS committed,
+			bool_or(m.added_at > $2 AND m.added_at < $3) AS added,
+			bool_or(m.removed_at > $2 AND m.removed_at < $3) AS removed,
+			bool_or(m.removed_at IS NULL OR m.removed_at >= $3) AS present,
+			bool_or(t.finished_at IS NOT NULL AND t.finished_at <= $3
+				AND m.added_at <= t.finished_at AND (m.removed_at IS NULL OR m.removed_at >= t.finished_at)) AS completed
+		FROM %s m
+		JOIN %s t ON t.id = m.task
+		WHERE m.sprint = $1 AND m.added_at < $3
+		GROUP BY m.task, t.finished_at
+	)
+	SELECT
+		COUNT(*) FILTER (WHERE committed) AS committed,
+		COUNT(*) FILTER (WHERE added) AS added,
+		COUNT(*) FILTER (WHERE removed AND NOT present) AS removed,
+		COUNT(*) FILTER (WHERE completed) AS completed,
+		COUNT(*) FILTER (WHERE completed AND committed) AS committed_completed,
+		COUNT(*) FILTER (WHERE completed AND added AND NOT committed) AS added_completed,
+		COUNT(*) FILTER (WHERE present AND NOT completed) AS remaining
+	FROM membership`, sprintTasksTable, tasksTable)
+
+	err := repo.db.GetContext(ctx, &report, query, id, start, end)
+	return report, err
+}
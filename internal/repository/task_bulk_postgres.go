@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+var ErrTaskNotFound = errors.New("task not found")
+
+// BulkTasks applies all items inside a single transaction. In atomic mode the
+// first failing item rolls back the whole batch, otherwise every item runs
+// behind its own savepoint so a failure only undoes that item.
+func (repo TaskPostgres) BulkTasks(ctx context.Context, items []entity.BulkTaskItem, atomic bool) ([]entity.BulkTaskResult, error) {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]entity.BulkTaskResult, 0, len(items))
+	for i, item := range items {
+		if !atomic {
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT bulk_item"); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+
+		id, err := applyBulkItem(ctx, tx, item)
+		result := entity.BulkTaskResult{Index: i, Op: item.Op, ID: id}
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+
+			if atomic {
+				tx.Rollback()
+				return results, fmt.Errorf("item %d: %v", i, err)
+			}
+
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT bulk_item"); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			continue
+		}
+
+		if !atomic {
+			if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT bulk_item"); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func applyBulkItem(ctx context.Context, tx *sqlx.Tx, item entity.BulkTaskItem) (string, error) {
+	switch item.Op {
+	case entity.BulkCreate:
+		var id string
+		var createdAt time.Time
+		query := fmt.Sprintf("INSERT INTO %s (title, description, priority, status, assignee, project, due_at, custom_fields) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at", tasksTable)
+		task := item.Task
+		err := tx.QueryRowContext(ctx, query, task.Title, task.Description, task.Priority, task.Status, task.Assignee, task.Project, task.DueAt, task.Fields).Scan(&id, &createdAt)
+		return id, archived(err)
+	case entity.BulkUpdate:
+		query, args := updateTaskQuery(item.ID, item.Task)
+		return item.ID, execAffected(ctx, tx, query, args...)
+	case entity.BulkDelete:
+		query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", tasksTable)
+		return item.ID, execAffected(ctx, tx, query, item.ID)
+	default:
+		return item.ID, fmt.Errorf("unknown operation %q", item.Op)
+	}
+}
+
+func execAffected(ctx context.Context, db sqlx.ExecerContext, query string, args ...interface{}) error {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return archived(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+// ReassignTasks moves every unfinished task of a user within the
+// organization to another assignee, optionally limited to a single project.
+func (repo TaskPostgres) ReassignTasks(ctx context.Context, orgId, from string, input entity.ReassignTasks) (int64, error) {
+	query := fmt.Sprintf("UPDATE %s SET assignee = $1 WHERE assignee = $2 AND finished_at IS NULL AND %s", tasksTable, inOrganization("project", 3))
+	args := []interface{}{input.Assignee, from, orgId}
+	if input.Project != "" {
+		query += " AND project = $4"
+		args = append(args, input.Project)
+	}
+
+	res, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
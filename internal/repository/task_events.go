@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/logging"
+	"github.com/lib/pq"
+	"time"
+)
+
+const taskEventsChannel = "task_events"
+
+// ListenTaskEvents passes every task change announced by the database to
+// publish until the context is cancelled. The listener reconnects on its
+// own; events sent while it is disconnected are lost.
+func ListenTaskEvents(ctx context.Context, config Config, publish func(entity.TaskEvent)) error {
+	listener := pq.NewListener(config.dataSource(), 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logging.FromContext(ctx).Warn("task events listener", "error", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(taskEventsChannel); err != nil {
+		return err
+	}
+
+	ping := time.NewTicker(time.Minute)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// A nil notification follows a reconnect.
+			if notification == nil {
+				continue
+			}
+
+			var event entity.TaskEvent
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				logging.FromContext(ctx).Error("invalid task event", "payload", notification.Extra, "error", err)
+				continue
+			}
+			publish(event)
+		case <-ping.C:
+			go listener.Ping()
+		}
+	}
+}
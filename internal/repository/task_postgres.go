@@ -1,9 +1,11 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"strings"
 	"time"
 )
@@ -16,58 +18,90 @@ func NewTaskPostgres(db *sqlx.DB) *TaskPostgres {
 	return &TaskPostgres{db: db}
 }
 
-func (repo *TaskPostgres) CreateTask(task entity.Task) (string, time.Time, error) {
-	query := fmt.Sprintf("INSERT INTO %s (title, description, priority, status, assignee, project) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at", tasksTable)
-	return Create(repo.db, query, task.Title, task.Description, task.Priority, task.Status, task.Assignee, task.Project)
+func (repo *TaskPostgres) CreateTask(ctx context.Context, task entity.Task) (string, time.Time, error) {
+	query := fmt.Sprintf("INSERT INTO %s (title, description, priority, status, assignee, project, due_at, custom_fields) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at", tasksTable)
+	id, createdAt, err := Create(ctx, repo.db, query, task.Title, task.Description, task.Priority, task.Status, task.Assignee, task.Project, task.DueAt, task.Fields)
+	return id, createdAt, archived(err)
 }
 
-func (repo *TaskPostgres) GetByColumn(column, value string) (entity.Task, error) {
+func (repo *TaskPostgres) GetByColumn(ctx context.Context, column, value string) (entity.Task, error) {
 	var task entity.Task
 	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = $1", tasksTable, column)
-	err := repo.db.Get(&task, query, value)
+	err := repo.db.GetContext(ctx, &task, query, value)
 	if err != nil {
-		return task, fmt.Errorf("error retrieving task by %s: %v", column, err)
+		return task, fmt.Errorf("error retrieving task by %s: %w", column, err)
 	}
 
 	return task, nil
 }
 
-func (repo *TaskPostgres) SelectByColumn(column, value string) ([]entity.Task, error) {
+func (repo *TaskPostgres) SelectByColumn(ctx context.Context, orgId, column, value string, includeArchived bool) ([]entity.Task, error) {
 	var tasks []entity.Task
-	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = $1", tasksTable, column)
-	err := repo.db.Select(&tasks, query, value)
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = $1 AND %s", tasksTable, column, inActiveOrganization("project", 2, includeArchived))
+	err := repo.db.SelectContext(ctx, &tasks, query, value, orgId)
 
 	return tasks, err
 }
 
-func (repo *TaskPostgres) GetTaskById(id string) (entity.Task, error) {
-	return repo.GetByColumn("id", id)
+func (repo *TaskPostgres) GetTaskById(ctx context.Context, id string) (entity.Task, error) {
+	return repo.GetByColumn(ctx, "id", id)
+}
+
+func (repo *TaskPostgres) GetTaskByTitle(ctx context.Context, orgId, title string) (entity.Task, error) {
+	var task entity.Task
+	query := fmt.Sprintf("SELECT * FROM %s WHERE title = $1 AND %s", tasksTable, inActiveOrganization("project", 2, true))
+	if err := repo.db.GetContext(ctx, &task, query, title, orgId); err != nil {
+		return task, fmt.Errorf("error retrieving task by title: %w", err)
+	}
+
+	return task, nil
 }
 
-func (repo *TaskPostgres) GetTaskByTitle(title string) (entity.Task, error) {
-	return repo.GetByColumn("title", title)
+func (repo TaskPostgres) GetTaskByStatus(ctx context.Context, orgId, status string, includeArchived bool) ([]entity.Task, error) {
+	return repo.SelectByColumn(ctx, orgId, "status", status, includeArchived)
 }
 
-func (repo TaskPostgres) GetTaskByStatus(status string) ([]entity.Task, error) {
-	return repo.SelectByColumn("status", status)
+func (repo TaskPostgres) GetTaskByPriority(ctx context.Context, orgId, priority string, includeArchived bool) ([]entity.Task, error) {
+	return repo.SelectByColumn(ctx, orgId, "priority", priority, includeArchived)
 }
 
-func (repo TaskPostgres) GetTaskByPriority(priority string) ([]entity.Task, error) {
-	return repo.SelectByColumn("priority", priority)
+func (repo TaskPostgres) GetTasksByUserId(ctx context.Context, orgId, userId, column string, includeArchived bool) ([]entity.Task, error) {
+	return repo.SelectByColumn(ctx, orgId, column, userId, includeArchived)
 }
 
-func (repo TaskPostgres) GetTasksByUserId(userId, column string) ([]entity.Task, error) {
-	return repo.SelectByColumn(column, userId)
+// GetTasksByProjectId returns the project's tasks in board order.
+func (repo TaskPostgres) GetTasksByProjectId(ctx context.Context, project string) ([]entity.Task, error) {
+	var tasks []entity.Task
+	query := fmt.Sprintf("SELECT * FROM %s WHERE project = $1 ORDER BY status, rank NULLS LAST, created_at", tasksTable)
+	err := repo.db.SelectContext(ctx, &tasks, query, project)
+
+	return tasks, err
 }
 
-func (repo TaskPostgres) GetTasksByProjectId(project string) ([]entity.Task, error) {
-	return repo.SelectByColumn("project", project)
+// GetTasksByProjectIds returns the tasks of all given projects in board
+// order.
+func (repo TaskPostgres) GetTasksByProjectIds(ctx context.Context, ids []string) ([]entity.Task, error) {
+	var tasks []entity.Task
+	query := fmt.Sprintf("SELECT * FROM %s WHERE project = ANY($1) ORDER BY status, rank NULLS LAST, created_at", tasksTable)
+	err := repo.db.SelectContext(ctx, &tasks, query, pq.Array(ids))
+
+	return tasks, err
 }
 
-func (repo TaskPostgres) GetAllTasks() ([]entity.Task, error) {
+// GetTasksByAssignees returns the tasks of all given users within the
+// organization.
+func (repo TaskPostgres) GetTasksByAssignees(ctx context.Context, orgId string, ids []string, includeArchived bool) ([]entity.Task, error) {
 	var tasks []entity.Task
-	query := fmt.Sprintf("SELECT * FROM %s", tasksTable)
-	err := repo.db.Select(&tasks, query)
+	query := fmt.Sprintf("SELECT * FROM %s WHERE assignee = ANY($1) AND %s ORDER BY created_at", tasksTable, inActiveOrganization("project", 2, includeArchived))
+	err := repo.db.SelectContext(ctx, &tasks, query, pq.Array(ids), orgId)
+
+	return tasks, err
+}
+
+func (repo TaskPostgres) GetAllTasks(ctx context.Context, orgId string, includeArchived bool) ([]entity.Task, error) {
+	var tasks []entity.Task
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s", tasksTable, inActiveOrganization("project", 1, includeArchived))
+	err := repo.db.SelectContext(ctx, &tasks, query, orgId)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving all tasks: %v", err)
 	}
@@ -75,7 +109,23 @@ func (repo TaskPostgres) GetAllTasks() ([]entity.Task, error) {
 	return tasks, nil
 }
 
-func (repo TaskPostgres) UpdateTask(id string, task entity.Task) error {
+func (repo TaskPostgres) UpdateTask(ctx context.Context, id string, task entity.Task) error {
+	query, args := updateTaskQuery(id, task)
+	_, err := repo.db.ExecContext(ctx, query, args...)
+	return archived(err)
+}
+
+func updateTaskQuery(id string, task entity.Task) (string, []interface{}) {
+	setValues, args := taskAssignments(task)
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", tasksTable, strings.Join(setValues, ", "), len(args)+1)
+	args = append(args, id)
+
+	return query, args
+}
+
+// taskAssignments lists "column = $n" for every field set on the task,
+// numbering parameters from $1.
+func taskAssignments(task entity.Task) ([]string, []interface{}) {
 	setValues := make([]string, 0)
 	args := make([]interface{}, 0)
 	argId := 1
@@ -122,16 +172,17 @@ func (repo TaskPostgres) UpdateTask(id string, task entity.Task) error {
 		argId++
 	}
 
-	setQuery := strings.Join(setValues, ", ")
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", tasksTable, setQuery, argId)
-	args = append(args, id)
+	if task.DueAt.Valid {
+		setValues = append(setValues, fmt.Sprintf("due_at = $%d", argId))
+		args = append(args, task.DueAt.Time)
+		argId++
+	}
 
-	_, err := repo.db.Exec(query, args...)
-	return err
+	return setValues, args
 }
 
-func (repo TaskPostgres) DeleteTask(id string) error {
+func (repo TaskPostgres) DeleteTask(ctx context.Context, id string) error {
 	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", tasksTable)
-	_, err := repo.db.Exec(query, id)
-	return err
+	_, err := repo.db.ExecContext(ctx, query, id)
+	return archived(err)
 }
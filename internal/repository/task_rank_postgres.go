@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/rank"
+	"github.com/lib/pq"
+)
+
+// ErrRankTaken is returned when a concurrent move claimed the same rank.
+var ErrRankTaken = errors.New("rank is already taken")
+
+const uniqueViolation = "23505"
+
+// placedSetting tells the rank trigger that the moves of the transaction
+// chose their ranks, which in another column may equal the old one.
+const placedSetting = "pm.rank_placed"
+
+// GetColumn lists the tasks of one board column in board order. Tasks that
+// were never placed come last, oldest first.
+func (repo TaskPostgres) GetColumn(ctx context.Context, projectId, status string) ([]entity.TaskRank, error) {
+	var ranks []entity.TaskRank
+	query := fmt.Sprintf("SELECT id, rank FROM %s WHERE project = $1 AND status = $2 ORDER BY rank NULLS LAST, created_at, id", tasksTable)
+	err := repo.db.SelectContext(ctx, &ranks, query, projectId, status)
+
+	return ranks, err
+}
+
+// MoveTask puts the task at the given rank of the status column.
+func (repo TaskPostgres) MoveTask(ctx context.Context, id, status, key string) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL %s = 'on'", placedSetting)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET status = $1, rank = $2 WHERE id = $3", tasksTable)
+	if _, err := tx.ExecContext(ctx, query, status, key, id); err != nil {
+		tx.Rollback()
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return ErrRankTaken
+		}
+		return archived(err)
+	}
+
+	return tx.Commit()
+}
+
+// RebalanceColumn rewrites the ranks of one column with short, evenly spaced
+// keys, keeping the current order. Only the rows of that column are locked.
+func (repo TaskPostgres) RebalanceColumn(ctx context.Context, projectId, status string) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET CONSTRAINTS %s_rank_key DEFERRED", tasksTable)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var ids []string
+	query := fmt.Sprintf("SELECT id FROM %s WHERE project = $1 AND status = $2 ORDER BY rank NULLS LAST, created_at, id FOR UPDATE", tasksTable)
+	if err := tx.SelectContext(ctx, &ids, query, projectId, status); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	keys := rank.Spread(len(ids))
+	query = fmt.Sprintf("UPDATE %s SET rank = $1 WHERE id = $2", tasksTable)
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, query, keys[i], id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
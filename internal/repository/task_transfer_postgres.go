@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+)
+
+// StreamTasksByProjectId walks the project's tasks row by row together with
+// the assignee's name and email so large projects are never held in memory.
+func (repo TaskPostgres) StreamTasksByProjectId(ctx context.Context, projectId string, fn func(entity.TaskExport) error) error {
+	query := fmt.Sprintf(`SELECT t.*, u.name AS assignee_name, u.email AS assignee_email
+		FROM %s t JOIN %s u ON u.id = t.assignee
+		WHERE t.project = $1 ORDER BY t.created_at`, tasksTable, usersTable)
+
+	rows, err := repo.db.QueryxContext(ctx, query, projectId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var task entity.TaskExport
+		if err := rows.StructScan(&task); err != nil {
+			return fmt.Errorf("error scanning task: %v", err)
+		}
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// CreateTasks inserts all tasks in one transaction and returns their ids in
+// input order. Nothing is written if any insert fails.
+func (repo TaskPostgres) CreateTasks(ctx context.Context, tasks []entity.Task) ([]string, error) {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (title, description, priority, status, assignee, project, finished_at, due_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id", tasksTable)
+	ids := make([]string, 0, len(tasks))
+	for i, task := range tasks {
+		var id string
+		err := tx.QueryRowContext(ctx, query, task.Title, task.Description, task.Priority, task.Status, task.Assignee, task.Project, task.FinishedAt, task.DueAt).Scan(&id)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("task %d: %v", i, err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
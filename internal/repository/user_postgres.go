@@ -1,13 +1,19 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"strings"
 	"time"
 )
 
+var ErrUserExists = errors.New("a user with this email is already a member of the organization")
+
 type UserPostgres struct {
 	db *sqlx.DB
 }
@@ -16,32 +22,80 @@ func NewUserPostgres(db *sqlx.DB) *UserPostgres {
 	return &UserPostgres{db: db}
 }
 
-func (repo *UserPostgres) CreateUser(user entity.User) (string, time.Time, error) {
-	query := fmt.Sprintf("INSERT INTO %s (name, email, role) VALUES ($1, $2, $3) RETURNING id, registered_at", usersTable)
-	return Create(repo.db, query, user.Name, user.Email, user.Role)
+// CreateUser inserts the user as a member of the organization.
+// CreateUser registers the user as a member of the organization. Accounts
+// are shared by organizations, so an account that already has the email is
+// made a member instead, and ErrUserExists is returned if it already is one.
+func (repo *UserPostgres) CreateUser(ctx context.Context, orgId string, user entity.User) (string, time.Time, error) {
+	query := fmt.Sprintf(`WITH existing AS (
+			SELECT id, registered_at FROM %[1]s WHERE email = $2
+		), u AS (
+			INSERT INTO %[1]s (name, email, role) SELECT $1, $2, $3 WHERE NOT EXISTS (SELECT 1 FROM existing) RETURNING id, registered_at
+		), account AS (
+			SELECT id, registered_at FROM u UNION ALL SELECT id, registered_at FROM existing
+		), m AS (
+			INSERT INTO %[2]s (organization, user_id, role) SELECT $4, id, $5 FROM account ON CONFLICT DO NOTHING RETURNING user_id
+		)
+		SELECT a.id, a.registered_at FROM account a JOIN m ON m.user_id = a.id`, usersTable, membershipsTable)
+
+	id, registeredAt, err := Create(ctx, repo.db, query, user.Name, user.Email, user.Role, orgId, entity.OrgRoleMember)
+	var pqErr *pq.Error
+	if errors.Is(err, sql.ErrNoRows) || (errors.As(err, &pqErr) && pqErr.Code == uniqueViolation) {
+		return "", time.Time{}, ErrUserExists
+	}
+	return id, registeredAt, err
 }
 
-func (repo *UserPostgres) GetByColumn(value, column string) (entity.User, error) {
+func (repo *UserPostgres) GetByColumn(ctx context.Context, value, column string) (entity.User, error) {
 	var user entity.User
 	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = $1", usersTable, column)
-	err := repo.db.Get(&user, query, value)
+	err := repo.db.GetContext(ctx, &user, query, value)
+
+	return user, err
+}
+
+func (repo *UserPostgres) GetMemberByColumn(ctx context.Context, orgId, value, column string) (entity.User, error) {
+	var user entity.User
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = $1 AND %s", usersTable, column, isMember("id", 2))
+	err := repo.db.GetContext(ctx, &user, query, value, orgId)
 
 	return user, err
 }
 
-func (repo *UserPostgres) GetUserById(id string) (entity.User, error) {
-	return repo.GetByColumn(id, "id")
+func (repo *UserPostgres) GetUserById(ctx context.Context, id string) (entity.User, error) {
+	return repo.GetByColumn(ctx, id, "id")
+}
+
+// GetUsersByIds returns the members of the organization among the given
+// users, in no particular order.
+func (repo *UserPostgres) GetUsersByIds(ctx context.Context, orgId string, ids []string) ([]entity.User, error) {
+	var users []entity.User
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = ANY($1) AND %s", usersTable, isMember("id", 2))
+	err := repo.db.SelectContext(ctx, &users, query, pq.Array(ids), orgId)
+
+	return users, err
+}
+
+func (repo *UserPostgres) GetUserByName(ctx context.Context, orgId, name string) (entity.User, error) {
+	return repo.GetMemberByColumn(ctx, orgId, name, "name")
 }
 
-func (repo *UserPostgres) GetUserByName(name string) (entity.User, error) {
-	return repo.GetByColumn(name, "name")
+func (repo *UserPostgres) GetUserByEmail(ctx context.Context, orgId, email string) (entity.User, error) {
+	return repo.GetMemberByColumn(ctx, orgId, email, "email")
 }
 
-func (repo *UserPostgres) GetUserByEmail(email string) (entity.User, error) {
-	return repo.GetByColumn(email, "email")
+// GetProjectMember returns the user if they are a member of the project's
+// organization.
+func (repo *UserPostgres) GetProjectMember(ctx context.Context, projectId, id string) (entity.User, error) {
+	var user entity.User
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1 AND id IN (SELECT user_id FROM %s WHERE organization = (SELECT organization FROM %s WHERE id = $2))",
+		usersTable, membershipsTable, projectsTable)
+	err := repo.db.GetContext(ctx, &user, query, id, projectId)
+
+	return user, err
 }
 
-func (repo *UserPostgres) UpdateUser(id string, user entity.User) error {
+func (repo *UserPostgres) UpdateUser(ctx context.Context, id string, user entity.User) error {
 	setValues := make([]string, 0)
 	args := make([]interface{}, 0)
 	argId := 1
@@ -69,20 +123,20 @@ func (repo *UserPostgres) UpdateUser(id string, user entity.User) error {
 	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", usersTable, setQuery, argId)
 	args = append(args, id)
 
-	_, err := repo.db.Exec(query, args...)
+	_, err := repo.db.ExecContext(ctx, query, args...)
 	return err
 }
 
-func (repo *UserPostgres) DeleteUser(id string) error {
+func (repo *UserPostgres) DeleteUser(ctx context.Context, id string) error {
 	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", usersTable)
-	_, err := repo.db.Exec(query, id)
+	_, err := repo.db.ExecContext(ctx, query, id)
 	return err
 }
 
-func (repo *UserPostgres) GetAllUsers() ([]entity.User, error) {
+func (repo *UserPostgres) GetAllUsers(ctx context.Context, orgId string) ([]entity.User, error) {
 	var users []entity.User
-	query := fmt.Sprintf("SELECT * FROM %s", usersTable)
-	if err := repo.db.Select(&users, query); err != nil {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s", usersTable, isMember("id", 1))
+	if err := repo.db.SelectContext(ctx, &users, query, orgId); err != nil {
 		return nil, err
 	}
 	return users, nil
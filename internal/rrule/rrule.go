@@ -0,0 +1,294 @@
+// Package rrule implements the subset of RFC 5545 recurrence rules used for
+// recurring tasks: DAILY, WEEKLY and MONTHLY frequencies with INTERVAL,
+// BYDAY, UNTIL and COUNT.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+// maxPeriods bounds the search for the next occurrence so that rules which
+// can never match (e.g. every 7 days on another weekday) terminate.
+const maxPeriods = 10000
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Weekday is a BYDAY entry. N is the ordinal within the month for MONTHLY
+// rules (1 is the first, -1 the last) and 0 for every such weekday.
+type Weekday struct {
+	Day time.Weekday
+	N   int
+}
+
+type Rule struct {
+	Freq     string
+	Interval int
+	ByDay    []Weekday
+	Until    time.Time
+	Count    int
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10".
+// The "RRULE:" prefix is optional.
+func Parse(value string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return rule, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return rule, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(val)
+			if rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly {
+				return rule, fmt.Errorf("%w: unsupported frequency %q", ErrInvalidRule, val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err != nil || rule.Interval < 1 {
+				return rule, fmt.Errorf("%w: interval must be a positive number", ErrInvalidRule)
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if err != nil || rule.Count < 1 {
+				return rule, fmt.Errorf("%w: count must be a positive number", ErrInvalidRule)
+			}
+		case "UNTIL":
+			rule.Until, err = parseUntil(val)
+			if err != nil {
+				return rule, err
+			}
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+			if err != nil {
+				return rule, err
+			}
+		default:
+			return rule, fmt.Errorf("%w: unsupported part %q", ErrInvalidRule, key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return rule, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return rule, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRule)
+	}
+	if rule.Freq != Monthly {
+		for _, day := range rule.ByDay {
+			if day.N != 0 {
+				return rule, fmt.Errorf("%w: BYDAY ordinals are only allowed for MONTHLY", ErrInvalidRule)
+			}
+		}
+	}
+
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return until, nil
+	}
+	if until, err := time.Parse("20060102T150405", value); err == nil {
+		return until, nil
+	}
+	// A date-only UNTIL includes the whole day.
+	if until, err := time.Parse("20060102", value); err == nil {
+		return until.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: malformed UNTIL %q", ErrInvalidRule, value)
+}
+
+func parseByDay(value string) ([]Weekday, error) {
+	var days []Weekday
+	for _, item := range strings.Split(strings.ToUpper(value), ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("%w: malformed BYDAY %q", ErrInvalidRule, item)
+		}
+
+		day, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown weekday %q", ErrInvalidRule, item)
+		}
+
+		n := 0
+		if ordinal := item[:len(item)-2]; ordinal != "" {
+			var err error
+			n, err = strconv.Atoi(ordinal)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("%w: malformed BYDAY ordinal %q", ErrInvalidRule, item)
+			}
+		}
+		days = append(days, Weekday{Day: day, N: n})
+	}
+	return days, nil
+}
+
+// String returns the rule in canonical form.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			code := strings.ToUpper(day.Day.String()[:2])
+			if day.N != 0 {
+				code = strconv.Itoa(day.N) + code
+			}
+			days = append(days, code)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// After returns the first occurrence strictly after the given moment for a
+// series that starts at start. The start itself is always the first
+// occurrence and counts towards COUNT. The second result is false once the
+// series has ended.
+func (r Rule) After(start, after time.Time) (time.Time, bool) {
+	if after.Before(start) {
+		return start, true
+	}
+
+	seen := 1
+	for period := 0; period < maxPeriods; period++ {
+		for _, candidate := range r.candidates(start, period) {
+			if !candidate.After(start) {
+				continue
+			}
+
+			seen++
+			if r.Count > 0 && seen > r.Count {
+				return time.Time{}, false
+			}
+			if !r.Until.IsZero() && candidate.After(r.Until) {
+				return time.Time{}, false
+			}
+			if candidate.After(after) {
+				return candidate, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// candidates lists the occurrences of one period (day, week or month) in
+// chronological order, keeping the time of day of start.
+func (r Rule) candidates(start time.Time, period int) []time.Time {
+	step := period * r.Interval
+	switch r.Freq {
+	case Daily:
+		day := start.AddDate(0, 0, step)
+		if len(r.ByDay) > 0 && !r.matchesWeekday(day.Weekday()) {
+			return nil
+		}
+		return []time.Time{day}
+	case Weekly:
+		monday := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*step)
+		if len(r.ByDay) == 0 {
+			return []time.Time{start.AddDate(0, 0, 7*step)}
+		}
+		days := make([]time.Time, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, monday.AddDate(0, 0, (int(day.Day)+6)%7))
+		}
+		sortTimes(days)
+		return dedupe(days)
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(step), 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+		if len(r.ByDay) == 0 {
+			day := first.AddDate(0, 0, start.Day()-1)
+			if day.Month() != first.Month() {
+				return nil
+			}
+			return []time.Time{day}
+		}
+		return monthlyByDay(first, r.ByDay)
+	default:
+		return nil
+	}
+}
+
+func (r Rule) matchesWeekday(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func monthlyByDay(first time.Time, byDay []Weekday) []time.Time {
+	byWeekday := make(map[time.Weekday][]time.Time)
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		byWeekday[day.Weekday()] = append(byWeekday[day.Weekday()], day)
+	}
+
+	var days []time.Time
+	for _, want := range byDay {
+		same := byWeekday[want.Day]
+		switch {
+		case want.N == 0:
+			days = append(days, same...)
+		case want.N > 0 && want.N <= len(same):
+			days = append(days, same[want.N-1])
+		case want.N < 0 && -want.N <= len(same):
+			days = append(days, same[len(same)+want.N])
+		}
+	}
+
+	sortTimes(days)
+	return dedupe(days)
+}
+
+func sortTimes(times []time.Time) {
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+}
+
+func dedupe(times []time.Time) []time.Time {
+	unique := times[:0]
+	for i, t := range times {
+		if i == 0 || !t.Equal(times[i-1]) {
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
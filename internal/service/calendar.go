@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCalendarToken = errors.New("calendar token is invalid or revoked")
+
+const (
+	icsTimeFormat = "20060102T150405Z"
+	icsDateFormat = "20060102"
+)
+
+type CalendarService struct {
+	repo       repository.Calendar
+	tasks      repository.Task
+	projects   repository.Project
+	milestones repository.Milestone
+}
+
+func NewCalendarService(repo repository.Calendar, tasks repository.Task, projects repository.Project, milestones repository.Milestone) *CalendarService {
+	return &CalendarService{repo: repo, tasks: tasks, projects: projects, milestones: milestones}
+}
+
+func (s CalendarService) CreateCalendarToken(ctx context.Context, orgId, scope, target string) (entity.CalendarToken, error) {
+	ctx, span := tracing.Start(ctx, "CalendarService.CreateCalendarToken")
+	defer span.End()
+
+	if scope != entity.CalendarScopeUser && scope != entity.CalendarScopeProject {
+		return entity.CalendarToken{}, fmt.Errorf("unknown calendar scope %q", scope)
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return entity.CalendarToken{}, err
+	}
+
+	token := entity.CalendarToken{Token: hex.EncodeToString(secret), Scope: scope, Target: target, Organization: orgId}
+	id, createdAt, err := s.repo.CreateCalendarToken(ctx, token)
+	if err != nil {
+		return entity.CalendarToken{}, err
+	}
+
+	token.ID = id
+	token.CreatedAt = createdAt
+	return token, nil
+}
+
+func (s CalendarService) RevokeCalendarToken(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "CalendarService.RevokeCalendarToken")
+	defer span.End()
+
+	affected, err := s.repo.RevokeCalendarToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrInvalidCalendarToken
+	}
+	return nil
+}
+
+// RenderCalendar builds the iCalendar feed the token grants access to. Every
+// task becomes a VTODO; unfinished tasks with a due date also get a VEVENT so
+// that calendar apps which ignore VTODO still show the deadline. Project
+// feeds also show milestones as all-day events on their target date.
+func (s CalendarService) RenderCalendar(ctx context.Context, token string) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "CalendarService.RenderCalendar")
+	defer span.End()
+
+	calendar, err := s.repo.GetCalendarToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidCalendarToken
+		}
+		return nil, err
+	}
+
+	var name string
+	var tasks []entity.Task
+	var milestones []entity.MilestoneProgress
+	switch calendar.Scope {
+	case entity.CalendarScopeUser:
+		name = "My tasks"
+		tasks, err = s.tasks.GetTasksByUserId(ctx, calendar.Organization, calendar.Target, "assignee", false)
+	case entity.CalendarScopeProject:
+		var project entity.Project
+		project, err = s.projects.GetProjectById(ctx, calendar.Target)
+		if err != nil {
+			return nil, err
+		}
+		name = project.Title
+		milestones, err = s.milestones.GetMilestonesByProject(ctx, calendar.Target)
+		if err != nil {
+			return nil, err
+		}
+		tasks, err = s.tasks.GetTasksByProjectId(ctx, calendar.Target)
+	default:
+		return nil, ErrInvalidCalendarToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ics := newICSWriter()
+	ics.line("BEGIN", "VCALENDAR")
+	ics.line("VERSION", "2.0")
+	ics.line("PRODID", "-//Projects-Manager//EN")
+	ics.line("CALSCALE", "GREGORIAN")
+	ics.line("METHOD", "PUBLISH")
+	ics.text("X-WR-CALNAME", name)
+
+	now := time.Now()
+	for _, task := range tasks {
+		writeTodo(ics, task, now)
+		if task.DueAt.Valid && !task.FinishedAt.Valid {
+			writeDueEvent(ics, task, now)
+		}
+	}
+	for _, milestone := range milestones {
+		writeMilestoneEvent(ics, milestone, now)
+	}
+
+	ics.line("END", "VCALENDAR")
+	return ics.bytes(), nil
+}
+
+func writeTodo(ics *icsWriter, task entity.Task, now time.Time) {
+	ics.line("BEGIN", "VTODO")
+	ics.line("UID", "task-"+task.ID+"@projects-manager")
+	ics.time("DTSTAMP", now)
+	ics.time("CREATED", task.CreatedAt)
+	ics.text("SUMMARY", task.Title)
+	ics.text("DESCRIPTION", task.Description)
+	if priority := icsPriority(task.Priority); priority != "" {
+		ics.line("PRIORITY", priority)
+	}
+	if task.DueAt.Valid {
+		ics.time("DUE", task.DueAt.Time)
+	}
+	ics.line("STATUS", icsTodoStatus(task))
+	if task.FinishedAt.Valid {
+		ics.time("COMPLETED", task.FinishedAt.Time)
+		ics.line("PERCENT-COMPLETE", "100")
+	}
+	ics.line("END", "VTODO")
+}
+
+func writeDueEvent(ics *icsWriter, task entity.Task, now time.Time) {
+	ics.line("BEGIN", "VEVENT")
+	ics.line("UID", "task-"+task.ID+"-due@projects-manager")
+	ics.time("DTSTAMP", now)
+	ics.time("DTSTART", task.DueAt.Time)
+	ics.time("DTEND", task.DueAt.Time)
+	ics.text("SUMMARY", "Due: "+task.Title)
+	ics.text("DESCRIPTION", task.Description)
+	ics.line("STATUS", "CONFIRMED")
+	ics.line("TRANSP", "TRANSPARENT")
+	ics.line("END", "VEVENT")
+}
+
+func writeMilestoneEvent(ics *icsWriter, milestone entity.MilestoneProgress, now time.Time) {
+	day := milestone.TargetAt.UTC()
+	ics.line("BEGIN", "VEVENT")
+	ics.line("UID", "milestone-"+milestone.ID+"@projects-manager")
+	ics.time("DTSTAMP", now)
+	ics.line("DTSTART;VALUE=DATE", day.Format(icsDateFormat))
+	ics.line("DTEND;VALUE=DATE", day.AddDate(0, 0, 1).Format(icsDateFormat))
+	ics.text("SUMMARY", "Milestone: "+milestone.Title)
+	ics.text("DESCRIPTION", fmt.Sprintf("%s\n%d of %d tasks done", milestone.Description, milestone.Done, milestone.Total))
+	ics.line("TRANSP", "TRANSPARENT")
+	ics.line("END", "VEVENT")
+}
+
+// icsTodoStatus maps the free-form task status onto the VTODO STATUS values.
+// A finished_at timestamp always wins over the status text.
+func icsTodoStatus(task entity.Task) string {
+	if task.FinishedAt.Valid {
+		return "COMPLETED"
+	}
+
+	status := strings.ToLower(task.Status)
+	switch {
+	case strings.Contains(status, "cancel"):
+		return "CANCELLED"
+	case strings.Contains(status, "done"), strings.Contains(status, "complete"), strings.Contains(status, "finish"):
+		return "COMPLETED"
+	case strings.Contains(status, "progress"), strings.Contains(status, "review"):
+		return "IN-PROCESS"
+	default:
+		return "NEEDS-ACTION"
+	}
+}
+
+func icsPriority(priority string) string {
+	switch strings.ToLower(priority) {
+	case "high":
+		return "1"
+	case "medium":
+		return "5"
+	case "low":
+		return "9"
+	default:
+		return ""
+	}
+}
+
+type icsWriter struct {
+	sb strings.Builder
+}
+
+func newICSWriter() *icsWriter {
+	return &icsWriter{}
+}
+
+func (w *icsWriter) line(name, value string) {
+	w.fold(name + ":" + value)
+}
+
+func (w *icsWriter) text(name, value string) {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	w.line(name, replacer.Replace(value))
+}
+
+func (w *icsWriter) time(name string, value time.Time) {
+	w.line(name, value.UTC().Format(icsTimeFormat))
+}
+
+// fold splits content lines longer than 75 octets as RFC 5545 requires,
+// never cutting a multi-byte character in half.
+func (w *icsWriter) fold(content string) {
+	limit := 75
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.sb.WriteString(content[:cut])
+		w.sb.WriteString("\r\n ")
+		content = content[cut:]
+		limit = 74
+	}
+	w.sb.WriteString(content)
+	w.sb.WriteString("\r\n")
+}
+
+func (w *icsWriter) bytes() []byte {
+	return []byte(w.sb.String())
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidField      = errors.New("invalid custom field")
+	ErrUnknownField      = errors.New("unknown custom field")
+	ErrInvalidFieldValue = errors.New("invalid custom field value")
+)
+
+const (
+	fieldDateFormat   = "2006-01-02"
+	maxFieldTextValue = 1000
+)
+
+// FieldChecker is what task creation uses to check the custom field values
+// of a new task against the fields of its project.
+type FieldChecker interface {
+	CheckTaskFields(ctx context.Context, projectId string, values entity.CustomValues) (entity.CustomValues, error)
+}
+
+type CustomFieldService struct {
+	repo  repository.CustomField
+	tasks repository.Task
+	users repository.User
+}
+
+func NewCustomFieldService(repo repository.CustomField, tasks repository.Task, users repository.User) *CustomFieldService {
+	return &CustomFieldService{repo: repo, tasks: tasks, users: users}
+}
+
+func (s CustomFieldService) CreateField(ctx context.Context, projectId string, field entity.CustomField) (entity.CustomField, error) {
+	ctx, span := tracing.Start(ctx, "CustomFieldService.CreateField")
+	defer span.End()
+
+	field.Project = projectId
+	if err := validateField(field); err != nil {
+		return entity.CustomField{}, err
+	}
+	if field.Required {
+		if err := s.requireValues(ctx, field); err != nil {
+			return entity.CustomField{}, err
+		}
+	}
+
+	id, createdAt, err := s.repo.CreateField(ctx, field)
+	if err != nil {
+		return entity.CustomField{}, err
+	}
+
+	field.ID = id
+	field.CreatedAt = createdAt
+	return field, nil
+}
+
+func (s CustomFieldService) GetFields(ctx context.Context, projectId string) ([]entity.CustomField, error) {
+	ctx, span := tracing.Start(ctx, "CustomFieldService.GetFields")
+	defer span.End()
+
+	return s.repo.GetFields(ctx, projectId)
+}
+
+// UpdateField renames the field or changes its options or whether it is
+// required. The type is fixed once values may have been stored.
+func (s CustomFieldService) UpdateField(ctx context.Context, id string, input entity.CustomFieldUpdate) error {
+	ctx, span := tracing.Start(ctx, "CustomFieldService.UpdateField")
+	defer span.End()
+
+	field, err := s.repo.GetField(ctx, id)
+	if err != nil {
+		return err
+	}
+	if input.Type != "" && input.Type != field.Type {
+		return fmt.Errorf("%w: the type of a field cannot change", ErrInvalidField)
+	}
+
+	field.Name = valueOr(input.Name, field.Name)
+	if input.Options != nil {
+		field.Options = input.Options
+	}
+	if input.Required != nil {
+		if *input.Required && !field.Required {
+			if err := s.requireValues(ctx, field); err != nil {
+				return err
+			}
+		}
+		field.Required = *input.Required
+	}
+
+	if err := validateField(field); err != nil {
+		return err
+	}
+	return s.repo.UpdateField(ctx, field)
+}
+
+func (s CustomFieldService) DeleteField(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "CustomFieldService.DeleteField")
+	defer span.End()
+
+	field, err := s.repo.GetField(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteField(ctx, field)
+}
+
+// SetTaskFields validates values, keyed by field name or id, against the
+// schema of the task's project and stores them. A null value clears the
+// field unless it is required.
+func (s CustomFieldService) SetTaskFields(ctx context.Context, taskId string, values map[string]interface{}) error {
+	ctx, span := tracing.Start(ctx, "CustomFieldService.SetTaskFields")
+	defer span.End()
+
+	task, err := s.tasks.GetTaskById(ctx, taskId)
+	if err != nil {
+		return err
+	}
+
+	fields, err := s.repo.GetFields(ctx, task.Project)
+	if err != nil {
+		return err
+	}
+
+	set := entity.CustomValues{}
+	remove := make([]string, 0)
+	for key, raw := range values {
+		field, ok := findField(fields, key)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownField, key)
+		}
+
+		if raw == nil {
+			if field.Required {
+				return fmt.Errorf("%w: %s is required", ErrInvalidFieldValue, field.Name)
+			}
+			remove = append(remove, field.ID)
+			continue
+		}
+
+		value, err := s.fieldValue(ctx, field, raw)
+		if err != nil {
+			return err
+		}
+		set[field.ID] = value
+	}
+
+	return s.repo.SetTaskFields(ctx, taskId, set, remove)
+}
+
+// CheckTaskFields checks the values of a new task, keyed by field name or
+// id, and returns them keyed by id. Every required field needs a value.
+func (s CustomFieldService) CheckTaskFields(ctx context.Context, projectId string, values entity.CustomValues) (entity.CustomValues, error) {
+	ctx, span := tracing.Start(ctx, "CustomFieldService.CheckTaskFields")
+	defer span.End()
+
+	fields, err := s.repo.GetFields(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	checked := entity.CustomValues{}
+	for key, raw := range values {
+		field, ok := findField(fields, key)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, key)
+		}
+		if raw == nil {
+			continue
+		}
+
+		value, err := s.fieldValue(ctx, field, raw)
+		if err != nil {
+			return nil, err
+		}
+		checked[field.ID] = value
+	}
+
+	for _, field := range fields {
+		if _, ok := checked[field.ID]; field.Required && !ok {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidFieldValue, field.Name)
+		}
+	}
+	return checked, nil
+}
+
+// GetProjectTasks lists the project's tasks, filtered and sorted by custom
+// fields when the query asks for it.
+func (s CustomFieldService) GetProjectTasks(ctx context.Context, projectId string, query entity.TaskListQuery) ([]entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "CustomFieldService.GetProjectTasks")
+	defer span.End()
+
+	if len(query.Fields) == 0 && query.Sort == "" {
+		return s.tasks.GetTasksByProjectId(ctx, projectId)
+	}
+
+	fields, err := s.repo.GetFields(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := make([]entity.FieldFilter, 0, len(query.Fields))
+	for key, value := range query.Fields {
+		field, ok := findField(fields, key)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, key)
+		}
+		if field.Type == entity.FieldNumber {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("%w: %s must be a number", ErrInvalidFieldValue, field.Name)
+			}
+		}
+		filters = append(filters, entity.FieldFilter{Field: field, Value: value})
+	}
+
+	var sort *entity.FieldSort
+	if query.Sort != "" {
+		field, ok := findField(fields, query.Sort)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, query.Sort)
+		}
+		sort = &entity.FieldSort{Field: field, Desc: query.Desc}
+	}
+
+	return s.repo.GetTasksByFields(ctx, projectId, filters, sort)
+}
+
+// fieldValue checks a JSON value against the field type and returns it in
+// the form it is stored in.
+func (s CustomFieldService) fieldValue(ctx context.Context, field entity.CustomField, raw interface{}) (interface{}, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %s %s", ErrInvalidFieldValue, field.Name, reason)
+	}
+
+	switch field.Type {
+	case entity.FieldText:
+		text, ok := raw.(string)
+		if !ok || len(text) > maxFieldTextValue {
+			return nil, invalid(fmt.Sprintf("must be text of at most %d characters", maxFieldTextValue))
+		}
+		return text, nil
+	case entity.FieldNumber:
+		number, ok := raw.(float64)
+		if !ok {
+			return nil, invalid("must be a number")
+		}
+		return number, nil
+	case entity.FieldDate:
+		text, ok := raw.(string)
+		if _, err := time.Parse(fieldDateFormat, text); !ok || err != nil {
+			return nil, invalid("must be a date formatted as YYYY-MM-DD")
+		}
+		return text, nil
+	case entity.FieldSelect:
+		option, ok := raw.(string)
+		if !ok || !hasOption(field, option) {
+			return nil, invalid("must be one of its options")
+		}
+		return option, nil
+	case entity.FieldMultiSelect:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil, invalid("must be a list of its options")
+		}
+		selected := make([]string, 0, len(items))
+		for _, item := range items {
+			option, ok := item.(string)
+			if !ok || !hasOption(field, option) {
+				return nil, invalid("must be a list of its options")
+			}
+			if !contains(selected, option) {
+				selected = append(selected, option)
+			}
+		}
+		return selected, nil
+	case entity.FieldUser:
+		userId, ok := raw.(string)
+		if !ok {
+			return nil, invalid("must be a user id")
+		}
+		if _, err := s.users.GetProjectMember(ctx, field.Project, userId); err != nil {
+			return nil, invalid("must be a member of the organization")
+		}
+		return userId, nil
+	default:
+		return nil, invalid("has an unknown type")
+	}
+}
+
+// requireValues refuses to make a field required while tasks of its project
+// have no value for it.
+func (s CustomFieldService) requireValues(ctx context.Context, field entity.CustomField) error {
+	missing, err := s.repo.CountTasksWithoutField(ctx, field.Project, field.ID)
+	if err != nil {
+		return err
+	}
+	if missing > 0 {
+		return fmt.Errorf("%w: %d tasks have no value for %s", ErrInvalidField, missing, field.Name)
+	}
+	return nil
+}
+
+func validateField(field entity.CustomField) error {
+	if field.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidField)
+	}
+
+	switch field.Type {
+	case entity.FieldSelect, entity.FieldMultiSelect:
+		if len(field.Options) == 0 {
+			return fmt.Errorf("%w: select fields need options", ErrInvalidField)
+		}
+	case entity.FieldText, entity.FieldNumber, entity.FieldDate, entity.FieldUser:
+		if len(field.Options) > 0 {
+			return fmt.Errorf("%w: only select fields have options", ErrInvalidField)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidField, field.Type)
+	}
+	return nil
+}
+
+func findField(fields []entity.CustomField, key string) (entity.CustomField, bool) {
+	for _, field := range fields {
+		if field.ID == key || field.Name == key {
+			return field, true
+		}
+	}
+	return entity.CustomField{}, false
+}
+
+func hasOption(field entity.CustomField, option string) bool {
+	return contains(field.Options, option)
+}
+
+func contains(values []string, value string) bool {
+	for _, known := range values {
+		if known == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"math"
+	"time"
+)
+
+const (
+	dueSoonWindow       = 7 * 24 * time.Hour
+	recentlyChangedDays = 7
+	dashboardListLimit  = 20
+)
+
+type DashboardService struct {
+	repo repository.Dashboard
+}
+
+func NewDashboardService(repo repository.Dashboard) *DashboardService {
+	return &DashboardService{repo: repo}
+}
+
+func (d DashboardService) GetDashboard(ctx context.Context, orgId, userId string) (entity.Dashboard, error) {
+	ctx, span := tracing.Start(ctx, "DashboardService.GetDashboard")
+	defer span.End()
+
+	var dashboard entity.Dashboard
+	var err error
+	now := time.Now()
+
+	if dashboard.OpenTasks, err = d.repo.OpenTaskCounts(ctx, orgId, userId); err != nil {
+		return entity.Dashboard{}, err
+	}
+
+	if dashboard.DueSoon, err = d.repo.DueSoonTasks(ctx, orgId, userId, now.Add(dueSoonWindow), dashboardListLimit); err != nil {
+		return entity.Dashboard{}, err
+	}
+
+	since := now.AddDate(0, 0, -recentlyChangedDays)
+	if dashboard.RecentlyChanged, err = d.repo.RecentlyChangedTasks(ctx, orgId, userId, since, dashboardListLimit); err != nil {
+		return entity.Dashboard{}, err
+	}
+
+	if dashboard.Projects, err = d.repo.ManagedProjectProgress(ctx, orgId, userId); err != nil {
+		return entity.Dashboard{}, err
+	}
+
+	for i, project := range dashboard.Projects {
+		dashboard.Projects[i].Progress = percent(project.Finished, project.Total)
+	}
+
+	return dashboard, nil
+}
+
+// percent is the share of done out of total in percent, rounded to one
+// decimal. Projects and milestones report progress this way.
+func percent(done, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(done)/float64(total)*1000) / 10
+}
+
+func (d DashboardService) GetUsage(ctx context.Context) (entity.Usage, error) {
+	ctx, span := tracing.Start(ctx, "DashboardService.GetUsage")
+	defer span.End()
+
+	var usage entity.Usage
+	var err error
+
+	if usage.OpenTasks, err = d.repo.CountOpenTasks(ctx); err != nil {
+		return entity.Usage{}, err
+	}
+
+	if usage.ActiveProjects, err = d.repo.CountActiveProjects(ctx); err != nil {
+		return entity.Usage{}, err
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/logging"
+	"github.com/Aytya/projects-manager-HL/internal/mailer"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownEmailMode   = errors.New("unknown email mode")
+	ErrInvalidUnsubscribe = errors.New("unsubscribe link is invalid")
+)
+
+const (
+	emailBatchSize   = 100
+	digestBatchSize  = 10 * emailBatchSize
+	emailMaxAttempts = 5
+	emailRetryBase   = time.Minute
+	emailLease       = 10 * time.Minute
+	// Notifications older than this are never emailed, so enabling emails
+	// again does not flood the user with history.
+	pendingNotificationAge = 7 * 24 * time.Hour
+)
+
+type EmailConfig struct {
+	BaseURL string
+	Secret  string
+}
+
+type EmailService struct {
+	repo      repository.Email
+	transport mailer.Transport
+	config    EmailConfig
+}
+
+func NewEmailService(repo repository.Email, transport mailer.Transport, config EmailConfig) *EmailService {
+	return &EmailService{repo: repo, transport: transport, config: config}
+}
+
+// GetEmailMode returns how the user receives emails; users who never chose
+// get instant emails.
+func (s EmailService) GetEmailMode(ctx context.Context, userId string) (string, error) {
+	ctx, span := tracing.Start(ctx, "EmailService.GetEmailMode")
+	defer span.End()
+
+	mode, err := s.repo.GetEmailMode(ctx, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.EmailModeInstant, nil
+	}
+	return mode, err
+}
+
+func (s EmailService) SetEmailMode(ctx context.Context, userId, mode string) error {
+	ctx, span := tracing.Start(ctx, "EmailService.SetEmailMode")
+	defer span.End()
+
+	switch mode {
+	case entity.EmailModeInstant, entity.EmailModeDigest, entity.EmailModeOff:
+		return s.repo.SetEmailMode(ctx, userId, mode)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownEmailMode, mode)
+	}
+}
+
+// Unsubscribe turns emails off for the user the signed link was issued to.
+func (s EmailService) Unsubscribe(ctx context.Context, userId, token string) error {
+	ctx, span := tracing.Start(ctx, "EmailService.Unsubscribe")
+	defer span.End()
+
+	if !hmac.Equal([]byte(token), []byte(s.unsubscribeToken(userId))) {
+		return ErrInvalidUnsubscribe
+	}
+	return s.repo.SetEmailMode(ctx, userId, entity.EmailModeOff)
+}
+
+// QueueInstantEmails puts one email per fresh notification into the outbox.
+func (s EmailService) QueueInstantEmails(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "EmailService.QueueInstantEmails")
+	defer span.End()
+
+	pending, err := s.repo.PendingNotifications(ctx, entity.EmailModeInstant, time.Now().Add(-pendingNotificationAge), emailBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, notification := range pending {
+		data := s.data(notification.UserID, notification.UserName, []entity.PendingNotification{notification})
+		if err := s.queue(ctx, notification.Type, notification.UserEmail, notification.UserID, data, []string{notification.ID}); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(pending), nil
+}
+
+// QueueDigestEmails collects the notifications not emailed yet into one email
+// per digest user, once every period. Notifications are read in batches
+// until none are left, and a user whose notifications run past the end of a
+// batch is left for the next one, which starts with them, unless they fill
+// the batch alone. Only then is the time of the digest stored, so restarts
+// do not push the next one back.
+func (s EmailService) QueueDigestEmails(ctx context.Context, every time.Duration) (int, error) {
+	ctx, span := tracing.Start(ctx, "EmailService.QueueDigestEmails")
+	defer span.End()
+
+	last, err := s.repo.GetLastDigest(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	if time.Since(last) < every {
+		return 0, nil
+	}
+
+	since := time.Now().Add(-pendingNotificationAge)
+	queued := 0
+	for {
+		pending, err := s.repo.PendingNotifications(ctx, entity.EmailModeDigest, since, digestBatchSize)
+		if err != nil {
+			return queued, err
+		}
+
+		full := len(pending) == digestBatchSize
+		groups := groupByUser(pending)
+		if full && len(groups) > 1 {
+			groups = groups[:len(groups)-1]
+		}
+
+		for _, group := range groups {
+			ids := make([]string, 0, len(group))
+			for _, notification := range group {
+				ids = append(ids, notification.ID)
+			}
+
+			first := group[0]
+			data := s.data(first.UserID, first.UserName, group)
+			if err := s.queue(ctx, mailer.DigestTemplate, first.UserEmail, first.UserID, data, ids); err != nil {
+				return queued, err
+			}
+			queued++
+		}
+
+		if !full {
+			break
+		}
+	}
+
+	return queued, s.repo.SetLastDigest(ctx, time.Now())
+}
+
+// groupByUser splits notifications ordered by user into one group per user.
+func groupByUser(pending []entity.PendingNotification) [][]entity.PendingNotification {
+	var groups [][]entity.PendingNotification
+	for start := 0; start < len(pending); {
+		end := start
+		for end < len(pending) && pending[end].UserID == pending[start].UserID {
+			end++
+		}
+
+		groups = append(groups, pending[start:end])
+		start = end
+	}
+	return groups
+}
+
+// DeliverEmails sends due outbox emails. A failed email is retried with
+// exponential backoff until it runs out of attempts.
+func (s EmailService) DeliverEmails(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "EmailService.DeliverEmails")
+	defer span.End()
+
+	emails, err := s.repo.ClaimEmails(ctx, emailBatchSize, emailMaxAttempts, emailLease)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, email := range emails {
+		err := s.transport.Send(mailer.Message{To: email.Recipient, Subject: email.Subject, Text: email.TextBody, HTML: email.HTMLBody})
+		if err != nil {
+			next := time.Now().Add(emailRetryBase << email.Attempts)
+			if err := s.repo.MarkEmailFailed(ctx, email.ID, err.Error(), next); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		if err := s.repo.MarkEmailSent(ctx, email.ID); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+func (s EmailService) queue(ctx context.Context, template, to, userId string, data mailer.Data, notificationIds []string) error {
+	message, err := mailer.Render(template, to, data)
+	if err != nil {
+		return err
+	}
+
+	email := entity.Email{UserID: userId, Recipient: to, Subject: message.Subject, TextBody: message.Text, HTMLBody: message.HTML}
+	return s.repo.QueueEmail(ctx, email, notificationIds)
+}
+
+func (s EmailService) data(userId, name string, notifications []entity.PendingNotification) mailer.Data {
+	base := strings.TrimRight(s.config.BaseURL, "/")
+
+	items := make([]mailer.Item, 0, len(notifications))
+	for _, notification := range notifications {
+		item := mailer.Item{Message: notification.Message, CreatedAt: notification.CreatedAt}
+		if notification.Task.Valid {
+			item.TaskURL = base + "/tasks/" + notification.Task.String
+		}
+		items = append(items, item)
+	}
+
+	query := url.Values{"user": {userId}, "token": {s.unsubscribeToken(userId)}}
+	return mailer.Data{Name: name, Items: items, UnsubscribeURL: base + "/unsubscribe?" + query.Encode()}
+}
+
+func (s EmailService) unsubscribeToken(userId string) string {
+	mac := hmac.New(sha256.New, []byte(s.config.Secret))
+	mac.Write([]byte(userId))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RunMailer queues and delivers emails every interval and sends digests once
+// per digest period until ctx is cancelled.
+func RunMailer(ctx context.Context, emails Email, every, digestEvery time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		if _, err := emails.QueueInstantEmails(ctx); err != nil {
+			logging.FromContext(ctx).Error("failed to queue emails", "error", err)
+		}
+
+		if _, err := emails.QueueDigestEmails(ctx, digestEvery); err != nil {
+			logging.FromContext(ctx).Error("failed to queue digest emails", "error", err)
+		}
+
+		if _, err := emails.DeliverEmails(ctx); err != nil {
+			logging.FromContext(ctx).Error("failed to deliver emails", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// pingTimeout bounds the database check of a readiness probe, so that probes
+// fail instead of hanging when the database does not answer.
+const pingTimeout = 2 * time.Second
+
+// Workers runs the background workers and keeps track of the ones that are
+// still running.
+type Workers struct {
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+func NewWorkers() *Workers {
+	return &Workers{running: make(map[string]bool)}
+}
+
+// Go runs a worker in its own goroutine until it returns, which it should do
+// once ctx is cancelled.
+func (w *Workers) Go(ctx context.Context, name string, run func(context.Context)) {
+	w.mu.Lock()
+	w.running[name] = true
+	w.mu.Unlock()
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() {
+			w.mu.Lock()
+			w.running[name] = false
+			w.mu.Unlock()
+		}()
+		run(ctx)
+	}()
+}
+
+// Stopped returns the names of the workers that have returned, sorted.
+func (w *Workers) Stopped() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var stopped []string
+	for name, running := range w.running {
+		if !running {
+			stopped = append(stopped, name)
+		}
+	}
+	sort.Strings(stopped)
+	return stopped
+}
+
+// Wait blocks until all workers have returned.
+func (w *Workers) Wait() {
+	w.wg.Wait()
+}
+
+type HealthService struct {
+	*Workers
+	repo     repository.Health
+	draining atomic.Bool
+}
+
+func NewHealthService(repo repository.Health, workers *Workers) *HealthService {
+	return &HealthService{Workers: workers, repo: repo}
+}
+
+// Drain makes readiness fail from now on, so that load balancers stop
+// sending traffic before the server shuts down.
+func (h *HealthService) Drain() {
+	h.draining.Store(true)
+}
+
+func (h *HealthService) GetReadiness(ctx context.Context) entity.Readiness {
+	ctx, span := tracing.Start(ctx, "HealthService.GetReadiness")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	readiness := entity.Readiness{Status: entity.HealthOK}
+	check := func(name string, err error) {
+		component := entity.ComponentHealth{Name: name, Status: entity.HealthOK}
+		if err != nil {
+			component.Status = entity.HealthFailing
+			component.Detail = err.Error()
+			readiness.Status = entity.HealthFailing
+		}
+		readiness.Components = append(readiness.Components, component)
+	}
+
+	err := h.repo.Ping(ctx)
+	check(entity.ComponentDatabase, err)
+
+	if err == nil {
+		var version int
+		if version, err = h.repo.GetSchemaVersion(ctx); err != nil {
+			err = fmt.Errorf("schema version unknown: %w", err)
+		} else if version < repository.SchemaVersion() {
+			err = fmt.Errorf("schema version %d, want %d", version, repository.SchemaVersion())
+		}
+	} else {
+		err = errors.New("database unavailable")
+	}
+	check(entity.ComponentMigrations, err)
+
+	err = nil
+	if stopped := h.Stopped(); len(stopped) > 0 {
+		err = fmt.Errorf("stopped: %s", strings.Join(stopped, ", "))
+	}
+	check(entity.ComponentWorkers, err)
+
+	err = nil
+	if h.draining.Load() {
+		err = errors.New("shutting down")
+	}
+	check(entity.ComponentShutdown, err)
+
+	return readiness
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"math/rand"
+	"time"
+)
+
+var ErrInvalidSeed = errors.New("seed needs users for projects and projects for tasks")
+
+var (
+	seedPriorities = []string{"Low", "Medium", "High"}
+	seedStatuses   = []string{entity.TaskStatusNotStarted, "In Progress", entity.TaskStatusDone}
+)
+
+// MaintenanceService backs the administrative subcommands of the server
+// binary. Unlike the other services it acts without a calling user.
+type MaintenanceService struct {
+	integrity     repository.Integrity
+	users         repository.User
+	projects      repository.Project
+	tasks         repository.Task
+	organizations repository.Organization
+}
+
+func NewMaintenanceService(repo *repository.Repository) *MaintenanceService {
+	return &MaintenanceService{
+		integrity:     repo.Integrity,
+		users:         repo.User,
+		projects:      repo.Project,
+		tasks:         repo.Task,
+		organizations: repo.Organization,
+	}
+}
+
+func (m MaintenanceService) CheckIntegrity(ctx context.Context) ([]entity.Inconsistency, error) {
+	ctx, span := tracing.Start(ctx, "MaintenanceService.CheckIntegrity")
+	defer span.End()
+
+	return m.integrity.CheckIntegrity(ctx)
+}
+
+func (m MaintenanceService) FixIntegrity(ctx context.Context) ([]entity.IntegrityFix, error) {
+	ctx, span := tracing.Start(ctx, "MaintenanceService.FixIntegrity")
+	defer span.End()
+
+	return m.integrity.FixIntegrity(ctx)
+}
+
+// CreateAdmin creates a user and makes them an admin of the organization.
+func (m MaintenanceService) CreateAdmin(ctx context.Context, orgId string, user entity.User) (string, error) {
+	ctx, span := tracing.Start(ctx, "MaintenanceService.CreateAdmin")
+	defer span.End()
+
+	id, _, err := m.users.CreateUser(ctx, orgId, user)
+	if err != nil {
+		return "", err
+	}
+
+	if err := m.organizations.SetMember(ctx, orgId, id, entity.OrgRoleAdmin); err != nil {
+		return "", fmt.Errorf("user %s was created as a member: %w", id, err)
+	}
+	return id, nil
+}
+
+// Seed fills the organization with demo data: users, projects managed by
+// them and tasks spread over the projects with random assignees, priorities,
+// statuses and due dates. Emails are tagged with the run so seeding twice
+// does not collide.
+func (m MaintenanceService) Seed(ctx context.Context, orgId string, seed entity.Seed) error {
+	ctx, span := tracing.Start(ctx, "MaintenanceService.Seed")
+	defer span.End()
+
+	if seed.Users < 0 || seed.Projects < 0 || seed.Tasks < 0 ||
+		(seed.Projects > 0 && seed.Users == 0) || (seed.Tasks > 0 && seed.Projects == 0) {
+		return ErrInvalidSeed
+	}
+
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	run := fmt.Sprintf("%06x", random.Intn(1<<24))
+
+	users := make([]string, 0, seed.Users)
+	for i := 1; i <= seed.Users; i++ {
+		id, _, err := m.users.CreateUser(ctx, orgId, entity.User{
+			Name:  fmt.Sprintf("Seed User %d", i),
+			Email: fmt.Sprintf("seed-%s-%d@example.com", run, i),
+			Role:  "USER",
+		})
+		if err != nil {
+			return fmt.Errorf("user %d: %w", i, err)
+		}
+		users = append(users, id)
+	}
+
+	projects := make([]entity.Project, 0, seed.Projects)
+	for i := 1; i <= seed.Projects; i++ {
+		project := entity.Project{
+			Title:        fmt.Sprintf("Seed project %d", i),
+			Description:  fmt.Sprintf("Generated by seed run %s", run),
+			Manager:      users[random.Intn(len(users))],
+			Organization: orgId,
+		}
+
+		id, _, err := m.projects.CreateProject(ctx, project)
+		if err != nil {
+			return fmt.Errorf("project %d: %w", i, err)
+		}
+		project.ID = id
+		projects = append(projects, project)
+	}
+
+	now := time.Now()
+	tasks := make([]entity.Task, 0, seed.Tasks)
+	for i := 1; i <= seed.Tasks; i++ {
+		task := entity.Task{
+			Title:       fmt.Sprintf("Seed task %d", i),
+			Description: fmt.Sprintf("Generated by seed run %s", run),
+			Priority:    seedPriorities[random.Intn(len(seedPriorities))],
+			Status:      seedStatuses[random.Intn(len(seedStatuses))],
+			Assignee:    users[random.Intn(len(users))],
+			Project:     projects[random.Intn(len(projects))].ID,
+			DueAt:       sql.NullTime{Time: now.AddDate(0, 0, random.Intn(60)-14), Valid: true},
+		}
+		if task.Status == entity.TaskStatusDone {
+			task.FinishedAt = sql.NullTime{Time: now.Add(-time.Duration(random.Intn(14*24)) * time.Hour), Valid: true}
+		}
+		tasks = append(tasks, task)
+	}
+
+	if len(tasks) == 0 {
+		return nil
+	}
+	_, err := m.tasks.CreateTasks(ctx, tasks)
+	return err
+}
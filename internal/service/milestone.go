@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"time"
+)
+
+var (
+	ErrInvalidMilestone   = errors.New("milestone needs a title and a target date")
+	ErrTaskNotInMilestone = errors.New("task is not in the milestone")
+)
+
+// minEstimatePeriod keeps a burst of tasks finished right after a milestone
+// was created from projecting an unrealistically early completion.
+const minEstimatePeriod = 24 * time.Hour
+
+type MilestoneService struct {
+	repo repository.Milestone
+}
+
+func NewMilestoneService(repo repository.Milestone) *MilestoneService {
+	return &MilestoneService{repo: repo}
+}
+
+func (s MilestoneService) CreateMilestone(ctx context.Context, projectId string, milestone entity.Milestone) (entity.Milestone, error) {
+	ctx, span := tracing.Start(ctx, "MilestoneService.CreateMilestone")
+	defer span.End()
+
+	if milestone.Title == "" || milestone.TargetAt.IsZero() {
+		return entity.Milestone{}, ErrInvalidMilestone
+	}
+
+	milestone.Project = projectId
+	id, createdAt, err := s.repo.CreateMilestone(ctx, milestone)
+	if err != nil {
+		return entity.Milestone{}, err
+	}
+
+	milestone.ID = id
+	milestone.CreatedAt = createdAt
+	return milestone, nil
+}
+
+func (s MilestoneService) GetMilestone(ctx context.Context, id string) (entity.MilestoneProgress, error) {
+	ctx, span := tracing.Start(ctx, "MilestoneService.GetMilestone")
+	defer span.End()
+
+	milestone, err := s.repo.GetMilestone(ctx, id)
+	if err != nil {
+		return entity.MilestoneProgress{}, err
+	}
+
+	return withProgress(milestone, time.Now()), nil
+}
+
+// GetProjectMilestones lists the project's milestones by target date. With
+// overdueOnly only milestones past their target with open tasks are listed.
+func (s MilestoneService) GetProjectMilestones(ctx context.Context, projectId string, overdueOnly bool) ([]entity.MilestoneProgress, error) {
+	ctx, span := tracing.Start(ctx, "MilestoneService.GetProjectMilestones")
+	defer span.End()
+
+	milestones, err := s.repo.GetMilestonesByProject(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]entity.MilestoneProgress, 0, len(milestones))
+	for _, milestone := range milestones {
+		milestone = withProgress(milestone, now)
+		if overdueOnly && !milestone.Overdue {
+			continue
+		}
+		result = append(result, milestone)
+	}
+	return result, nil
+}
+
+func (s MilestoneService) UpdateMilestone(ctx context.Context, id string, input entity.Milestone) error {
+	ctx, span := tracing.Start(ctx, "MilestoneService.UpdateMilestone")
+	defer span.End()
+
+	current, err := s.repo.GetMilestone(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	milestone := current.Milestone
+	milestone.Title = valueOr(input.Title, milestone.Title)
+	milestone.Description = valueOr(input.Description, milestone.Description)
+	if !input.TargetAt.IsZero() {
+		milestone.TargetAt = input.TargetAt
+	}
+
+	return s.repo.UpdateMilestone(ctx, milestone)
+}
+
+func (s MilestoneService) DeleteMilestone(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "MilestoneService.DeleteMilestone")
+	defer span.End()
+
+	return s.repo.DeleteMilestone(ctx, id)
+}
+
+func (s MilestoneService) AddMilestoneTasks(ctx context.Context, id string, taskIds []string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "MilestoneService.AddMilestoneTasks")
+	defer span.End()
+
+	milestone, err := s.repo.GetMilestone(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.repo.AddMilestoneTasks(ctx, id, milestone.Project, taskIds)
+}
+
+func (s MilestoneService) RemoveMilestoneTask(ctx context.Context, id, taskId string) error {
+	ctx, span := tracing.Start(ctx, "MilestoneService.RemoveMilestoneTask")
+	defer span.End()
+
+	removed, err := s.repo.RemoveMilestoneTask(ctx, id, taskId)
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrTaskNotInMilestone
+	}
+	return nil
+}
+
+func (s MilestoneService) GetMilestoneTasks(ctx context.Context, id string) ([]entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "MilestoneService.GetMilestoneTasks")
+	defer span.End()
+
+	return s.repo.GetMilestoneTasks(ctx, id)
+}
+
+// withProgress fills in the derived fields. A finished milestone completed
+// with its last task; otherwise the remaining tasks are projected at the
+// pace tasks were finished since the milestone was created.
+func withProgress(milestone entity.MilestoneProgress, now time.Time) entity.MilestoneProgress {
+	remaining := milestone.Total - milestone.Done
+	milestone.Progress = percent(milestone.Done, milestone.Total)
+
+	switch {
+	case milestone.Total > 0 && remaining == 0:
+		milestone.EstimatedCompletion = milestone.LastFinishedAt
+	case milestone.Done > 0:
+		elapsed := now.Sub(milestone.CreatedAt)
+		if elapsed < minEstimatePeriod {
+			elapsed = minEstimatePeriod
+		}
+		perTask := elapsed / time.Duration(milestone.Done)
+		milestone.EstimatedCompletion = sql.NullTime{Time: now.Add(perTask * time.Duration(remaining)), Valid: true}
+	}
+
+	milestone.Overdue = remaining > 0 && now.After(milestone.TargetAt)
+	return milestone
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/logging"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"time"
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrUnknownNotification  = errors.New("unknown notification type")
+)
+
+// Notifier is what task operations use to tell users about changes.
+type Notifier interface {
+	Notify(ctx context.Context, notification entity.Notification) error
+}
+
+// notify never fails the task operation that triggered it; a lost
+// notification is only logged.
+func notify(ctx context.Context, notifier Notifier, userId, kind, taskId, message string) {
+	notification := entity.Notification{
+		UserID:  userId,
+		Type:    kind,
+		Task:    sql.NullString{String: taskId, Valid: taskId != ""},
+		Message: message,
+	}
+
+	if err := notifier.Notify(ctx, notification); err != nil {
+		logging.FromContext(ctx).Error("failed to notify user", "user", userId, "error", err)
+	}
+}
+
+type NotificationService struct {
+	repo repository.Notification
+}
+
+func NewNotificationService(repo repository.Notification) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// Notify stores the notification unless the user turned its type off.
+func (n NotificationService) Notify(ctx context.Context, notification entity.Notification) error {
+	ctx, span := tracing.Start(ctx, "NotificationService.Notify")
+	defer span.End()
+
+	preferences, err := n.GetPreferences(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, preference := range preferences {
+		if preference.Type == notification.Type && !preference.Enabled {
+			return nil
+		}
+	}
+
+	_, _, err = n.repo.CreateNotification(ctx, notification)
+	return err
+}
+
+func (n NotificationService) NotifyDueSoon(ctx context.Context, window time.Duration) (int64, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.NotifyDueSoon")
+	defer span.End()
+
+	return n.repo.CreateDueSoonNotifications(ctx, time.Now().Add(window))
+}
+
+func (n NotificationService) GetNotifications(ctx context.Context, userId string, unreadOnly bool, limit, offset int) ([]entity.Notification, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.GetNotifications")
+	defer span.End()
+
+	return n.repo.GetNotifications(ctx, userId, unreadOnly, limit, offset)
+}
+
+func (n NotificationService) CountUnread(ctx context.Context, userId string) (int, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.CountUnread")
+	defer span.End()
+
+	return n.repo.CountUnread(ctx, userId)
+}
+
+func (n NotificationService) MarkRead(ctx context.Context, userId, id string) error {
+	ctx, span := tracing.Start(ctx, "NotificationService.MarkRead")
+	defer span.End()
+
+	affected, err := n.repo.MarkRead(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (n NotificationService) MarkAllRead(ctx context.Context, userId string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.MarkAllRead")
+	defer span.End()
+
+	return n.repo.MarkAllRead(ctx, userId)
+}
+
+// GetPreferences lists every notification type; types the user never
+// configured are enabled.
+func (n NotificationService) GetPreferences(ctx context.Context, userId string) ([]entity.NotificationPreference, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.GetPreferences")
+	defer span.End()
+
+	stored, err := n.repo.GetPreferences(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := make(map[string]bool)
+	for _, preference := range stored {
+		enabled[preference.Type] = preference.Enabled
+	}
+
+	preferences := make([]entity.NotificationPreference, 0, len(entity.NotificationTypes))
+	for _, kind := range entity.NotificationTypes {
+		value, ok := enabled[kind]
+		preferences = append(preferences, entity.NotificationPreference{Type: kind, Enabled: value || !ok})
+	}
+
+	return preferences, nil
+}
+
+func (n NotificationService) SetPreferences(ctx context.Context, userId string, preferences []entity.NotificationPreference) error {
+	ctx, span := tracing.Start(ctx, "NotificationService.SetPreferences")
+	defer span.End()
+
+	for _, preference := range preferences {
+		if !isNotificationType(preference.Type) {
+			return fmt.Errorf("%w: %s", ErrUnknownNotification, preference.Type)
+		}
+	}
+
+	for _, preference := range preferences {
+		if err := n.repo.SetPreference(ctx, userId, preference); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isNotificationType(kind string) bool {
+	for _, known := range entity.NotificationTypes {
+		if known == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// RunDueSoonNotifier checks for tasks coming due every interval until ctx is
+// cancelled.
+func RunDueSoonNotifier(ctx context.Context, notifications Notification, every, window time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		if _, err := notifications.NotifyDueSoon(ctx, window); err != nil {
+			logging.FromContext(ctx).Error("failed to create due soon notifications", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"github.com/google/uuid"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidOrganization  = errors.New("organization needs a name and a slug of lowercase letters, digits and dashes")
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrSlugTaken            = errors.New("organization slug is already taken")
+	ErrNotMember            = errors.New("user is not a member of the organization")
+	ErrForbidden            = errors.New("organization role does not allow this")
+	ErrInvalidRole          = errors.New("unknown organization role")
+	ErrLastOwner            = errors.New("organization must keep at least one owner")
+)
+
+// slugPattern matches a single DNS label so that a slug can be used as a
+// subdomain.
+var slugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+type OrganizationService struct {
+	repo repository.Organization
+}
+
+func NewOrganizationService(repo repository.Organization) *OrganizationService {
+	return &OrganizationService{repo: repo}
+}
+
+// CreateOrganization makes the user the owner of a new organization. The slug
+// is derived from the name when it is not given.
+func (o OrganizationService) CreateOrganization(ctx context.Context, ownerId string, organization entity.Organization) (entity.Organization, error) {
+	ctx, span := tracing.Start(ctx, "OrganizationService.CreateOrganization")
+	defer span.End()
+
+	organization.Name = strings.TrimSpace(organization.Name)
+	if organization.Slug == "" {
+		organization.Slug = slugify(organization.Name)
+	}
+	if err := o.validate(ctx, organization, ""); err != nil {
+		return entity.Organization{}, err
+	}
+
+	id, createdAt, err := o.repo.CreateOrganization(ctx, organization, ownerId)
+	if err != nil {
+		return entity.Organization{}, err
+	}
+
+	organization.ID = id
+	organization.CreatedAt = createdAt
+	return organization, nil
+}
+
+// ResolveOrganization finds an organization by id or slug; an empty key
+// means the default organization.
+func (o OrganizationService) ResolveOrganization(ctx context.Context, key string) (entity.Organization, error) {
+	ctx, span := tracing.Start(ctx, "OrganizationService.ResolveOrganization")
+	defer span.End()
+
+	var organization entity.Organization
+	var err error
+	if key == "" {
+		key = entity.DefaultOrganization
+	}
+
+	if _, parseErr := uuid.Parse(key); parseErr == nil {
+		organization, err = o.repo.GetOrganization(ctx, key)
+	} else {
+		organization, err = o.repo.GetOrganizationBySlug(ctx, strings.ToLower(key))
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Organization{}, fmt.Errorf("%w: %s", ErrOrganizationNotFound, key)
+	}
+
+	return organization, err
+}
+
+func (o OrganizationService) GetOrganization(ctx context.Context, id string) (entity.Organization, error) {
+	ctx, span := tracing.Start(ctx, "OrganizationService.GetOrganization")
+	defer span.End()
+
+	organization, err := o.repo.GetOrganization(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Organization{}, ErrOrganizationNotFound
+	}
+
+	return organization, err
+}
+
+func (o OrganizationService) UpdateOrganization(ctx context.Context, id, actorId string, input entity.Organization) error {
+	ctx, span := tracing.Start(ctx, "OrganizationService.UpdateOrganization")
+	defer span.End()
+
+	if err := o.requireRole(ctx, id, actorId, entity.OrgRoleAdmin); err != nil {
+		return err
+	}
+
+	organization, err := o.GetOrganization(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	current := organization.Slug
+	organization.Name = valueOr(strings.TrimSpace(input.Name), organization.Name)
+	organization.Slug = valueOr(input.Slug, organization.Slug)
+	if err := o.validate(ctx, organization, current); err != nil {
+		return err
+	}
+
+	return o.repo.UpdateOrganization(ctx, organization)
+}
+
+// DeleteOrganization removes the organization with all of its projects. The
+// default organization is kept because requests without one fall back to it.
+func (o OrganizationService) DeleteOrganization(ctx context.Context, id, actorId string) error {
+	ctx, span := tracing.Start(ctx, "OrganizationService.DeleteOrganization")
+	defer span.End()
+
+	if err := o.requireRole(ctx, id, actorId, entity.OrgRoleOwner); err != nil {
+		return err
+	}
+
+	organization, err := o.GetOrganization(ctx, id)
+	if err != nil {
+		return err
+	}
+	if organization.Slug == entity.DefaultOrganization {
+		return fmt.Errorf("%w: the default organization cannot be deleted", ErrForbidden)
+	}
+
+	return o.repo.DeleteOrganization(ctx, id)
+}
+
+func (o OrganizationService) GetUserOrganizations(ctx context.Context, userId string) ([]entity.UserOrganization, error) {
+	ctx, span := tracing.Start(ctx, "OrganizationService.GetUserOrganizations")
+	defer span.End()
+
+	return o.repo.GetUserOrganizations(ctx, userId)
+}
+
+// GetRole returns the user's role in the organization.
+func (o OrganizationService) GetRole(ctx context.Context, orgId, userId string) (string, error) {
+	ctx, span := tracing.Start(ctx, "OrganizationService.GetRole")
+	defer span.End()
+
+	membership, err := o.repo.GetMembership(ctx, orgId, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotMember
+	}
+
+	return membership.Role, err
+}
+
+func (o OrganizationService) GetMembers(ctx context.Context, orgId string) ([]entity.Member, error) {
+	ctx, span := tracing.Start(ctx, "OrganizationService.GetMembers")
+	defer span.End()
+
+	return o.repo.GetMembers(ctx, orgId)
+}
+
+// SetMember adds a user to the organization or changes their role. Admins
+// manage members and admins; only owners grant or take away ownership.
+func (o OrganizationService) SetMember(ctx context.Context, orgId, actorId, userId, role string) error {
+	ctx, span := tracing.Start(ctx, "OrganizationService.SetMember")
+	defer span.End()
+
+	if roleRank(role) == 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidRole, role)
+	}
+
+	actorRole, err := o.GetRole(ctx, orgId, actorId)
+	if err != nil {
+		return err
+	}
+
+	current, err := o.GetRole(ctx, orgId, userId)
+	if err != nil && !errors.Is(err, ErrNotMember) {
+		return err
+	}
+
+	required := entity.OrgRoleAdmin
+	if role == entity.OrgRoleOwner || current == entity.OrgRoleOwner {
+		required = entity.OrgRoleOwner
+	}
+	if roleRank(actorRole) < roleRank(required) {
+		return ErrForbidden
+	}
+
+	if current == entity.OrgRoleOwner && role != entity.OrgRoleOwner {
+		if err := o.keepOwner(ctx, orgId); err != nil {
+			return err
+		}
+	}
+
+	return o.repo.SetMember(ctx, orgId, userId, role)
+}
+
+// RemoveMember takes the user out of the organization. Users may always
+// leave; removing someone else needs the rights SetMember asks for.
+func (o OrganizationService) RemoveMember(ctx context.Context, orgId, actorId, userId string) error {
+	ctx, span := tracing.Start(ctx, "OrganizationService.RemoveMember")
+	defer span.End()
+
+	current, err := o.GetRole(ctx, orgId, userId)
+	if err != nil {
+		return err
+	}
+
+	if actorId != userId {
+		actorRole, err := o.GetRole(ctx, orgId, actorId)
+		if err != nil {
+			return err
+		}
+
+		required := entity.OrgRoleAdmin
+		if current == entity.OrgRoleOwner {
+			required = entity.OrgRoleOwner
+		}
+		if roleRank(actorRole) < roleRank(required) {
+			return ErrForbidden
+		}
+	}
+
+	if current == entity.OrgRoleOwner {
+		if err := o.keepOwner(ctx, orgId); err != nil {
+			return err
+		}
+	}
+
+	affected, err := o.repo.RemoveMember(ctx, orgId, userId)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotMember
+	}
+	return nil
+}
+
+func (o OrganizationService) Contains(ctx context.Context, orgId, resource, id string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "OrganizationService.Contains")
+	defer span.End()
+
+	return o.repo.Contains(ctx, orgId, resource, id)
+}
+
+func (o OrganizationService) requireRole(ctx context.Context, orgId, userId, role string) error {
+	current, err := o.GetRole(ctx, orgId, userId)
+	if err != nil {
+		return err
+	}
+
+	if roleRank(current) < roleRank(role) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// keepOwner fails when the organization is down to its last owner.
+func (o OrganizationService) keepOwner(ctx context.Context, orgId string) error {
+	owners, err := o.repo.CountOwners(ctx, orgId)
+	if err != nil {
+		return err
+	}
+
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func (o OrganizationService) validate(ctx context.Context, organization entity.Organization, currentSlug string) error {
+	if organization.Name == "" || !slugPattern.MatchString(organization.Slug) {
+		return ErrInvalidOrganization
+	}
+
+	if organization.Slug == currentSlug {
+		return nil
+	}
+
+	_, err := o.repo.GetOrganizationBySlug(ctx, organization.Slug)
+	if err == nil {
+		return fmt.Errorf("%w: %s", ErrSlugTaken, organization.Slug)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	return nil
+}
+
+func roleRank(role string) int {
+	for i, known := range entity.OrgRoles {
+		if known == role {
+			return len(entity.OrgRoles) - i
+		}
+	}
+	return 0
+}
+
+// slugify lowercases the name and joins its letters and digits with dashes.
+func slugify(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+
+	slug := strings.Join(words, "-")
+	if len(slug) > 63 {
+		slug = strings.TrimRight(slug[:63], "-")
+	}
+	return slug
+}
@@ -1,41 +1,138 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
 	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
 	"time"
 )
 
+var (
+	ErrProjectArchived   = repository.ErrProjectArchived
+	ErrInvalidArchive    = errors.New("invalid archive request")
+	ErrNotProjectManager = errors.New("only the project manager can do this")
+)
+
+// archiveStatuses is the status open tasks get under each archive policy.
+var archiveStatuses = map[string]string{
+	entity.ArchiveClose:  entity.TaskStatusDone,
+	entity.ArchiveCancel: entity.TaskStatusCancelled,
+}
+
 type ProjectService struct {
 	repo repository.Project
 }
 
-func (p ProjectService) GetAllProjects() ([]entity.Project, error) {
-	return p.repo.GetAllProjects()
+func (p ProjectService) GetAllProjects(ctx context.Context, orgId string, includeArchived bool) ([]entity.Project, error) {
+	ctx, span := tracing.Start(ctx, "ProjectService.GetAllProjects")
+	defer span.End()
+
+	return p.repo.GetAllProjects(ctx, orgId, includeArchived)
 }
 
-func (p ProjectService) CreateProject(project entity.Project) (string, time.Time, error) {
-	return p.repo.CreateProject(project)
+func (p ProjectService) CreateProject(ctx context.Context, project entity.Project) (string, time.Time, error) {
+	ctx, span := tracing.Start(ctx, "ProjectService.CreateProject")
+	defer span.End()
+
+	return p.repo.CreateProject(ctx, project)
 }
 
-func (p ProjectService) UpdateProject(id string, project entity.Project) error {
-	return p.repo.UpdateProject(id, project)
+func (p ProjectService) UpdateProject(ctx context.Context, id string, project entity.Project) error {
+	ctx, span := tracing.Start(ctx, "ProjectService.UpdateProject")
+	defer span.End()
+
+	return p.repo.UpdateProject(ctx, id, project)
 }
 
-func (p ProjectService) DeleteProject(id string) error {
-	return p.repo.DeleteProject(id)
+func (p ProjectService) DeleteProject(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "ProjectService.DeleteProject")
+	defer span.End()
+
+	return p.repo.DeleteProject(ctx, id)
 }
 
-func (p ProjectService) GetProjectById(id string) (entity.Project, error) {
-	return p.repo.GetProjectById(id)
+func (p ProjectService) GetProjectById(ctx context.Context, id string) (entity.Project, error) {
+	ctx, span := tracing.Start(ctx, "ProjectService.GetProjectById")
+	defer span.End()
+
+	return p.repo.GetProjectById(ctx, id)
 }
 
-func (p ProjectService) GetProjectByTitle(projectTitle string) (entity.Project, error) {
-	return p.repo.GetProjectByTitle(projectTitle)
+func (p ProjectService) GetProjectsByIds(ctx context.Context, orgId string, ids []string) ([]entity.Project, error) {
+	ctx, span := tracing.Start(ctx, "ProjectService.GetProjectsByIds")
+	defer span.End()
+
+	return p.repo.GetProjectsByIds(ctx, orgId, ids)
 }
 
-func (p ProjectService) GetProjectByManagerId(managerId string) ([]entity.Project, error) {
-	return p.repo.GetProjectByManagerId(managerId)
+func (p ProjectService) GetProjectByTitle(ctx context.Context, orgId, projectTitle string) (entity.Project, error) {
+	ctx, span := tracing.Start(ctx, "ProjectService.GetProjectByTitle")
+	defer span.End()
+
+	return p.repo.GetProjectByTitle(ctx, orgId, projectTitle)
+}
+
+func (p ProjectService) GetProjectByManagerId(ctx context.Context, orgId, managerId string, includeArchived bool) ([]entity.Project, error) {
+	ctx, span := tracing.Start(ctx, "ProjectService.GetProjectByManagerId")
+	defer span.End()
+
+	return p.repo.GetProjectByManagerId(ctx, orgId, managerId, includeArchived)
+}
+
+// ArchiveProject finishes the project and its open tasks according to the
+// policy, or with the given closed status. Only the project manager may do
+// so; an empty actor is a trusted caller such as a gRPC client with the
+// server token.
+func (p ProjectService) ArchiveProject(ctx context.Context, id, actor string, input entity.ArchiveProject) (int64, error) {
+	ctx, span := tracing.Start(ctx, "ProjectService.ArchiveProject")
+	defer span.End()
+
+	status, ok := archiveStatuses[input.Policy]
+	if !ok {
+		return 0, fmt.Errorf("%w: policy must be %q or %q", ErrInvalidArchive, entity.ArchiveClose, entity.ArchiveCancel)
+	}
+	if input.Status != "" {
+		if input.Status != entity.TaskStatusDone && input.Status != entity.TaskStatusCancelled {
+			return 0, fmt.Errorf("%w: status must be %q or %q", ErrInvalidArchive, entity.TaskStatusDone, entity.TaskStatusCancelled)
+		}
+		status = input.Status
+	}
+
+	project, err := p.repo.GetProjectById(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if actor != "" && project.Manager != actor {
+		return 0, ErrNotProjectManager
+	}
+	if project.FinishedAt.Valid {
+		return 0, ErrProjectArchived
+	}
+
+	return p.repo.ArchiveProject(ctx, id, status, time.Now())
+}
+
+// UnarchiveProject reopens the project for changes. Only its manager may do
+// so; the tasks closed when it was archived stay closed.
+func (p ProjectService) UnarchiveProject(ctx context.Context, id, actor string) error {
+	ctx, span := tracing.Start(ctx, "ProjectService.UnarchiveProject")
+	defer span.End()
+
+	project, err := p.repo.GetProjectById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if project.Manager != actor {
+		return ErrNotProjectManager
+	}
+	if !project.FinishedAt.Valid {
+		return nil
+	}
+
+	return p.repo.UnarchiveProject(ctx, id)
 }
 
 func NewProjectService(repo repository.Project) *ProjectService {
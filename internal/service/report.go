@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"time"
+)
+
+const maxReportPoints = 366
+
+var ErrInvalidReportRange = errors.New("invalid report range")
+
+type ReportService struct {
+	repo repository.Report
+}
+
+func NewReportService(repo repository.Report) *ReportService {
+	return &ReportService{repo: repo}
+}
+
+func (r ReportService) Burndown(ctx context.Context, projectId string, period entity.ReportRange) ([]entity.BurndownPoint, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.Burndown")
+	defer span.End()
+
+	if err := validateReportRange(period); err != nil {
+		return nil, err
+	}
+	return r.repo.Burndown(ctx, projectId, period)
+}
+
+func (r ReportService) CumulativeFlow(ctx context.Context, projectId string, period entity.ReportRange) ([]entity.CumulativeFlowPoint, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.CumulativeFlow")
+	defer span.End()
+
+	if err := validateReportRange(period); err != nil {
+		return nil, err
+	}
+
+	counts, err := r.repo.CumulativeFlow(ctx, projectId, period)
+	if err != nil {
+		return nil, err
+	}
+
+	// Buckets without any task are missing from the query result, so the
+	// series is laid out first and the counts are filled in afterwards.
+	points := make([]entity.CumulativeFlowPoint, 0)
+	index := make(map[int64]int)
+	for date := period.From; !date.After(period.To); date = nextBucket(date, period.Interval) {
+		index[date.Unix()] = len(points)
+		points = append(points, entity.CumulativeFlowPoint{Date: date, Statuses: make(map[string]int)})
+	}
+
+	for _, count := range counts {
+		if i, ok := index[count.Date.Unix()]; ok {
+			points[i].Statuses[count.Status] = count.Count
+		}
+	}
+
+	return points, nil
+}
+
+func (r ReportService) Throughput(ctx context.Context, projectId string, period entity.ReportRange) ([]entity.ThroughputPoint, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.Throughput")
+	defer span.End()
+
+	if err := validateReportRange(period); err != nil {
+		return nil, err
+	}
+	return r.repo.Throughput(ctx, projectId, period)
+}
+
+func validateReportRange(period entity.ReportRange) error {
+	if period.Interval != entity.IntervalDay && period.Interval != entity.IntervalWeek {
+		return ErrInvalidReportRange
+	}
+
+	if period.To.Before(period.From) {
+		return ErrInvalidReportRange
+	}
+
+	points := 0
+	for date := period.From; !date.After(period.To); date = nextBucket(date, period.Interval) {
+		points++
+		if points > maxReportPoints {
+			return ErrInvalidReportRange
+		}
+	}
+	return nil
+}
+
+func nextBucket(date time.Time, interval string) time.Time {
+	if interval == entity.IntervalWeek {
+		return date.AddDate(0, 0, 7)
+	}
+	return date.AddDate(0, 0, 1)
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/logging"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/rrule"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"time"
+)
+
+var (
+	ErrInvalidRecurrence = errors.New("invalid recurrence")
+	ErrNotRecurring      = errors.New("task is not part of a series")
+	ErrAlreadyRecurring  = errors.New("task is already part of a series")
+)
+
+// scheduleRounds bounds how many overdue occurrences of one series a single
+// scheduler run catches up on.
+const scheduleRounds = 10
+
+// Recurrer is what task operations use to continue a series once one of its
+// occurrences is finished.
+type Recurrer interface {
+	Recur(ctx context.Context, task entity.Task) error
+}
+
+type SeriesService struct {
+	repo     repository.Series
+	tasks    repository.Task
+	fields   FieldChecker
+	notifier Notifier
+}
+
+func NewSeriesService(repo repository.Series, tasks repository.Task, fields FieldChecker, notifier Notifier) *SeriesService {
+	return &SeriesService{repo: repo, tasks: tasks, fields: fields, notifier: notifier}
+}
+
+// SetRecurrence turns the task into the first occurrence of a new series.
+// The series starts at the task's due date, or at its creation if it has
+// none, and its next occurrence is the first one still ahead, so dates that
+// already passed are not filled in.
+func (s SeriesService) SetRecurrence(ctx context.Context, taskId string, input entity.Recurrence) (entity.TaskSeries, error) {
+	ctx, span := tracing.Start(ctx, "SeriesService.SetRecurrence")
+	defer span.End()
+
+	task, err := s.tasks.GetTaskById(ctx, taskId)
+	if err != nil {
+		return entity.TaskSeries{}, err
+	}
+	if task.Series.Valid {
+		return entity.TaskSeries{}, ErrAlreadyRecurring
+	}
+
+	rule, err := rrule.Parse(input.Rule)
+	if err != nil {
+		return entity.TaskSeries{}, fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+
+	trigger, err := recurrenceTrigger(input.Trigger, entity.RecurOnFinish)
+	if err != nil {
+		return entity.TaskSeries{}, err
+	}
+
+	start := task.CreatedAt
+	if task.DueAt.Valid {
+		start = task.DueAt.Time
+	}
+	next, ok := rule.After(start, latestTime(start, time.Now()))
+
+	series := entity.TaskSeries{
+		Rule:        rule.String(),
+		Trigger:     trigger,
+		StartAt:     start,
+		NextAt:      sql.NullTime{Time: next, Valid: ok},
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		Status:      task.Status,
+		Assignee:    task.Assignee,
+		Project:     task.Project,
+		Fields:      task.Fields,
+	}
+
+	series.ID, err = s.repo.CreateSeries(ctx, taskId, series)
+	if err != nil {
+		return entity.TaskSeries{}, err
+	}
+
+	if task.FinishedAt.Valid && trigger == entity.RecurOnFinish && ok {
+		if _, err := s.advance(ctx, series, time.Now()); err != nil {
+			return series, err
+		}
+	}
+	return series, nil
+}
+
+func (s SeriesService) GetTaskSeries(ctx context.Context, taskId string) (entity.TaskSeries, error) {
+	ctx, span := tracing.Start(ctx, "SeriesService.GetTaskSeries")
+	defer span.End()
+
+	task, err := s.tasks.GetTaskById(ctx, taskId)
+	if err != nil {
+		return entity.TaskSeries{}, err
+	}
+	if !task.Series.Valid {
+		return entity.TaskSeries{}, ErrNotRecurring
+	}
+
+	return s.repo.GetSeries(ctx, task.Series.String)
+}
+
+// UpdateSeries edits the series from the given occurrence on: the template
+// for future occurrences changes and so do the unfinished occurrences that
+// already exist, except for their status. A new rule is anchored at this
+// occurrence and continues after the latest occurrence or now, whichever is
+// later.
+func (s SeriesService) UpdateSeries(ctx context.Context, taskId string, input entity.SeriesUpdate) error {
+	ctx, span := tracing.Start(ctx, "SeriesService.UpdateSeries")
+	defer span.End()
+
+	task, err := s.tasks.GetTaskById(ctx, taskId)
+	if err != nil {
+		return err
+	}
+	if !task.Series.Valid {
+		return ErrNotRecurring
+	}
+
+	series, err := s.repo.GetSeries(ctx, task.Series.String)
+	if err != nil {
+		return err
+	}
+
+	changes := entity.Task{Title: input.Title, Description: input.Description, Priority: input.Priority, Assignee: input.Assignee}
+	series.Title = valueOr(input.Title, series.Title)
+	series.Description = valueOr(input.Description, series.Description)
+	series.Priority = valueOr(input.Priority, series.Priority)
+	series.Status = valueOr(input.Status, series.Status)
+	series.Assignee = valueOr(input.Assignee, series.Assignee)
+
+	series.Trigger, err = recurrenceTrigger(input.Trigger, series.Trigger)
+	if err != nil {
+		return err
+	}
+
+	if input.Rule != "" {
+		rule, err := rrule.Parse(input.Rule)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+		}
+
+		latest, err := s.repo.LatestOccurrence(ctx, series.ID)
+		if err != nil {
+			return err
+		}
+
+		next, ok := rule.After(task.OccursAt.Time, latestTime(latest, time.Now()))
+		series.Rule = rule.String()
+		series.StartAt = task.OccursAt.Time
+		series.NextAt = sql.NullTime{Time: next, Valid: ok}
+	}
+
+	return s.repo.UpdateSeries(ctx, series, task.OccursAt.Time, changes)
+}
+
+// StopSeries ends the recurrence. Occurrences created so far stay as
+// ordinary tasks.
+func (s SeriesService) StopSeries(ctx context.Context, taskId string) error {
+	ctx, span := tracing.Start(ctx, "SeriesService.StopSeries")
+	defer span.End()
+
+	task, err := s.tasks.GetTaskById(ctx, taskId)
+	if err != nil {
+		return err
+	}
+	if !task.Series.Valid {
+		return ErrNotRecurring
+	}
+
+	return s.repo.DeleteSeries(ctx, task.Series.String)
+}
+
+// Recur creates the next occurrence of a series that recurs on finish. Dates
+// that passed while the task was open are skipped.
+func (s SeriesService) Recur(ctx context.Context, task entity.Task) error {
+	ctx, span := tracing.Start(ctx, "SeriesService.Recur")
+	defer span.End()
+
+	if !task.Series.Valid {
+		return nil
+	}
+
+	series, err := s.repo.GetSeries(ctx, task.Series.String)
+	if err != nil {
+		return err
+	}
+	if series.Trigger != entity.RecurOnFinish || !series.NextAt.Valid {
+		return nil
+	}
+
+	_, err = s.advance(ctx, series, time.Now())
+	return err
+}
+
+// GenerateScheduled creates the occurrences of scheduled series that take
+// place within lead from now.
+func (s SeriesService) GenerateScheduled(ctx context.Context, lead time.Duration) (int, error) {
+	ctx, span := tracing.Start(ctx, "SeriesService.GenerateScheduled")
+	defer span.End()
+
+	created := 0
+	for round := 0; round < scheduleRounds; round++ {
+		due, err := s.repo.DueSeries(ctx, time.Now().Add(lead), 100)
+		if err != nil {
+			return created, err
+		}
+
+		before := created
+		for _, series := range due {
+			_, err := s.advance(ctx, series, time.Time{})
+			if errors.Is(err, repository.ErrSeriesChanged) {
+				continue
+			}
+			if errors.Is(err, ErrUnknownField) || errors.Is(err, ErrInvalidFieldValue) {
+				logging.FromContext(ctx).Error("series does not fit the custom fields of its project", "series", series.ID, "error", err)
+				continue
+			}
+			if err != nil {
+				return created, err
+			}
+			created++
+		}
+		if created == before {
+			break
+		}
+	}
+
+	return created, nil
+}
+
+// advance creates the pending occurrence of the series, or the first one
+// after notBefore if the pending one is older. The occurrence gets the
+// custom field values of the series, checked like those of a new task, and
+// its assignee is notified as for a new task. A series without occurrences
+// left is marked as ended.
+func (s SeriesService) advance(ctx context.Context, series entity.TaskSeries, notBefore time.Time) (string, error) {
+	rule, err := rrule.Parse(series.Rule)
+	if err != nil {
+		return "", err
+	}
+
+	occursAt := series.NextAt.Time
+	if occursAt.Before(notBefore) {
+		var ok bool
+		occursAt, ok = rule.After(series.StartAt, notBefore)
+		if !ok {
+			series.NextAt = sql.NullTime{}
+			return "", s.repo.UpdateSeries(ctx, series, occursAt, entity.Task{})
+		}
+	}
+
+	if series.Fields, err = s.fields.CheckTaskFields(ctx, series.Project, series.Fields); err != nil {
+		return "", err
+	}
+
+	next, ok := rule.After(series.StartAt, occursAt)
+	id, err := s.repo.CreateOccurrence(ctx, series, occursAt, sql.NullTime{Time: next, Valid: ok})
+	if err != nil {
+		return "", err
+	}
+
+	notify(ctx, s.notifier, series.Assignee, entity.NotificationAssigned, id, fmt.Sprintf("You were assigned to task %q", series.Title))
+	return id, nil
+}
+
+func recurrenceTrigger(trigger, fallback string) (string, error) {
+	switch trigger {
+	case "":
+		return fallback, nil
+	case entity.RecurOnFinish, entity.RecurOnSchedule:
+		return trigger, nil
+	default:
+		return "", fmt.Errorf("%w: unknown trigger %q", ErrInvalidRecurrence, trigger)
+	}
+}
+
+func latestTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func valueOr(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
+
+// RunRecurrenceScheduler creates upcoming occurrences of scheduled series
+// every interval until ctx is cancelled.
+func RunRecurrenceScheduler(ctx context.Context, series Series, every, lead time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		if _, err := series.GenerateScheduled(ctx, lead); err != nil {
+			logging.FromContext(ctx).Error("failed to generate recurring tasks", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
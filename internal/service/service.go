@@ -1,54 +1,217 @@
 package service
 
 import (
+	"context"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/mailer"
 	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"io"
 	"time"
 )
 
 type User interface {
-	CreateUser(user entity.User) (string, time.Time, error)
-	UpdateUser(id string, user entity.User) error
-	DeleteUser(id string) error
-	GetUserById(id string) (entity.User, error)
-	GetUserByName(name string) (entity.User, error)
-	GetUserByEmail(email string) (entity.User, error)
-	GetAllUsers() ([]entity.User, error)
+	CreateUser(ctx context.Context, orgId string, user entity.User) (string, time.Time, error)
+	UpdateUser(ctx context.Context, orgId, actorId, id string, user entity.User) error
+	DeleteUser(ctx context.Context, orgId, actorId, id string) (bool, error)
+	GetUserById(ctx context.Context, id string) (entity.User, error)
+	GetUsersByIds(ctx context.Context, orgId string, ids []string) ([]entity.User, error)
+	GetUserByName(ctx context.Context, orgId, name string) (entity.User, error)
+	GetUserByEmail(ctx context.Context, orgId, email string) (entity.User, error)
+	GetAllUsers(ctx context.Context, orgId string) ([]entity.User, error)
 }
 
 type Task interface {
-	CreateTask(task entity.Task) (string, time.Time, error)
-	UpdateTask(id string, task entity.Task) error
-	DeleteTask(id string) error
-	GetTaskById(id string) (entity.Task, error)
-	GetAllTasks() ([]entity.Task, error)
-	GetTaskByTitle(title string) (entity.Task, error)
-	GetTaskByStatus(status string) ([]entity.Task, error)
-	GetTaskByPriority(priority string) ([]entity.Task, error)
-	GetTasksByUserId(userId, column string) ([]entity.Task, error)
-	GetTasksByProjectId(project string) ([]entity.Task, error)
+	CreateTask(ctx context.Context, task entity.Task) (string, time.Time, error)
+	UpdateTask(ctx context.Context, id string, task entity.Task) error
+	DeleteTask(ctx context.Context, id string) error
+	GetTaskById(ctx context.Context, id string) (entity.Task, error)
+	GetAllTasks(ctx context.Context, orgId string, includeArchived bool) ([]entity.Task, error)
+	GetTaskByTitle(ctx context.Context, orgId, title string) (entity.Task, error)
+	GetTaskByStatus(ctx context.Context, orgId, status string, includeArchived bool) ([]entity.Task, error)
+	GetTaskByPriority(ctx context.Context, orgId, priority string, includeArchived bool) ([]entity.Task, error)
+	GetTasksByUserId(ctx context.Context, orgId, userId, column string, includeArchived bool) ([]entity.Task, error)
+	GetTasksByProjectId(ctx context.Context, project string) ([]entity.Task, error)
+	GetTasksByProjectIds(ctx context.Context, ids []string) ([]entity.Task, error)
+	GetTasksByAssignees(ctx context.Context, orgId string, ids []string, includeArchived bool) ([]entity.Task, error)
+	BulkTasks(ctx context.Context, items []entity.BulkTaskItem, atomic bool) ([]entity.BulkTaskResult, error)
+	ReassignTasks(ctx context.Context, orgId, from string, input entity.ReassignTasks) (int64, error)
+	MoveTask(ctx context.Context, id string, input entity.MoveTask) error
+}
+
+type Template interface {
+	GetTemplates(ctx context.Context, orgId string) ([]entity.Project, error)
+	SaveAsTemplate(ctx context.Context, id, title string) (entity.Project, error)
+	CloneProject(ctx context.Context, id string, input entity.CloneProject) (entity.Project, error)
+}
+
+type TaskTransfer interface {
+	ExportTasks(ctx context.Context, projectId, format string, w io.Writer) error
+	ImportTasks(ctx context.Context, orgId, projectId, format string, r io.Reader, columns map[string]string, dryRun bool) (entity.TaskImportReport, error)
 }
 
 type Project interface {
-	CreateProject(project entity.Project) (string, time.Time, error)
-	UpdateProject(id string, project entity.Project) error
-	DeleteProject(id string) error
-	GetProjectById(id string) (entity.Project, error)
-	GetProjectByTitle(title string) (entity.Project, error)
-	GetProjectByManagerId(managerId string) ([]entity.Project, error)
-	GetAllProjects() ([]entity.Project, error)
+	CreateProject(ctx context.Context, project entity.Project) (string, time.Time, error)
+	UpdateProject(ctx context.Context, id string, project entity.Project) error
+	DeleteProject(ctx context.Context, id string) error
+	GetProjectById(ctx context.Context, id string) (entity.Project, error)
+	GetProjectsByIds(ctx context.Context, orgId string, ids []string) ([]entity.Project, error)
+	GetProjectByTitle(ctx context.Context, orgId, title string) (entity.Project, error)
+	GetProjectByManagerId(ctx context.Context, orgId, managerId string, includeArchived bool) ([]entity.Project, error)
+	GetAllProjects(ctx context.Context, orgId string, includeArchived bool) ([]entity.Project, error)
+	ArchiveProject(ctx context.Context, id, actor string, input entity.ArchiveProject) (int64, error)
+	UnarchiveProject(ctx context.Context, id, actor string) error
+}
+
+type Calendar interface {
+	CreateCalendarToken(ctx context.Context, orgId, scope, target string) (entity.CalendarToken, error)
+	RevokeCalendarToken(ctx context.Context, token string) error
+	RenderCalendar(ctx context.Context, token string) ([]byte, error)
+}
+
+type Report interface {
+	Burndown(ctx context.Context, projectId string, period entity.ReportRange) ([]entity.BurndownPoint, error)
+	CumulativeFlow(ctx context.Context, projectId string, period entity.ReportRange) ([]entity.CumulativeFlowPoint, error)
+	Throughput(ctx context.Context, projectId string, period entity.ReportRange) ([]entity.ThroughputPoint, error)
+}
+
+type Dashboard interface {
+	GetDashboard(ctx context.Context, orgId, userId string) (entity.Dashboard, error)
+	GetUsage(ctx context.Context) (entity.Usage, error)
+}
+
+type Notification interface {
+	Notifier
+	NotifyDueSoon(ctx context.Context, window time.Duration) (int64, error)
+	GetNotifications(ctx context.Context, userId string, unreadOnly bool, limit, offset int) ([]entity.Notification, error)
+	CountUnread(ctx context.Context, userId string) (int, error)
+	MarkRead(ctx context.Context, userId, id string) error
+	MarkAllRead(ctx context.Context, userId string) (int64, error)
+	GetPreferences(ctx context.Context, userId string) ([]entity.NotificationPreference, error)
+	SetPreferences(ctx context.Context, userId string, preferences []entity.NotificationPreference) error
+}
+
+type Email interface {
+	GetEmailMode(ctx context.Context, userId string) (string, error)
+	SetEmailMode(ctx context.Context, userId, mode string) error
+	Unsubscribe(ctx context.Context, userId, token string) error
+	QueueInstantEmails(ctx context.Context) (int, error)
+	QueueDigestEmails(ctx context.Context, every time.Duration) (int, error)
+	DeliverEmails(ctx context.Context) (int, error)
+}
+
+type Series interface {
+	Recurrer
+	SetRecurrence(ctx context.Context, taskId string, input entity.Recurrence) (entity.TaskSeries, error)
+	GetTaskSeries(ctx context.Context, taskId string) (entity.TaskSeries, error)
+	UpdateSeries(ctx context.Context, taskId string, input entity.SeriesUpdate) error
+	StopSeries(ctx context.Context, taskId string) error
+	GenerateScheduled(ctx context.Context, lead time.Duration) (int, error)
+}
+
+type Sprint interface {
+	CreateSprint(ctx context.Context, projectId string, sprint entity.Sprint) (entity.Sprint, error)
+	GetSprint(ctx context.Context, id string) (entity.Sprint, error)
+	GetProjectSprints(ctx context.Context, projectId string) ([]entity.Sprint, error)
+	UpdateSprint(ctx context.Context, id string, input entity.Sprint) error
+	DeleteSprint(ctx context.Context, id string) error
+	StartSprint(ctx context.Context, id string) error
+	CloseSprint(ctx context.Context, id string, input entity.CloseSprint) (entity.CloseSprintResult, error)
+	AddSprintTasks(ctx context.Context, id string, taskIds []string) (int64, error)
+	RemoveSprintTask(ctx context.Context, id, taskId string) error
+	GetSprintTasks(ctx context.Context, id string) ([]entity.Task, error)
+	GetSprintReport(ctx context.Context, id string) (entity.SprintReport, error)
+}
+
+type Milestone interface {
+	CreateMilestone(ctx context.Context, projectId string, milestone entity.Milestone) (entity.Milestone, error)
+	GetMilestone(ctx context.Context, id string) (entity.MilestoneProgress, error)
+	GetProjectMilestones(ctx context.Context, projectId string, overdueOnly bool) ([]entity.MilestoneProgress, error)
+	UpdateMilestone(ctx context.Context, id string, input entity.Milestone) error
+	DeleteMilestone(ctx context.Context, id string) error
+	AddMilestoneTasks(ctx context.Context, id string, taskIds []string) (int64, error)
+	RemoveMilestoneTask(ctx context.Context, id, taskId string) error
+	GetMilestoneTasks(ctx context.Context, id string) ([]entity.Task, error)
+}
+
+type CustomField interface {
+	CreateField(ctx context.Context, projectId string, field entity.CustomField) (entity.CustomField, error)
+	GetFields(ctx context.Context, projectId string) ([]entity.CustomField, error)
+	UpdateField(ctx context.Context, id string, input entity.CustomFieldUpdate) error
+	DeleteField(ctx context.Context, id string) error
+	SetTaskFields(ctx context.Context, taskId string, values map[string]interface{}) error
+	GetProjectTasks(ctx context.Context, projectId string, query entity.TaskListQuery) ([]entity.Task, error)
+}
+
+type Organization interface {
+	CreateOrganization(ctx context.Context, ownerId string, organization entity.Organization) (entity.Organization, error)
+	ResolveOrganization(ctx context.Context, key string) (entity.Organization, error)
+	GetOrganization(ctx context.Context, id string) (entity.Organization, error)
+	UpdateOrganization(ctx context.Context, id, actorId string, input entity.Organization) error
+	DeleteOrganization(ctx context.Context, id, actorId string) error
+	GetUserOrganizations(ctx context.Context, userId string) ([]entity.UserOrganization, error)
+	GetRole(ctx context.Context, orgId, userId string) (string, error)
+	GetMembers(ctx context.Context, orgId string) ([]entity.Member, error)
+	SetMember(ctx context.Context, orgId, actorId, userId, role string) error
+	RemoveMember(ctx context.Context, orgId, actorId, userId string) error
+	Contains(ctx context.Context, orgId, resource, id string) (bool, error)
+}
+
+type Maintenance interface {
+	CheckIntegrity(ctx context.Context) ([]entity.Inconsistency, error)
+	FixIntegrity(ctx context.Context) ([]entity.IntegrityFix, error)
+	CreateAdmin(ctx context.Context, orgId string, user entity.User) (string, error)
+	Seed(ctx context.Context, orgId string, seed entity.Seed) error
+}
+
+type Health interface {
+	Go(ctx context.Context, name string, run func(context.Context))
+	Wait()
+	Drain()
+	GetReadiness(ctx context.Context) entity.Readiness
 }
 
 type Service struct {
 	User
 	Task
 	Project
+	Template
+	TaskTransfer
+	Calendar
+	Report
+	Dashboard
+	Notification
+	Email
+	Series
+	Sprint
+	Milestone
+	CustomField
+	Organization
+	Maintenance
+	Health
 }
 
-func NewService(repo *repository.Repository) *Service {
+func NewService(repo *repository.Repository, transport mailer.Transport, email EmailConfig) *Service {
+	notifications := NewNotificationService(repo.Notification)
+	fields := NewCustomFieldService(repo.CustomField, repo.Task, repo.User)
+	series := NewSeriesService(repo.Series, repo.Task, fields, notifications)
+
 	return &Service{
-		User:    NewUserService(repo.User),
-		Task:    NewTaskService(repo.Task),
-		Project: NewProjectService(repo.Project),
+		User:         NewUserService(repo.User, repo.Organization),
+		Task:         NewTaskService(repo.Task, notifications, series, fields),
+		Project:      NewProjectService(repo.Project),
+		Template:     NewTemplateService(repo.Project, repo.Task, repo.CustomField, repo.Milestone),
+		TaskTransfer: NewTaskTransferService(repo.Task, repo.User, fields),
+		Calendar:     NewCalendarService(repo.Calendar, repo.Task, repo.Project, repo.Milestone),
+		Report:       NewReportService(repo.Report),
+		Dashboard:    NewDashboardService(repo.Dashboard),
+		Notification: notifications,
+		Email:        NewEmailService(repo.Email, transport, email),
+		Series:       series,
+		Sprint:       NewSprintService(repo.Sprint),
+		Milestone:    NewMilestoneService(repo.Milestone),
+		CustomField:  fields,
+		Organization: NewOrganizationService(repo.Organization),
+		Maintenance:  NewMaintenanceService(repo),
+		Health:       NewHealthService(repo.Health, NewWorkers()),
 	}
 }
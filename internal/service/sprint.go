@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"time"
+)
+
+var (
+	ErrInvalidSprint   = errors.New("sprint needs a name and must end after it starts")
+	ErrSprintState     = errors.New("operation is not allowed in the sprint's state")
+	ErrTaskNotInSprint = errors.New("task is not in the sprint")
+)
+
+type SprintService struct {
+	repo repository.Sprint
+}
+
+func NewSprintService(repo repository.Sprint) *SprintService {
+	return &SprintService{repo: repo}
+}
+
+func (s SprintService) CreateSprint(ctx context.Context, projectId string, sprint entity.Sprint) (entity.Sprint, error) {
+	ctx, span := tracing.Start(ctx, "SprintService.CreateSprint")
+	defer span.End()
+
+	if err := validateSprint(sprint); err != nil {
+		return entity.Sprint{}, err
+	}
+
+	sprint.Project = projectId
+	sprint.State = entity.SprintPlanned
+	id, createdAt, err := s.repo.CreateSprint(ctx, sprint)
+	if err != nil {
+		return entity.Sprint{}, err
+	}
+
+	sprint.ID = id
+	sprint.CreatedAt = createdAt
+	return sprint, nil
+}
+
+func (s SprintService) GetSprint(ctx context.Context, id string) (entity.Sprint, error) {
+	ctx, span := tracing.Start(ctx, "SprintService.GetSprint")
+	defer span.End()
+
+	return s.repo.GetSprint(ctx, id)
+}
+
+func (s SprintService) GetProjectSprints(ctx context.Context, projectId string) ([]entity.Sprint, error) {
+	ctx, span := tracing.Start(ctx, "SprintService.GetProjectSprints")
+	defer span.End()
+
+	return s.repo.GetSprintsByProject(ctx, projectId)
+}
+
+// UpdateSprint changes the fields that are set on input. Closed sprints are
+// kept as they were.
+func (s SprintService) UpdateSprint(ctx context.Context, id string, input entity.Sprint) error {
+	ctx, span := tracing.Start(ctx, "SprintService.UpdateSprint")
+	defer span.End()
+
+	sprint, err := s.repo.GetSprint(ctx, id)
+	if err != nil {
+		return err
+	}
+	if sprint.State == entity.SprintClosed {
+		return ErrSprintState
+	}
+
+	sprint.Name = valueOr(input.Name, sprint.Name)
+	sprint.Goal = valueOr(input.Goal, sprint.Goal)
+	if !input.StartAt.IsZero() {
+		sprint.StartAt = input.StartAt
+	}
+	if !input.EndAt.IsZero() {
+		sprint.EndAt = input.EndAt
+	}
+	if input.Capacity > 0 {
+		sprint.Capacity = input.Capacity
+	}
+
+	if err := validateSprint(sprint); err != nil {
+		return err
+	}
+	return s.repo.UpdateSprint(ctx, sprint)
+}
+
+func (s SprintService) DeleteSprint(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "SprintService.DeleteSprint")
+	defer span.End()
+
+	return s.repo.DeleteSprint(ctx, id)
+}
+
+// StartSprint activates a planned sprint; a project runs one sprint at a
+// time.
+func (s SprintService) StartSprint(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "SprintService.StartSprint")
+	defer span.End()
+
+	sprint, err := s.repo.GetSprint(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	sprints, err := s.repo.GetSprintsByProject(ctx, sprint.Project)
+	if err != nil {
+		return err
+	}
+	for _, other := range sprints {
+		if other.State == entity.SprintActive {
+			return ErrSprintState
+		}
+	}
+
+	started, err := s.repo.StartSprint(ctx, id)
+	if err != nil {
+		return err
+	}
+	if started == 0 {
+		return ErrSprintState
+	}
+	return nil
+}
+
+// CloseSprint closes the active sprint and carries its unfinished tasks over
+// to input.Next, or to the project's next planned sprint when it is empty.
+func (s SprintService) CloseSprint(ctx context.Context, id string, input entity.CloseSprint) (entity.CloseSprintResult, error) {
+	ctx, span := tracing.Start(ctx, "SprintService.CloseSprint")
+	defer span.End()
+
+	sprint, err := s.repo.GetSprint(ctx, id)
+	if err != nil {
+		return entity.CloseSprintResult{}, err
+	}
+	if sprint.State != entity.SprintActive {
+		return entity.CloseSprintResult{}, ErrSprintState
+	}
+
+	next, err := s.nextSprint(ctx, sprint, input.Next)
+	if err != nil {
+		return entity.CloseSprintResult{}, err
+	}
+
+	moved, err := s.repo.CloseSprint(ctx, id, sql.NullString{String: next, Valid: next != ""})
+	if errors.Is(err, repository.ErrTaskNotFound) {
+		return entity.CloseSprintResult{}, ErrSprintState
+	}
+	if err != nil {
+		return entity.CloseSprintResult{}, err
+	}
+
+	return entity.CloseSprintResult{Next: next, Moved: moved}, nil
+}
+
+func (s SprintService) nextSprint(ctx context.Context, sprint entity.Sprint, requested string) (string, error) {
+	if requested != "" {
+		next, err := s.repo.GetSprint(ctx, requested)
+		if err != nil {
+			return "", err
+		}
+		if next.Project != sprint.Project || next.State != entity.SprintPlanned {
+			return "", ErrInvalidSprint
+		}
+		return next.ID, nil
+	}
+
+	sprints, err := s.repo.GetSprintsByProject(ctx, sprint.Project)
+	if err != nil {
+		return "", err
+	}
+	for _, other := range sprints {
+		if other.State == entity.SprintPlanned && other.ID != sprint.ID {
+			return other.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// AddSprintTasks moves tasks of the sprint's project into it and reports
+// how many were moved.
+func (s SprintService) AddSprintTasks(ctx context.Context, id string, taskIds []string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "SprintService.AddSprintTasks")
+	defer span.End()
+
+	sprint, err := s.repo.GetSprint(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if sprint.State == entity.SprintClosed {
+		return 0, ErrSprintState
+	}
+
+	return s.repo.AddSprintTasks(ctx, id, sprint.Project, taskIds)
+}
+
+func (s SprintService) RemoveSprintTask(ctx context.Context, id, taskId string) error {
+	ctx, span := tracing.Start(ctx, "SprintService.RemoveSprintTask")
+	defer span.End()
+
+	removed, err := s.repo.RemoveSprintTask(ctx, id, taskId)
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrTaskNotInSprint
+	}
+	return nil
+}
+
+func (s SprintService) GetSprintTasks(ctx context.Context, id string) ([]entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "SprintService.GetSprintTasks")
+	defer span.End()
+
+	return s.repo.GetSprintTasks(ctx, id)
+}
+
+// GetSprintReport reports on the sprint from its start until it closed, or
+// until now while it runs. A planned sprint is reported as if it started now.
+func (s SprintService) GetSprintReport(ctx context.Context, id string) (entity.SprintReport, error) {
+	ctx, span := tracing.Start(ctx, "SprintService.GetSprintReport")
+	defer span.End()
+
+	sprint, err := s.repo.GetSprint(ctx, id)
+	if err != nil {
+		return entity.SprintReport{}, err
+	}
+
+	now := time.Now()
+	start, end := now, now
+	if sprint.StartedAt.Valid {
+		start = sprint.StartedAt.Time
+	}
+	if sprint.ClosedAt.Valid {
+		end = sprint.ClosedAt.Time
+	}
+
+	report, err := s.repo.SprintReport(ctx, id, start, end)
+	if err != nil {
+		return entity.SprintReport{}, err
+	}
+
+	report.Sprint = sprint
+	if report.Committed > 0 {
+		report.CompletionRate = float64(report.CommittedCompleted) / float64(report.Committed)
+	}
+	return report, nil
+}
+
+func validateSprint(sprint entity.Sprint) error {
+	if sprint.Name == "" || sprint.StartAt.IsZero() || !sprint.EndAt.After(sprint.StartAt) || sprint.Capacity < 0 {
+		return ErrInvalidSprint
+	}
+	return nil
+}
@@ -1,55 +1,309 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/logging"
 	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
 	"time"
 )
 
 type TaskService struct {
-	repo repository.Task
+	repo     repository.Task
+	notifier Notifier
+	recurrer Recurrer
+	fields   FieldChecker
 }
 
-func (t TaskService) CreateTask(task entity.Task) (string, time.Time, error) {
-	return t.repo.CreateTask(task)
+func (t TaskService) CreateTask(ctx context.Context, task entity.Task) (string, time.Time, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.CreateTask")
+	defer span.End()
+
+	fields, err := t.fields.CheckTaskFields(ctx, task.Project, task.Fields)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	task.Fields = fields
+
+	id, createdAt, err := t.repo.CreateTask(ctx, task)
+	if err != nil {
+		return id, createdAt, err
+	}
+
+	t.notify(ctx, task.Assignee, entity.NotificationAssigned, id, fmt.Sprintf("You were assigned to task %q", task.Title))
+	return id, createdAt, nil
+}
+
+func (t TaskService) UpdateTask(ctx context.Context, id string, task entity.Task) error {
+	ctx, span := tracing.Start(ctx, "TaskService.UpdateTask")
+	defer span.End()
+
+	if !notifiesUpdate(task) {
+		return t.repo.UpdateTask(ctx, id, task)
+	}
+
+	previous, err := t.repo.GetTaskById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := t.repo.UpdateTask(ctx, id, task); err != nil {
+		return err
+	}
+
+	t.updated(ctx, id, previous, task)
+	return nil
+}
+
+// updated notifies the users concerned by an applied update and continues
+// the series of a task that got finished.
+func (t TaskService) updated(ctx context.Context, id string, previous, task entity.Task) {
+	assignee := previous.Assignee
+	if task.Assignee != "" && task.Assignee != previous.Assignee {
+		assignee = task.Assignee
+		t.notify(ctx, assignee, entity.NotificationAssigned, id, fmt.Sprintf("You were assigned to task %q", previous.Title))
+	}
+
+	if task.Status != "" && task.Status != previous.Status {
+		t.notify(ctx, assignee, entity.NotificationStatusChanged, id, fmt.Sprintf("Status of task %q changed from %q to %q", previous.Title, previous.Status, task.Status))
+	}
+
+	if task.FinishedAt.Valid && !previous.FinishedAt.Valid && previous.Series.Valid {
+		if err := t.recurrer.Recur(ctx, previous); err != nil {
+			logging.FromContext(ctx).Error("failed to create next occurrence", "task", id, "error", err)
+		}
+	}
 }
 
-func (t TaskService) UpdateTask(id string, task entity.Task) error {
-	return t.repo.UpdateTask(id, task)
+func (t TaskService) notify(ctx context.Context, userId, kind, taskId, message string) {
+	notify(ctx, t.notifier, userId, kind, taskId, message)
 }
 
-func (t TaskService) DeleteTask(id string) error {
-	return t.repo.DeleteTask(id)
+func (t TaskService) DeleteTask(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "TaskService.DeleteTask")
+	defer span.End()
+
+	return t.repo.DeleteTask(ctx, id)
 }
 
-func (t TaskService) GetTaskById(id string) (entity.Task, error) {
-	return t.repo.GetTaskById(id)
+func (t TaskService) GetTaskById(ctx context.Context, id string) (entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTaskById")
+	defer span.End()
+
+	return t.repo.GetTaskById(ctx, id)
 }
 
-func (t TaskService) GetAllTasks() ([]entity.Task, error) {
-	return t.repo.GetAllTasks()
+func (t TaskService) GetAllTasks(ctx context.Context, orgId string, includeArchived bool) ([]entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetAllTasks")
+	defer span.End()
+
+	return t.repo.GetAllTasks(ctx, orgId, includeArchived)
 }
 
-func (t TaskService) GetTaskByTitle(title string) (entity.Task, error) {
-	return t.repo.GetTaskByTitle(title)
+func (t TaskService) GetTaskByTitle(ctx context.Context, orgId, title string) (entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTaskByTitle")
+	defer span.End()
+
+	return t.repo.GetTaskByTitle(ctx, orgId, title)
+}
+
+func (t TaskService) GetTaskByStatus(ctx context.Context, orgId, status string, includeArchived bool) ([]entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTaskByStatus")
+	defer span.End()
+
+	return t.repo.GetTaskByStatus(ctx, orgId, status, includeArchived)
+}
+
+func (t TaskService) GetTaskByPriority(ctx context.Context, orgId, priority string, includeArchived bool) ([]entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTaskByPriority")
+	defer span.End()
+
+	return t.repo.GetTaskByPriority(ctx, orgId, priority, includeArchived)
+}
+
+func (t TaskService) GetTasksByUserId(ctx context.Context, orgId, userId, column string, includeArchived bool) ([]entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTasksByUserId")
+	defer span.End()
+
+	return t.repo.GetTasksByUserId(ctx, orgId, userId, column, includeArchived)
 }
 
-func (t TaskService) GetTaskByStatus(status string) ([]entity.Task, error) {
-	return t.repo.GetTaskByStatus(status)
+func (t TaskService) GetTasksByProjectId(ctx context.Context, projectId string) ([]entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTasksByProjectId")
+	defer span.End()
+
+	return t.repo.GetTasksByProjectId(ctx, projectId)
+}
+
+func (t TaskService) GetTasksByProjectIds(ctx context.Context, ids []string) ([]entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTasksByProjectIds")
+	defer span.End()
+
+	return t.repo.GetTasksByProjectIds(ctx, ids)
+}
+
+func (t TaskService) GetTasksByAssignees(ctx context.Context, orgId string, ids []string, includeArchived bool) ([]entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTasksByAssignees")
+	defer span.End()
+
+	return t.repo.GetTasksByAssignees(ctx, orgId, ids, includeArchived)
+}
+
+func (t TaskService) BulkTasks(ctx context.Context, items []entity.BulkTaskItem, atomic bool) ([]entity.BulkTaskResult, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.BulkTasks")
+	defer span.End()
+
+	valid := make([]entity.BulkTaskItem, 0, len(items))
+	index := make([]int, 0, len(items))
+	rejected := make([]entity.BulkTaskResult, 0)
+
+	for i, item := range items {
+		if err := t.checkBulkItem(ctx, &item); err != nil {
+			result := entity.BulkTaskResult{Index: i, Op: item.Op, ID: item.ID, Error: err.Error()}
+			if atomic {
+				return []entity.BulkTaskResult{result}, fmt.Errorf("item %d: %v", i, err)
+			}
+			rejected = append(rejected, result)
+			continue
+		}
+		valid = append(valid, item)
+		index = append(index, i)
+	}
+
+	previous := make(map[int]entity.Task)
+	for i, item := range valid {
+		if item.Op != entity.BulkUpdate || !notifiesUpdate(item.Task) {
+			continue
+		}
+		task, err := t.repo.GetTaskById(ctx, item.ID)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to read task before bulk update", "task", item.ID, "error", err)
+			continue
+		}
+		previous[i] = task
+	}
+
+	results, err := t.repo.BulkTasks(ctx, valid, atomic)
+	if err == nil {
+		t.bulkApplied(ctx, valid, results, previous)
+	}
+	for i := range results {
+		results[i].Index = index[results[i].Index]
+	}
+	if err != nil {
+		return results, err
+	}
+
+	return mergeBulkResults(results, rejected), nil
+}
+
+// bulkApplied notifies about the applied items of a batch as CreateTask and
+// UpdateTask do. Updates whose task could not be read beforehand are left
+// out.
+func (t TaskService) bulkApplied(ctx context.Context, items []entity.BulkTaskItem, results []entity.BulkTaskResult, previous map[int]entity.Task) {
+	for _, result := range results {
+		if result.Error != "" {
+			continue
+		}
+
+		item := items[result.Index]
+		switch item.Op {
+		case entity.BulkCreate:
+			t.notify(ctx, item.Task.Assignee, entity.NotificationAssigned, result.ID, fmt.Sprintf("You were assigned to task %q", item.Task.Title))
+		case entity.BulkUpdate:
+			if task, ok := previous[result.Index]; ok {
+				t.updated(ctx, item.ID, task, item.Task)
+			}
+		}
+	}
+}
+
+func (t TaskService) ReassignTasks(ctx context.Context, orgId, from string, input entity.ReassignTasks) (int64, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.ReassignTasks")
+	defer span.End()
+
+	if input.Assignee == "" {
+		return 0, errors.New("assignee is required")
+	}
+
+	count, err := t.repo.ReassignTasks(ctx, orgId, from, input)
+	if err != nil {
+		return 0, err
+	}
+
+	if count > 0 {
+		t.notify(ctx, input.Assignee, entity.NotificationAssigned, "", fmt.Sprintf("%d tasks were reassigned to you", count))
+	}
+	return count, nil
+}
+
+// checkBulkItem validates the item and, for a new task, replaces its custom
+// field values with the checked ones as CreateTask does.
+func (t TaskService) checkBulkItem(ctx context.Context, item *entity.BulkTaskItem) error {
+	if err := validateBulkItem(*item); err != nil {
+		return err
+	}
+	if item.Op != entity.BulkCreate {
+		return nil
+	}
+
+	fields, err := t.fields.CheckTaskFields(ctx, item.Task.Project, item.Task.Fields)
+	if err != nil {
+		return err
+	}
+	item.Task.Fields = fields
+	return nil
 }
 
-func (t TaskService) GetTaskByPriority(priority string) ([]entity.Task, error) {
-	return t.repo.GetTaskByPriority(priority)
+func validateBulkItem(item entity.BulkTaskItem) error {
+	switch item.Op {
+	case entity.BulkCreate:
+		task := item.Task
+		if task.Title == "" || task.Description == "" || task.Priority == "" || task.Status == "" || task.Assignee == "" || task.Project == "" {
+			return errors.New("all fields are required")
+		}
+	case entity.BulkUpdate:
+		if item.ID == "" {
+			return errors.New("id is required")
+		}
+		task := item.Task
+		if task.Title == "" && task.Description == "" && task.Priority == "" && task.Status == "" && task.Assignee == "" && task.Project == "" && !task.FinishedAt.Valid && !task.DueAt.Valid {
+			return errors.New("nothing to update")
+		}
+	case entity.BulkDelete:
+		if item.ID == "" {
+			return errors.New("id is required")
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", item.Op)
+	}
+	return nil
 }
 
-func (t TaskService) GetTasksByUserId(userId, column string) ([]entity.Task, error) {
-	return t.repo.GetTasksByUserId(userId, column)
+// notifiesUpdate tells whether an update may need notifications or a new
+// occurrence, and so the task as it was before.
+func notifiesUpdate(task entity.Task) bool {
+	return task.Assignee != "" || task.Status != "" || task.FinishedAt.Valid
 }
 
-func (t TaskService) GetTasksByProjectId(projectId string) ([]entity.Task, error) {
-	return t.repo.GetTasksByProjectId(projectId)
+func mergeBulkResults(applied, rejected []entity.BulkTaskResult) []entity.BulkTaskResult {
+	merged := make([]entity.BulkTaskResult, 0, len(applied)+len(rejected))
+	i, j := 0, 0
+	for i < len(applied) || j < len(rejected) {
+		if j == len(rejected) || (i < len(applied) && applied[i].Index < rejected[j].Index) {
+			merged = append(merged, applied[i])
+			i++
+		} else {
+			merged = append(merged, rejected[j])
+			j++
+		}
+	}
+	return merged
 }
 
-func NewTaskService(repo repository.Task) *TaskService {
-	return &TaskService{repo: repo}
+func NewTaskService(repo repository.Task, notifier Notifier, recurrer Recurrer, fields FieldChecker) *TaskService {
+	return &TaskService{repo: repo, notifier: notifier, recurrer: recurrer, fields: fields}
 }
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/logging"
+	"github.com/Aytya/projects-manager-HL/internal/rank"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+)
+
+var ErrInvalidMove = errors.New("invalid move")
+
+// moveAttempts bounds the retries when concurrent moves pick the same rank.
+const moveAttempts = 3
+
+// MoveTask places the task between its new neighbors, optionally in another
+// status column. Only the moved task gets a new rank; the column is
+// rebalanced when keys grew too long.
+func (t TaskService) MoveTask(ctx context.Context, id string, input entity.MoveTask) error {
+	ctx, span := tracing.Start(ctx, "TaskService.MoveTask")
+	defer span.End()
+
+	task, err := t.repo.GetTaskById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	status := task.Status
+	if input.Status != "" {
+		status = input.Status
+	}
+
+	for attempt := 0; attempt < moveAttempts; attempt++ {
+		column, err := t.column(ctx, task.Project, status, id)
+		if err != nil {
+			return err
+		}
+
+		prev, next, err := neighbors(column, input)
+		if err != nil {
+			return err
+		}
+
+		key, err := rank.Between(prev, next)
+		if err != nil {
+			return err
+		}
+
+		err = t.repo.MoveTask(ctx, id, status, key)
+		if errors.Is(err, repository.ErrRankTaken) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(key) > rank.MaxLength {
+			if err := t.repo.RebalanceColumn(ctx, task.Project, status); err != nil {
+				logging.FromContext(ctx).Error("failed to rebalance column", "project", task.Project, "status", status, "error", err)
+			}
+		}
+		if status != task.Status {
+			t.notify(ctx, task.Assignee, entity.NotificationStatusChanged, id, fmt.Sprintf("Status of task %q changed from %q to %q", task.Title, task.Status, status))
+		}
+		return nil
+	}
+
+	return repository.ErrRankTaken
+}
+
+// column returns the column without the moved task, rebalancing it first if
+// tasks appended to its bottom made keys too long.
+func (t TaskService) column(ctx context.Context, projectId, status, moved string) ([]entity.TaskRank, error) {
+	column, err := t.repo.GetColumn(ctx, projectId, status)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, task := range column {
+		if len(task.Rank.String) > rank.MaxLength {
+			if err := t.repo.RebalanceColumn(ctx, projectId, status); err != nil {
+				return nil, err
+			}
+			if column, err = t.repo.GetColumn(ctx, projectId, status); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	others := make([]entity.TaskRank, 0, len(column))
+	for _, task := range column {
+		if task.ID != moved {
+			others = append(others, task)
+		}
+	}
+	return others, nil
+}
+
+// neighbors finds the ranks the moved task goes between.
+func neighbors(column []entity.TaskRank, input entity.MoveTask) (string, string, error) {
+	index := func(id string) int {
+		for i, task := range column {
+			if task.ID == id {
+				return i
+			}
+		}
+		return -1
+	}
+	rankAt := func(i int) string {
+		if i < 0 || i >= len(column) {
+			return ""
+		}
+		return column[i].Rank.String
+	}
+
+	after, before := index(input.After), index(input.Before)
+	if (input.After != "" && after < 0) || (input.Before != "" && before < 0) {
+		return "", "", fmt.Errorf("%w: neighbor is not in the column", ErrInvalidMove)
+	}
+
+	switch {
+	case input.After != "" && input.Before != "":
+		if before != after+1 {
+			return "", "", fmt.Errorf("%w: neighbors are not next to each other", ErrInvalidMove)
+		}
+		return rankAt(after), rankAt(before), nil
+	case input.After != "":
+		return rankAt(after), rankAt(after + 1), nil
+	case input.Before != "":
+		return rankAt(before - 1), rankAt(before), nil
+	default:
+		return rankAt(len(column) - 1), "", nil
+	}
+}
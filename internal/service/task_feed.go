@@ -0,0 +1,76 @@
+package service
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"sync"
+)
+
+// taskFeedBuffer is how many events a subscriber may fall behind before it
+// is dropped.
+const taskFeedBuffer = 64
+
+// TaskFeed fans task events out to the subscribers of an organization.
+type TaskFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan entity.TaskEvent]string
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+func NewTaskFeed() *TaskFeed {
+	return &TaskFeed{subscribers: make(map[chan entity.TaskEvent]string), done: make(chan struct{})}
+}
+
+// Done is closed when the feed is closed, and subscribers should stop
+// watching.
+func (f *TaskFeed) Done() <-chan struct{} {
+	return f.done
+}
+
+// Close tells the subscribers to stop watching, so that streams held open
+// by them do not keep the server from shutting down.
+func (f *TaskFeed) Close() {
+	f.closeOnce.Do(func() { close(f.done) })
+}
+
+// Subscribe returns the events of the organization and a function that
+// ends the subscription. The channel is closed when the subscription ends,
+// also when the subscriber falls too far behind.
+func (f *TaskFeed) Subscribe(orgId string) (<-chan entity.TaskEvent, func()) {
+	events := make(chan entity.TaskEvent, taskFeedBuffer)
+
+	f.mu.Lock()
+	f.subscribers[events] = orgId
+	f.mu.Unlock()
+
+	return events, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.remove(events)
+	}
+}
+
+// Publish never blocks on a slow subscriber.
+func (f *TaskFeed) Publish(event entity.TaskEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for events, orgId := range f.subscribers {
+		if event.Organization != orgId {
+			continue
+		}
+
+		select {
+		case events <- event:
+		default:
+			f.remove(events)
+		}
+	}
+}
+
+func (f *TaskFeed) remove(events chan entity.TaskEvent) {
+	if _, ok := f.subscribers[events]; ok {
+		delete(f.subscribers, events)
+		close(events)
+	}
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format, expected csv or xlsx")
+	ErrInvalidFile       = errors.New("file cannot be parsed")
+)
+
+var exportHeader = []string{"id", "title", "description", "priority", "status", "assignee", "assignee_name", "assignee_email", "created_at", "finished_at", "due_at"}
+
+// importFields are the task fields an import row can fill. The first row of
+// the file is the header; its cells are matched against these names unless a
+// column mapping says otherwise.
+var importFields = []struct {
+	name     string
+	required bool
+}{
+	{"title", true},
+	{"description", true},
+	{"priority", true},
+	{"status", true},
+	{"assignee_email", true},
+	{"finished_at", false},
+	{"due_at", false},
+}
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+type TaskTransferService struct {
+	repo   repository.Task
+	users  repository.User
+	fields FieldChecker
+}
+
+func NewTaskTransferService(repo repository.Task, users repository.User, fields FieldChecker) *TaskTransferService {
+	return &TaskTransferService{repo: repo, users: users, fields: fields}
+}
+
+func (t TaskTransferService) ExportTasks(ctx context.Context, projectId, format string, w io.Writer) error {
+	ctx, span := tracing.Start(ctx, "TaskTransferService.ExportTasks")
+	defer span.End()
+
+	switch format {
+	case entity.FormatCSV:
+		return t.exportCSV(ctx, projectId, w)
+	case entity.FormatXLSX:
+		return t.exportXLSX(ctx, projectId, w)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+func (t TaskTransferService) exportCSV(ctx context.Context, projectId string, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportHeader); err != nil {
+		return err
+	}
+
+	err := t.repo.StreamTasksByProjectId(ctx, projectId, func(task entity.TaskExport) error {
+		if err := writer.Write(exportRecord(task)); err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportXLSX is not streamed like the CSV export: rows go through a stream
+// writer to keep the sheet small, but the workbook is only written to w once
+// every task was read, so it is held in memory until then.
+func (t TaskTransferService) exportXLSX(ctx context.Context, projectId string, w io.Writer) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	sheet := file.GetSheetName(0)
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	row := 1
+	writeRow := func(record []string) error {
+		cells := make([]interface{}, len(record))
+		for i, value := range record {
+			cells[i] = value
+		}
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+		row++
+		return stream.SetRow(cell, cells)
+	}
+
+	if err := writeRow(exportHeader); err != nil {
+		return err
+	}
+
+	err = t.repo.StreamTasksByProjectId(ctx, projectId, func(task entity.TaskExport) error {
+		return writeRow(exportRecord(task))
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := stream.Flush(); err != nil {
+		return err
+	}
+
+	return file.Write(w)
+}
+
+func exportRecord(task entity.TaskExport) []string {
+	record := []string{
+		task.ID,
+		task.Title,
+		task.Description,
+		task.Priority,
+		task.Status,
+		task.Assignee,
+		task.AssigneeName,
+		task.AssigneeEmail,
+		task.CreatedAt.Format(time.RFC3339),
+		formatNullTime(task.FinishedAt),
+		formatNullTime(task.DueAt),
+	}
+	for i, value := range record {
+		record[i] = escapeFormula(value)
+	}
+	return record
+}
+
+// escapeFormula prefixes values that a spreadsheet would read as a formula
+// with a quote so they are shown as text. A leading tab or carriage return
+// is escaped too, as some spreadsheets skip it and read the formula after
+// it.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func formatNullTime(value sql.NullTime) string {
+	if !value.Valid {
+		return ""
+	}
+	return value.Time.Format(time.RFC3339)
+}
+
+// ImportTasks validates every row of the file before anything is written.
+// If one row is invalid nothing is imported and the report lists each
+// problem; otherwise all rows are inserted in a single transaction unless
+// dryRun is set.
+func (t TaskTransferService) ImportTasks(ctx context.Context, orgId, projectId, format string, r io.Reader, columns map[string]string, dryRun bool) (entity.TaskImportReport, error) {
+	ctx, span := tracing.Start(ctx, "TaskTransferService.ImportTasks")
+	defer span.End()
+
+	report := entity.TaskImportReport{DryRun: dryRun}
+
+	records, err := readRecords(format, r)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedFormat) {
+			return report, err
+		}
+		return report, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	if len(records) == 0 {
+		report.Errors = append(report.Errors, entity.ImportRowError{Row: 1, Error: "file is empty"})
+		return report, nil
+	}
+
+	// Imported tasks carry no custom field values.
+	if _, err := t.fields.CheckTaskFields(ctx, projectId, nil); err != nil {
+		if !errors.Is(err, ErrInvalidFieldValue) {
+			return report, err
+		}
+		report.Errors = append(report.Errors, entity.ImportRowError{Row: 1, Error: err.Error()})
+		return report, nil
+	}
+
+	index, headerErrors := mapColumns(records[0], columns)
+	if len(headerErrors) > 0 {
+		report.Errors = headerErrors
+		return report, nil
+	}
+
+	assignees := make(map[string]string)
+	tasks := make([]entity.Task, 0, len(records)-1)
+	for i, record := range records[1:] {
+		if isBlank(record) {
+			continue
+		}
+		report.Rows++
+
+		task, rowErrors := t.parseRow(ctx, orgId, i+2, record, index, assignees)
+		if len(rowErrors) > 0 {
+			report.Errors = append(report.Errors, rowErrors...)
+			continue
+		}
+		task.Project = projectId
+		tasks = append(tasks, task)
+	}
+
+	if len(report.Errors) > 0 || dryRun {
+		return report, nil
+	}
+
+	ids, err := t.repo.CreateTasks(ctx, tasks)
+	if err != nil {
+		return report, err
+	}
+
+	report.Imported = len(ids)
+	report.IDs = ids
+	return report, nil
+}
+
+func (t TaskTransferService) parseRow(ctx context.Context, orgId string, row int, record []string, index map[string]int, assignees map[string]string) (entity.Task, []entity.ImportRowError) {
+	var rowErrors []entity.ImportRowError
+	value := func(field string) string {
+		i, ok := index[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	for _, field := range importFields {
+		if field.required && value(field.name) == "" {
+			rowErrors = append(rowErrors, entity.ImportRowError{Row: row, Column: field.name, Error: "value is required"})
+		}
+	}
+
+	task := entity.Task{
+		Title:       value("title"),
+		Description: value("description"),
+		Priority:    value("priority"),
+		Status:      value("status"),
+	}
+
+	if email := value("assignee_email"); email != "" {
+		id, err := t.resolveAssignee(ctx, orgId, email, assignees)
+		if err != nil {
+			rowErrors = append(rowErrors, entity.ImportRowError{Row: row, Column: "assignee_email", Error: err.Error()})
+		}
+		task.Assignee = id
+	}
+
+	if finishedAt := value("finished_at"); finishedAt != "" {
+		parsed, err := parseDate(finishedAt)
+		if err != nil {
+			rowErrors = append(rowErrors, entity.ImportRowError{Row: row, Column: "finished_at", Error: err.Error()})
+		}
+		task.FinishedAt = sql.NullTime{Time: parsed, Valid: err == nil}
+	}
+
+	if dueAt := value("due_at"); dueAt != "" {
+		parsed, err := parseDate(dueAt)
+		if err != nil {
+			rowErrors = append(rowErrors, entity.ImportRowError{Row: row, Column: "due_at", Error: err.Error()})
+		}
+		task.DueAt = sql.NullTime{Time: parsed, Valid: err == nil}
+	}
+
+	return task, rowErrors
+}
+
+func (t TaskTransferService) resolveAssignee(ctx context.Context, orgId, email string, cache map[string]string) (string, error) {
+	if id, ok := cache[email]; ok {
+		return id, nil
+	}
+
+	user, err := t.users.GetUserByEmail(ctx, orgId, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("no user with email %s", email)
+		}
+		return "", err
+	}
+
+	cache[email] = user.ID
+	return user.ID, nil
+}
+
+func readRecords(format string, r io.Reader) ([][]string, error) {
+	switch format {
+	case entity.FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return reader.ReadAll()
+	case entity.FormatXLSX:
+		file, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return file.GetRows(file.GetSheetName(0))
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// mapColumns resolves header cells to import fields. columns maps a header
+// as it appears in the file to a field name and takes precedence over the
+// header itself.
+func mapColumns(header []string, columns map[string]string) (map[string]int, []entity.ImportRowError) {
+	index := make(map[string]int)
+	for i, cell := range header {
+		name := strings.TrimSpace(cell)
+		field, ok := columns[name]
+		if !ok {
+			field = strings.ReplaceAll(strings.ToLower(name), " ", "_")
+		}
+		for _, known := range importFields {
+			if known.name == field {
+				index[field] = i
+			}
+		}
+	}
+
+	var headerErrors []entity.ImportRowError
+	for _, field := range importFields {
+		if _, ok := index[field.name]; field.required && !ok {
+			headerErrors = append(headerErrors, entity.ImportRowError{Row: 1, Column: field.name, Error: "column is missing"})
+		}
+	}
+
+	return index, headerErrors
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func isBlank(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
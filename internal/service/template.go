@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"time"
+)
+
+type TemplateService struct {
+	projects   repository.Project
+	tasks      repository.Task
+	fields     repository.CustomField
+	milestones repository.Milestone
+}
+
+func NewTemplateService(projects repository.Project, tasks repository.Task, fields repository.CustomField, milestones repository.Milestone) *TemplateService {
+	return &TemplateService{projects: projects, tasks: tasks, fields: fields, milestones: milestones}
+}
+
+func (s TemplateService) GetTemplates(ctx context.Context, orgId string) ([]entity.Project, error) {
+	ctx, span := tracing.Start(ctx, "TemplateService.GetTemplates")
+	defer span.End()
+
+	return s.projects.GetTemplates(ctx, orgId)
+}
+
+// templateStart is the start of every saved template. Due dates of its tasks
+// are kept as distances from it rather than as real deadlines.
+var templateStart = time.Unix(0, 0).UTC()
+
+// SaveAsTemplate copies the project into a template. The tasks are given to
+// the template's manager and their due dates are counted from templateStart.
+func (s TemplateService) SaveAsTemplate(ctx context.Context, id, title string) (entity.Project, error) {
+	ctx, span := tracing.Start(ctx, "TemplateService.SaveAsTemplate")
+	defer span.End()
+
+	source, err := s.projects.GetProjectById(ctx, id)
+	if err != nil {
+		return entity.Project{}, err
+	}
+
+	input := entity.CloneProject{Title: valueOr(title, source.Title+" template"), StartAt: templateStart}
+	return s.clone(ctx, source, input, true)
+}
+
+// CloneProject copies the project, or instantiates the template, starting
+// today unless the input says otherwise. Template tasks not mapped by the
+// input go to the manager of the new project.
+func (s TemplateService) CloneProject(ctx context.Context, id string, input entity.CloneProject) (entity.Project, error) {
+	ctx, span := tracing.Start(ctx, "TemplateService.CloneProject")
+	defer span.End()
+
+	source, err := s.projects.GetProjectById(ctx, id)
+	if err != nil {
+		return entity.Project{}, err
+	}
+
+	if input.StartAt.IsZero() {
+		input.StartAt = time.Now()
+	}
+	if input.Title == "" {
+		input.Title = source.Title
+		if !source.Template {
+			input.Title += " (copy)"
+		}
+	}
+
+	return s.clone(ctx, source, input, false)
+}
+
+func (s TemplateService) clone(ctx context.Context, source entity.Project, input entity.CloneProject, template bool) (entity.Project, error) {
+	fields, err := s.fields.GetFields(ctx, source.ID)
+	if err != nil {
+		return entity.Project{}, err
+	}
+
+	milestones, err := s.milestones.GetMilestonesByProject(ctx, source.ID)
+	if err != nil {
+		return entity.Project{}, err
+	}
+
+	tasks, err := s.tasks.GetTasksByProjectId(ctx, source.ID)
+	if err != nil {
+		return entity.Project{}, err
+	}
+
+	start := startOfDay(input.StartAt)
+	origin := startOfDay(projectStart(source))
+	shift := func(t time.Time) time.Time {
+		return start.Add(t.Sub(origin))
+	}
+
+	clone := entity.ProjectCopy{
+		Project: entity.Project{
+			Title:        input.Title,
+			Description:  source.Description,
+			Manager:      valueOr(input.Manager, source.Manager),
+			Organization: source.Organization,
+			StartAt:      sql.NullTime{Time: start, Valid: true},
+			Template:     template,
+		},
+		Fields: fields,
+	}
+
+	for _, milestone := range milestones {
+		milestone.TargetAt = shift(milestone.TargetAt)
+		clone.Milestones = append(clone.Milestones, milestone.Milestone)
+	}
+
+	// Tasks come in board order and are inserted without a rank, so the
+	// database appends them to the one column they all start in.
+	for _, task := range tasks {
+		task.Status = valueOr(input.Status, entity.TaskStatusNotStarted)
+		task.FinishedAt = sql.NullTime{}
+		task.Rank = sql.NullString{}
+		if assignee, ok := input.Assignees[task.Assignee]; ok {
+			task.Assignee = assignee
+		} else if template || source.Template {
+			task.Assignee = clone.Project.Manager
+		}
+		if task.DueAt.Valid {
+			task.DueAt.Time = shift(task.DueAt.Time)
+		}
+		clone.Tasks = append(clone.Tasks, task)
+	}
+
+	id, err := s.projects.CloneProject(ctx, clone)
+	if err != nil {
+		return entity.Project{}, err
+	}
+
+	return s.projects.GetProjectById(ctx, id)
+}
+
+// projectStart is the day the project's schedule is counted from.
+func projectStart(project entity.Project) time.Time {
+	if project.StartAt.Valid {
+		return project.StartAt.Time
+	}
+	return project.CreatedAt
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -1,43 +1,153 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
 	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
 	"time"
 )
 
+var ErrUserExists = repository.ErrUserExists
+
 type UserService struct {
 	repo repository.User
+	orgs repository.Organization
 }
 
-func (u UserService) GetAllUsers() ([]entity.User, error) {
-	return u.repo.GetAllUsers()
+func (u UserService) GetAllUsers(ctx context.Context, orgId string) ([]entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsers")
+	defer span.End()
+
+	return u.repo.GetAllUsers(ctx, orgId)
 }
 
-func (u UserService) CreateUser(user entity.User) (string, time.Time, error) {
-	return u.repo.CreateUser(user)
+func (u UserService) CreateUser(ctx context.Context, orgId string, user entity.User) (string, time.Time, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
+	return u.repo.CreateUser(ctx, orgId, user)
 }
 
-func (u UserService) UpdateUser(id string, user entity.User) error {
-	return u.repo.UpdateUser(id, user)
+// UpdateUser edits a profile. Users edit their own; admins of the
+// organization edit the profiles of users that belong to no other
+// organization, since the profile is shared by all of them. An empty actor
+// is a trusted caller such as a gRPC client with the server token.
+func (u UserService) UpdateUser(ctx context.Context, orgId, actorId, id string, user entity.User) error {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	if actorId != id {
+		if err := u.requireAdmin(ctx, orgId, actorId); err != nil {
+			return err
+		}
+
+		organizations, err := u.orgs.GetUserOrganizations(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(organizations) > 1 {
+			return fmt.Errorf("%w: the user belongs to other organizations", ErrForbidden)
+		}
+	}
+
+	return u.repo.UpdateUser(ctx, id, user)
 }
 
-func (u UserService) DeleteUser(id string) error {
-	return u.repo.DeleteUser(id)
+// DeleteUser deletes a user of the organization, who may be the actor or,
+// for admins, anyone. A user that belongs to other organizations too is
+// only taken out of this one, and deleted reports whether the account was
+// deleted.
+func (u UserService) DeleteUser(ctx context.Context, orgId, actorId, id string) (deleted bool, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	if actorId != id {
+		if err := u.requireAdmin(ctx, orgId, actorId); err != nil {
+			return false, err
+		}
+	}
+
+	membership, err := u.orgs.GetMembership(ctx, orgId, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrNotMember
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if membership.Role == entity.OrgRoleOwner {
+		owners, err := u.orgs.CountOwners(ctx, orgId)
+		if err != nil {
+			return false, err
+		}
+		if owners <= 1 {
+			return false, ErrLastOwner
+		}
+	}
+
+	organizations, err := u.orgs.GetUserOrganizations(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if len(organizations) > 1 {
+		_, err := u.orgs.RemoveMember(ctx, orgId, id)
+		return false, err
+	}
+
+	return true, u.repo.DeleteUser(ctx, id)
 }
 
-func (u UserService) GetUserById(id string) (entity.User, error) {
-	return u.repo.GetUserById(id)
+func (u UserService) requireAdmin(ctx context.Context, orgId, actorId string) error {
+	if actorId == "" {
+		return nil
+	}
+
+	membership, err := u.orgs.GetMembership(ctx, orgId, actorId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotMember
+	}
+	if err != nil {
+		return err
+	}
+
+	if roleRank(membership.Role) < roleRank(entity.OrgRoleAdmin) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (u UserService) GetUserById(ctx context.Context, id string) (entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserById")
+	defer span.End()
+
+	return u.repo.GetUserById(ctx, id)
 }
 
-func (u UserService) GetUserByName(name string) (entity.User, error) {
-	return u.repo.GetUserByName(name)
+func (u UserService) GetUsersByIds(ctx context.Context, orgId string, ids []string) ([]entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUsersByIds")
+	defer span.End()
+
+	return u.repo.GetUsersByIds(ctx, orgId, ids)
 }
 
-func (u UserService) GetUserByEmail(email string) (entity.User, error) {
-	return u.repo.GetUserByEmail(email)
+func (u UserService) GetUserByName(ctx context.Context, orgId, name string) (entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByName")
+	defer span.End()
+
+	return u.repo.GetUserByName(ctx, orgId, name)
+}
+
+func (u UserService) GetUserByEmail(ctx context.Context, orgId, email string) (entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByEmail")
+	defer span.End()
+
+	return u.repo.GetUserByEmail(ctx, orgId, email)
 }
 
-func NewUserService(repo repository.User) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo repository.User, orgs repository.Organization) *UserService {
+	return &UserService{repo: repo, orgs: orgs}
 }
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// GetCalendar returns the iCalendar feed of a calendar token.
+func (c *Client) GetCalendar(ctx context.Context, token string) ([]byte, error) {
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: "/calendar/" + escape(token)})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+func (c *Client) RevokeCalendar(ctx context.Context, token string) error {
+	return c.delete(ctx, "/calendar/"+escape(strings.TrimSuffix(token, ".ics")), nil)
+}
@@ -0,0 +1,325 @@
+// Package client is a typed Go client for the Projects-Manager HTTP API.
+//
+//	c, err := client.New("http://localhost:8080", client.WithOrganization("acme"), client.WithUser(userId))
+//	tasks, err := c.ListTasks(ctx, client.ListOptions{})
+//
+// Every method takes a context. Idempotent calls (GET, PUT and DELETE) are
+// retried with exponential backoff when the server is unavailable; errors
+// returned by the server are *Error values that match ErrNotFound,
+// ErrConflict and the other sentinel errors with errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	userIdHeader       = "X-User-ID"
+	organizationHeader = "X-Organization"
+)
+
+// Retry configures how idempotent calls are retried. Attempts counts the
+// first try; a delay doubles from MinBackoff up to MaxBackoff.
+type Retry struct {
+	Attempts   int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+var DefaultRetry = Retry{Attempts: 3, MinBackoff: 100 * time.Millisecond, MaxBackoff: 2 * time.Second}
+
+type Client struct {
+	baseURL      string
+	http         *http.Client
+	token        string
+	organization string
+	user         string
+	retry        Retry
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.http = httpClient
+	}
+}
+
+// WithToken sends token as a bearer token with every request.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithOrganization selects the organization, by id or slug, requests act in.
+// Without it the server picks the organization from the host name or falls
+// back to the default one.
+func WithOrganization(key string) Option {
+	return func(c *Client) {
+		c.organization = key
+	}
+}
+
+// WithUser identifies the caller; the /me routes and a few others need it.
+func WithUser(id string) Option {
+	return func(c *Client) {
+		c.user = id
+	}
+}
+
+// WithRetry replaces DefaultRetry. Attempts of 1 turns retries off.
+func WithRetry(retry Retry) Option {
+	return func(c *Client) {
+		c.retry = retry
+	}
+}
+
+func New(baseURL string, options ...Option) (*Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme and host are required", baseURL)
+	}
+
+	c := &Client{baseURL: base.String(), http: http.DefaultClient, retry: DefaultRetry}
+	for _, option := range options {
+		option(c)
+	}
+	if c.retry.Attempts < 1 {
+		c.retry.Attempts = 1
+	}
+	return c, nil
+}
+
+// InOrganization returns a copy of the client that acts in another
+// organization.
+func (c *Client) InOrganization(key string) *Client {
+	clone := *c
+	clone.organization = key
+	return &clone
+}
+
+// AsUser returns a copy of the client that acts on behalf of another user.
+func (c *Client) AsUser(id string) *Client {
+	clone := *c
+	clone.user = id
+	return &clone
+}
+
+// request is one API call. Query, body and out are optional; a body of type
+// *multipartBody is sent as is, any other body is encoded as JSON.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	out    interface{}
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	return c.do(ctx, request{method: http.MethodGet, path: path, query: query, out: out})
+}
+
+func (c *Client) post(ctx context.Context, path string, body, out interface{}) error {
+	return c.do(ctx, request{method: http.MethodPost, path: path, body: body, out: out})
+}
+
+func (c *Client) put(ctx context.Context, path string, body, out interface{}) error {
+	return c.do(ctx, request{method: http.MethodPut, path: path, body: body, out: out})
+}
+
+func (c *Client) delete(ctx context.Context, path string, out interface{}) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: path, out: out})
+}
+
+func (c *Client) do(ctx context.Context, r request) error {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if r.out == nil {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(r.out); err != nil {
+		return fmt.Errorf("decoding response of %s %s: %w", r.method, r.path, err)
+	}
+	return nil
+}
+
+// send performs the request, retrying idempotent ones, and turns error
+// responses into *Error. The caller closes the body of the response.
+func (c *Client) send(ctx context.Context, r request) (*http.Response, error) {
+	var payload []byte
+	contentType := ""
+	switch body := r.body.(type) {
+	case nil:
+	case *multipartBody:
+		payload, contentType = body.data, body.contentType
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		payload, contentType = data, "application/json"
+	}
+
+	attempts := 1
+	if idempotent(r.method) {
+		attempts = c.retry.Attempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.attempt(ctx, r, payload, contentType)
+		if attempt == attempts || !retryable(ctx, resp, err) {
+			if err != nil {
+				return nil, err
+			}
+			if resp.StatusCode >= http.StatusBadRequest {
+				defer resp.Body.Close()
+				return nil, readError(resp)
+			}
+			return resp, nil
+		}
+
+		delay := c.backoff(attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, r request, payload []byte, contentType string) (*http.Response, error) {
+	target := c.baseURL + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.organization != "" {
+		req.Header.Set(organizationHeader, c.organization)
+	}
+	if c.user != "" {
+		req.Header.Set(userIdHeader, c.user)
+	}
+
+	return c.http.Do(req)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff is the delay before the next attempt: the Retry-After of the
+// response when it gives one, else an exponential delay with jitter.
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, c.retry.MaxBackoff)
+		}
+	}
+
+	delay := c.retry.MinBackoff << (attempt - 1)
+	if delay <= 0 || delay > c.retry.MaxBackoff {
+		delay = c.retry.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func escape(segment string) string {
+	return url.PathEscape(segment)
+}
+
+// errorBody is the {"error": ..., "message": ...} body of failed requests.
+type errorBody struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+func readError(resp *http.Response) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	e := &Error{StatusCode: resp.StatusCode, Body: data}
+	var body errorBody
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		e.Message, e.Detail = body.Error, body.Message
+	} else {
+		e.Message = strings.TrimSpace(string(data))
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
+
+var errNoUser = errors.New("client: the call needs a user, create the client with WithUser")
+
+func (c *Client) requireUser() error {
+	if c.user == "" {
+		return errNoUser
+	}
+	return nil
+}
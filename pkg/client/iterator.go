@@ -0,0 +1,70 @@
+package client
+
+import "context"
+
+// Iterator walks a paginated listing one item at a time, fetching pages as
+// needed:
+//
+//	it := c.GetNotifications(client.NotificationOptions{})
+//	for it.Next(ctx) {
+//		n := it.Item()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type Iterator[T any] struct {
+	fetch    func(ctx context.Context, limit, offset int) ([]T, error)
+	pageSize int
+	page     []T
+	index    int
+	offset   int
+	done     bool
+	err      error
+}
+
+func newIterator[T any](pageSize int, fetch func(ctx context.Context, limit, offset int) ([]T, error)) *Iterator[T] {
+	return &Iterator[T]{fetch: fetch, pageSize: pageSize, index: -1}
+}
+
+// Next advances to the next item and reports whether there is one.
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index++
+	if it.index < len(it.page) {
+		return true
+	}
+	if it.done {
+		return false
+	}
+
+	page, err := it.fetch(ctx, it.pageSize, it.offset)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.page, it.index = page, 0
+	it.offset += len(page)
+	// A short page is the last one.
+	it.done = len(page) < it.pageSize
+	return len(page) > 0
+}
+
+// Item is the current item.
+func (it *Iterator[T]) Item() T {
+	return it.page[it.index]
+}
+
+// Err is the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// All collects the remaining items.
+func (it *Iterator[T]) All(ctx context.Context) ([]T, error) {
+	var items []T
+	for it.Next(ctx) {
+		items = append(items, it.Item())
+	}
+	return items, it.Err()
+}
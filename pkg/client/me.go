@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// defaultPageSize matches the server's default page of notifications.
+const defaultPageSize = 50
+
+// GetDashboard summarizes the tasks and projects of the client's user.
+func (c *Client) GetDashboard(ctx context.Context) (Dashboard, error) {
+	if err := c.requireUser(); err != nil {
+		return Dashboard{}, err
+	}
+
+	var dashboard Dashboard
+	err := c.get(ctx, "/me/dashboard", nil, &dashboard)
+	return dashboard, err
+}
+
+// GetMyOrganizations lists the organizations of the client's user with
+// their role.
+func (c *Client) GetMyOrganizations(ctx context.Context) ([]UserOrganization, error) {
+	if err := c.requireUser(); err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Organizations []UserOrganization `json:"organizations"`
+	}
+	err := c.get(ctx, "/me/organizations", nil, &response)
+	return response.Organizations, err
+}
+
+type NotificationOptions struct {
+	Unread bool
+	// PageSize is the number of notifications fetched per request, at most
+	// 200.
+	PageSize int
+}
+
+// GetNotifications iterates over the notifications of the client's user,
+// newest first.
+func (c *Client) GetNotifications(options NotificationOptions) *Iterator[Notification] {
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	return newIterator(pageSize, func(ctx context.Context, limit, offset int) ([]Notification, error) {
+		if err := c.requireUser(); err != nil {
+			return nil, err
+		}
+
+		query := url.Values{"limit": {strconv.Itoa(limit)}, "offset": {strconv.Itoa(offset)}}
+		if options.Unread {
+			query.Set("unread", "true")
+		}
+
+		var response struct {
+			Notifications []Notification `json:"notifications"`
+		}
+		err := c.get(ctx, "/me/notifications", query, &response)
+		return response.Notifications, err
+	})
+}
+
+func (c *Client) GetUnreadCount(ctx context.Context) (int, error) {
+	if err := c.requireUser(); err != nil {
+		return 0, err
+	}
+
+	var response struct {
+		Unread int `json:"unread"`
+	}
+	err := c.get(ctx, "/me/notifications/unread-count", nil, &response)
+	return response.Unread, err
+}
+
+func (c *Client) MarkNotificationRead(ctx context.Context, id string) error {
+	if err := c.requireUser(); err != nil {
+		return err
+	}
+	return c.post(ctx, "/me/notifications/"+escape(id)+"/read", nil, nil)
+}
+
+// MarkAllNotificationsRead returns how many notifications were marked.
+func (c *Client) MarkAllNotificationsRead(ctx context.Context) (int64, error) {
+	if err := c.requireUser(); err != nil {
+		return 0, err
+	}
+
+	var response struct {
+		Read int64 `json:"read"`
+	}
+	err := c.post(ctx, "/me/notifications/read-all", nil, &response)
+	return response.Read, err
+}
+
+func (c *Client) GetNotificationPreferences(ctx context.Context) ([]NotificationPreference, error) {
+	if err := c.requireUser(); err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Preferences []NotificationPreference `json:"preferences"`
+	}
+	err := c.get(ctx, "/me/notifications/preferences", nil, &response)
+	return response.Preferences, err
+}
+
+func (c *Client) UpdateNotificationPreferences(ctx context.Context, preferences []NotificationPreference) error {
+	if err := c.requireUser(); err != nil {
+		return err
+	}
+	return c.put(ctx, "/me/notifications/preferences", preferences, nil)
+}
+
+// GetEmailMode returns instant, digest or off.
+func (c *Client) GetEmailMode(ctx context.Context) (string, error) {
+	if err := c.requireUser(); err != nil {
+		return "", err
+	}
+
+	var preference EmailPreference
+	err := c.get(ctx, "/me/email", nil, &preference)
+	return preference.Mode, err
+}
+
+func (c *Client) SetEmailMode(ctx context.Context, mode string) error {
+	if err := c.requireUser(); err != nil {
+		return err
+	}
+	return c.put(ctx, "/me/email", EmailPreference{Mode: mode}, nil)
+}
+
+// Unsubscribe turns emails off for the user with the token from the link in
+// a notification email.
+func (c *Client) Unsubscribe(ctx context.Context, user, token string) error {
+	return c.get(ctx, "/unsubscribe", url.Values{"user": {user}, "token": {token}}, nil)
+}
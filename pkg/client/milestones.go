@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+)
+
+func (c *Client) GetMilestone(ctx context.Context, id string) (MilestoneProgress, error) {
+	var milestone MilestoneProgress
+	err := c.get(ctx, "/milestones/"+escape(id), nil, &milestone)
+	return milestone, err
+}
+
+func (c *Client) UpdateMilestone(ctx context.Context, id string, milestone Milestone) error {
+	return c.put(ctx, "/milestones/"+escape(id), milestone, nil)
+}
+
+func (c *Client) DeleteMilestone(ctx context.Context, id string) error {
+	return c.delete(ctx, "/milestones/"+escape(id), nil)
+}
+
+func (c *Client) GetMilestoneTasks(ctx context.Context, id string) ([]Task, error) {
+	var response struct {
+		Tasks []Task `json:"tasks"`
+	}
+	err := c.get(ctx, "/milestones/"+escape(id)+"/tasks", nil, &response)
+	return response.Tasks, err
+}
+
+// AddMilestoneTasks puts tasks of the milestone's project into the
+// milestone and returns how many were added.
+func (c *Client) AddMilestoneTasks(ctx context.Context, id string, tasks []string) (int64, error) {
+	var response struct {
+		Added int64 `json:"added"`
+	}
+	err := c.post(ctx, "/milestones/"+escape(id)+"/tasks", entity.MilestoneTasks{Tasks: tasks}, &response)
+	return response.Added, err
+}
+
+func (c *Client) RemoveMilestoneTask(ctx context.Context, id, task string) error {
+	return c.delete(ctx, "/milestones/"+escape(id)+"/tasks/"+escape(task), nil)
+}
+
+func (c *Client) UpdateCustomField(ctx context.Context, id string, field CustomField) error {
+	return c.put(ctx, "/fields/"+escape(id), field, nil)
+}
+
+// DeleteCustomField deletes the field together with its values.
+func (c *Client) DeleteCustomField(ctx context.Context, id string) error {
+	return c.delete(ctx, "/fields/"+escape(id), nil)
+}
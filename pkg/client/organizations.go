@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+)
+
+// CreateOrganization creates an organization owned by the client's user.
+func (c *Client) CreateOrganization(ctx context.Context, organization Organization) (Organization, error) {
+	if err := c.requireUser(); err != nil {
+		return Organization{}, err
+	}
+
+	var created Organization
+	err := c.post(ctx, "/organizations/", organization, &created)
+	return created, err
+}
+
+func (c *Client) GetOrganization(ctx context.Context, id string) (Organization, error) {
+	if err := c.requireUser(); err != nil {
+		return Organization{}, err
+	}
+
+	var organization Organization
+	err := c.get(ctx, "/organizations/"+escape(id), nil, &organization)
+	return organization, err
+}
+
+func (c *Client) UpdateOrganization(ctx context.Context, id string, organization Organization) error {
+	if err := c.requireUser(); err != nil {
+		return err
+	}
+	return c.put(ctx, "/organizations/"+escape(id), organization, nil)
+}
+
+// DeleteOrganization deletes the organization with its projects.
+func (c *Client) DeleteOrganization(ctx context.Context, id string) error {
+	if err := c.requireUser(); err != nil {
+		return err
+	}
+	return c.delete(ctx, "/organizations/"+escape(id), nil)
+}
+
+func (c *Client) GetOrganizationMembers(ctx context.Context, id string) ([]Member, error) {
+	if err := c.requireUser(); err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Members []Member `json:"members"`
+	}
+	err := c.get(ctx, "/organizations/"+escape(id)+"/members", nil, &response)
+	return response.Members, err
+}
+
+// SetOrganizationMember adds the user to the organization or changes their
+// role.
+func (c *Client) SetOrganizationMember(ctx context.Context, id, user, role string) error {
+	if err := c.requireUser(); err != nil {
+		return err
+	}
+	return c.put(ctx, "/organizations/"+escape(id)+"/members/"+escape(user), entity.SetMember{Role: role}, nil)
+}
+
+func (c *Client) RemoveOrganizationMember(ctx context.Context, id, user string) error {
+	if err := c.requireUser(); err != nil {
+		return err
+	}
+	return c.delete(ctx, "/organizations/"+escape(id)+"/members/"+escape(user), nil)
+}
@@ -0,0 +1,276 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func (c *Client) ListProjects(ctx context.Context, options ListOptions) ([]Project, error) {
+	var response struct {
+		Projects []Project `json:"projects"`
+	}
+	err := c.get(ctx, "/projects/", options.query(), &response)
+	return response.Projects, err
+}
+
+func (c *Client) CreateProject(ctx context.Context, project Project) (Created, error) {
+	var response created
+	if err := c.post(ctx, "/projects/", project, &response); err != nil {
+		return Created{}, err
+	}
+	return response.value(), nil
+}
+
+// GetTemplates lists the project templates of the organization.
+func (c *Client) GetTemplates(ctx context.Context) ([]Project, error) {
+	var response struct {
+		Templates []Project `json:"templates"`
+	}
+	err := c.get(ctx, "/projects/templates", nil, &response)
+	return response.Templates, err
+}
+
+func (c *Client) GetProject(ctx context.Context, id string) (Project, error) {
+	var response struct {
+		Project Project `json:"project"`
+	}
+	err := c.get(ctx, "/projects/"+escape(id), nil, &response)
+	return response.Project, err
+}
+
+func (c *Client) UpdateProject(ctx context.Context, id string, project Project) error {
+	return c.put(ctx, "/projects/"+escape(id), project, nil)
+}
+
+func (c *Client) DeleteProject(ctx context.Context, id string) error {
+	return c.delete(ctx, "/projects/"+escape(id), nil)
+}
+
+// CloneProject copies the project, or instantiates a template, with its
+// tasks, custom fields and milestones.
+func (c *Client) CloneProject(ctx context.Context, id string, input CloneProject) (Project, error) {
+	var project Project
+	err := c.post(ctx, "/projects/"+escape(id)+"/clone", input, &project)
+	return project, err
+}
+
+// SaveAsTemplate copies the project into a template; an empty title keeps
+// the project's.
+func (c *Client) SaveAsTemplate(ctx context.Context, id, title string) (Project, error) {
+	var template Project
+	err := c.post(ctx, "/projects/"+escape(id)+"/template", entity.SaveTemplate{Title: title}, &template)
+	return template, err
+}
+
+// ArchiveProject finishes the project and its open tasks and returns how
+// many tasks were closed.
+func (c *Client) ArchiveProject(ctx context.Context, id string, input ArchiveProject) (int64, error) {
+	var response struct {
+		Closed int64 `json:"closed"`
+	}
+	err := c.post(ctx, "/projects/"+escape(id)+"/archive", input, &response)
+	return response.Closed, err
+}
+
+// UnarchiveProject reopens the project; the client's user must be its
+// manager.
+func (c *Client) UnarchiveProject(ctx context.Context, id string) error {
+	if err := c.requireUser(); err != nil {
+		return err
+	}
+	return c.post(ctx, "/projects/"+escape(id)+"/unarchive", nil, nil)
+}
+
+func (c *Client) ListProjectTasks(ctx context.Context, id string, query TaskQuery) ([]Task, error) {
+	var response struct {
+		Tasks []Task `json:"tasks"`
+	}
+	err := c.get(ctx, "/projects/"+escape(id)+"/tasks", query.query(), &response)
+	return response.Tasks, err
+}
+
+// ExportProjectTasks streams the project's tasks as csv (the default) or
+// xlsx. The caller closes the returned reader.
+func (c *Client) ExportProjectTasks(ctx context.Context, id, format string) (io.ReadCloser, error) {
+	query := url.Values{}
+	if format != "" {
+		query.Set("format", format)
+	}
+
+	resp, err := c.send(ctx, request{
+		method: http.MethodGet,
+		path:   "/projects/" + escape(id) + "/tasks/export",
+		query:  query,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ImportTasks is a file of tasks to import. Format defaults to the
+// extension of Filename; Columns maps file headers to task fields.
+type ImportTasks struct {
+	File     io.Reader
+	Filename string
+	Format   string
+	Columns  map[string]string
+	DryRun   bool
+}
+
+// multipartBody is a request body sent as is.
+type multipartBody struct {
+	data        []byte
+	contentType string
+}
+
+// ImportProjectTasks imports tasks into the project. When rows are rejected
+// the report listing them is returned together with an error matching
+// ErrInvalid.
+func (c *Client) ImportProjectTasks(ctx context.Context, id string, input ImportTasks) (TaskImportReport, error) {
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("file", input.Filename)
+	if err != nil {
+		return TaskImportReport{}, err
+	}
+	if _, err := io.Copy(part, input.File); err != nil {
+		return TaskImportReport{}, err
+	}
+	if len(input.Columns) > 0 {
+		columns, err := json.Marshal(input.Columns)
+		if err != nil {
+			return TaskImportReport{}, err
+		}
+		if err := form.WriteField("columns", string(columns)); err != nil {
+			return TaskImportReport{}, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return TaskImportReport{}, err
+	}
+
+	query := url.Values{}
+	if input.Format != "" {
+		query.Set("format", input.Format)
+	}
+	if input.DryRun {
+		query.Set("dry_run", strconv.FormatBool(input.DryRun))
+	}
+
+	var report TaskImportReport
+	err = c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/projects/" + escape(id) + "/tasks/import",
+		query:  query,
+		body:   &multipartBody{data: buf.Bytes(), contentType: form.FormDataContentType()},
+		out:    &report,
+	})
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnprocessableEntity {
+		json.Unmarshal(apiErr.Body, &report)
+	}
+	return report, err
+}
+
+// CreateProjectCalendar creates a calendar feed of the project's tasks.
+func (c *Client) CreateProjectCalendar(ctx context.Context, id string) (CalendarLink, error) {
+	var link CalendarLink
+	err := c.post(ctx, "/projects/"+escape(id)+"/calendar", nil, &link)
+	return link, err
+}
+
+func (c *Client) GetBurndown(ctx context.Context, id string, options ReportOptions) (Report[BurndownPoint], error) {
+	var report Report[BurndownPoint]
+	err := c.get(ctx, "/projects/"+escape(id)+"/reports/burndown", options.query(), &report)
+	return report, err
+}
+
+func (c *Client) GetCumulativeFlow(ctx context.Context, id string, options ReportOptions) (Report[CumulativeFlowPoint], error) {
+	var report Report[CumulativeFlowPoint]
+	err := c.get(ctx, "/projects/"+escape(id)+"/reports/cfd", options.query(), &report)
+	return report, err
+}
+
+func (c *Client) GetThroughput(ctx context.Context, id string, options ReportOptions) (Report[ThroughputPoint], error) {
+	var report Report[ThroughputPoint]
+	err := c.get(ctx, "/projects/"+escape(id)+"/reports/throughput", options.query(), &report)
+	return report, err
+}
+
+func (c *Client) CreateSprint(ctx context.Context, project string, sprint Sprint) (Sprint, error) {
+	var created Sprint
+	err := c.post(ctx, "/projects/"+escape(project)+"/sprints", sprint, &created)
+	return created, err
+}
+
+func (c *Client) GetProjectSprints(ctx context.Context, project string) ([]Sprint, error) {
+	var response struct {
+		Sprints []Sprint `json:"sprints"`
+	}
+	err := c.get(ctx, "/projects/"+escape(project)+"/sprints", nil, &response)
+	return response.Sprints, err
+}
+
+func (c *Client) CreateMilestone(ctx context.Context, project string, milestone Milestone) (Milestone, error) {
+	var created Milestone
+	err := c.post(ctx, "/projects/"+escape(project)+"/milestones", milestone, &created)
+	return created, err
+}
+
+// GetProjectMilestones lists the project's milestones with their progress,
+// only the overdue ones when overdueOnly is set.
+func (c *Client) GetProjectMilestones(ctx context.Context, project string, overdueOnly bool) ([]MilestoneProgress, error) {
+	query := url.Values{}
+	if overdueOnly {
+		query.Set("overdue", "true")
+	}
+
+	var response struct {
+		Milestones []MilestoneProgress `json:"milestones"`
+	}
+	err := c.get(ctx, "/projects/"+escape(project)+"/milestones", query, &response)
+	return response.Milestones, err
+}
+
+func (c *Client) CreateCustomField(ctx context.Context, project string, field CustomField) (CustomField, error) {
+	var created CustomField
+	err := c.post(ctx, "/projects/"+escape(project)+"/fields", field, &created)
+	return created, err
+}
+
+func (c *Client) GetCustomFields(ctx context.Context, project string) ([]CustomField, error) {
+	var response struct {
+		Fields []CustomField `json:"fields"`
+	}
+	err := c.get(ctx, "/projects/"+escape(project)+"/fields", nil, &response)
+	return response.Fields, err
+}
+
+func (c *Client) GetProjectByTitle(ctx context.Context, title string) (Project, error) {
+	var response struct {
+		Project Project `json:"project"`
+	}
+	err := c.get(ctx, "/projects/search/"+escape(title), nil, &response)
+	return response.Project, err
+}
+
+func (c *Client) GetProjectsByManager(ctx context.Context, manager string, options ListOptions) ([]Project, error) {
+	query := options.query()
+	query.Set("manager", manager)
+
+	var response struct {
+		Projects []Project `json:"project"`
+	}
+	err := c.get(ctx, "/projects/search", query, &response)
+	return response.Projects, err
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+)
+
+func (c *Client) GetSprint(ctx context.Context, id string) (Sprint, error) {
+	var sprint Sprint
+	err := c.get(ctx, "/sprints/"+escape(id), nil, &sprint)
+	return sprint, err
+}
+
+func (c *Client) UpdateSprint(ctx context.Context, id string, sprint Sprint) error {
+	return c.put(ctx, "/sprints/"+escape(id), sprint, nil)
+}
+
+func (c *Client) DeleteSprint(ctx context.Context, id string) error {
+	return c.delete(ctx, "/sprints/"+escape(id), nil)
+}
+
+func (c *Client) StartSprint(ctx context.Context, id string) error {
+	return c.post(ctx, "/sprints/"+escape(id)+"/start", nil, nil)
+}
+
+// CloseSprint closes the active sprint and carries its unfinished tasks
+// over to input.Next, the next planned sprint or the backlog.
+func (c *Client) CloseSprint(ctx context.Context, id string, input CloseSprint) (CloseSprintResult, error) {
+	var result CloseSprintResult
+	err := c.post(ctx, "/sprints/"+escape(id)+"/close", input, &result)
+	return result, err
+}
+
+func (c *Client) GetSprintTasks(ctx context.Context, id string) ([]Task, error) {
+	var response struct {
+		Tasks []Task `json:"tasks"`
+	}
+	err := c.get(ctx, "/sprints/"+escape(id)+"/tasks", nil, &response)
+	return response.Tasks, err
+}
+
+// AddSprintTasks puts tasks of the sprint's project into the sprint and
+// returns how many were added.
+func (c *Client) AddSprintTasks(ctx context.Context, id string, tasks []string) (int64, error) {
+	var response struct {
+		Added int64 `json:"added"`
+	}
+	err := c.post(ctx, "/sprints/"+escape(id)+"/tasks", entity.SprintTasks{Tasks: tasks}, &response)
+	return response.Added, err
+}
+
+func (c *Client) RemoveSprintTask(ctx context.Context, id, task string) error {
+	return c.delete(ctx, "/sprints/"+escape(id)+"/tasks/"+escape(task), nil)
+}
+
+func (c *Client) GetSprintReport(ctx context.Context, id string) (SprintReport, error) {
+	var report SprintReport
+	err := c.get(ctx, "/sprints/"+escape(id)+"/report", nil, &report)
+	return report, err
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+)
+
+func (c *Client) ListTasks(ctx context.Context, options ListOptions) ([]Task, error) {
+	var response struct {
+		Tasks []Task `json:"tasks"`
+	}
+	err := c.get(ctx, "/tasks/", options.query(), &response)
+	return response.Tasks, err
+}
+
+func (c *Client) CreateTask(ctx context.Context, task Task) (Created, error) {
+	var response created
+	if err := c.post(ctx, "/tasks/", task, &response); err != nil {
+		return Created{}, err
+	}
+	return response.value(), nil
+}
+
+// BulkTasks applies the items in one transaction. When an atomic request is
+// rolled back the per-item results are returned together with the error.
+func (c *Client) BulkTasks(ctx context.Context, input BulkTaskRequest) ([]BulkTaskResult, error) {
+	var response struct {
+		Results []BulkTaskResult `json:"results"`
+	}
+	err := c.post(ctx, "/tasks/bulk", input, &response)
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		var rolledBack struct {
+			Results []BulkTaskResult `json:"results"`
+		}
+		if json.Unmarshal(apiErr.Body, &rolledBack) == nil {
+			return rolledBack.Results, err
+		}
+	}
+	return response.Results, err
+}
+
+func (c *Client) GetTask(ctx context.Context, id string) (Task, error) {
+	var response struct {
+		Task Task `json:"task"`
+	}
+	err := c.get(ctx, "/tasks/"+escape(id), nil, &response)
+	return response.Task, err
+}
+
+func (c *Client) UpdateTask(ctx context.Context, id string, task Task) error {
+	return c.put(ctx, "/tasks/"+escape(id), task, nil)
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id string) error {
+	return c.delete(ctx, "/tasks/"+escape(id), nil)
+}
+
+// MoveTask places the task's card on the board.
+func (c *Client) MoveTask(ctx context.Context, id string, input MoveTask) error {
+	return c.post(ctx, "/tasks/"+escape(id)+"/move", input, nil)
+}
+
+// SetTaskFields sets custom field values of the task, keyed by field name
+// or id; nil clears a value.
+func (c *Client) SetTaskFields(ctx context.Context, id string, values map[string]interface{}) error {
+	return c.put(ctx, "/tasks/"+escape(id)+"/fields", values, nil)
+}
+
+func (c *Client) SetRecurrence(ctx context.Context, id string, input Recurrence) (TaskSeries, error) {
+	var series TaskSeries
+	err := c.post(ctx, "/tasks/"+escape(id)+"/recurrence", input, &series)
+	return series, err
+}
+
+func (c *Client) GetTaskSeries(ctx context.Context, id string) (TaskSeries, error) {
+	var series TaskSeries
+	err := c.get(ctx, "/tasks/"+escape(id)+"/series", nil, &series)
+	return series, err
+}
+
+// UpdateTaskSeries changes the series the task belongs to from this
+// occurrence on.
+func (c *Client) UpdateTaskSeries(ctx context.Context, id string, input SeriesUpdate) error {
+	return c.put(ctx, "/tasks/"+escape(id)+"/series", input, nil)
+}
+
+func (c *Client) StopTaskSeries(ctx context.Context, id string) error {
+	return c.delete(ctx, "/tasks/"+escape(id)+"/series", nil)
+}
+
+func (c *Client) GetTaskByTitle(ctx context.Context, title string) (Task, error) {
+	var response struct {
+		Task Task `json:"task"`
+	}
+	err := c.get(ctx, "/tasks/search", url.Values{"title": {title}}, &response)
+	return response.Task, err
+}
+
+func (c *Client) GetTasksByStatus(ctx context.Context, status string, options ListOptions) ([]Task, error) {
+	query := options.query()
+	query.Set("status", status)
+
+	var response struct {
+		Tasks []Task `json:"task"`
+	}
+	err := c.get(ctx, "/tasks/search/status", query, &response)
+	return response.Tasks, err
+}
+
+func (c *Client) GetTasksByPriority(ctx context.Context, priority string, options ListOptions) ([]Task, error) {
+	query := options.query()
+	query.Set("priority", priority)
+
+	var response struct {
+		Tasks []Task `json:"task"`
+	}
+	err := c.get(ctx, "/tasks/search/priority", query, &response)
+	return response.Tasks, err
+}
+
+func (c *Client) GetTasksByAssignee(ctx context.Context, assignee string, options ListOptions) ([]Task, error) {
+	var response struct {
+		Tasks []Task `json:"tasks"`
+	}
+	err := c.get(ctx, "/tasks/search/"+escape(assignee), options.query(), &response)
+	return response.Tasks, err
+}
+
+// GetTasksByProject is ListProjectTasks under the task search route.
+func (c *Client) GetTasksByProject(ctx context.Context, project string, query TaskQuery) ([]Task, error) {
+	var response struct {
+		Tasks []Task `json:"tasks"`
+	}
+	err := c.get(ctx, "/tasks/search/project/"+escape(project), query.query(), &response)
+	return response.Tasks, err
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"net/url"
+	"time"
+)
+
+// The request and response types are those of the server.
+type (
+	User                   = entity.User
+	Task                   = entity.Task
+	Project                = entity.Project
+	MoveTask               = entity.MoveTask
+	BulkTaskItem           = entity.BulkTaskItem
+	BulkTaskRequest        = entity.BulkTaskRequest
+	BulkTaskResult         = entity.BulkTaskResult
+	ReassignTasks          = entity.ReassignTasks
+	CalendarToken          = entity.CalendarToken
+	CustomField            = entity.CustomField
+	CustomValues           = entity.CustomValues
+	FieldOptions           = entity.FieldOptions
+	Dashboard              = entity.Dashboard
+	TaskCount              = entity.TaskCount
+	ProjectProgress        = entity.ProjectProgress
+	EmailPreference        = entity.EmailPreference
+	Milestone              = entity.Milestone
+	MilestoneProgress      = entity.MilestoneProgress
+	Notification           = entity.Notification
+	NotificationPreference = entity.NotificationPreference
+	Organization           = entity.Organization
+	Member                 = entity.Member
+	UserOrganization       = entity.UserOrganization
+	CloneProject           = entity.CloneProject
+	ArchiveProject         = entity.ArchiveProject
+	ReportRange            = entity.ReportRange
+	BurndownPoint          = entity.BurndownPoint
+	CumulativeFlowPoint    = entity.CumulativeFlowPoint
+	ThroughputPoint        = entity.ThroughputPoint
+	TaskSeries             = entity.TaskSeries
+	Recurrence             = entity.Recurrence
+	SeriesUpdate           = entity.SeriesUpdate
+	Sprint                 = entity.Sprint
+	CloseSprint            = entity.CloseSprint
+	CloseSprintResult      = entity.CloseSprintResult
+	SprintReport           = entity.SprintReport
+	TaskImportReport       = entity.TaskImportReport
+	ImportRowError         = entity.ImportRowError
+)
+
+const (
+	BulkCreate = entity.BulkCreate
+	BulkUpdate = entity.BulkUpdate
+	BulkDelete = entity.BulkDelete
+
+	ArchiveClose  = entity.ArchiveClose
+	ArchiveCancel = entity.ArchiveCancel
+
+	TaskStatusDone      = entity.TaskStatusDone
+	TaskStatusCancelled = entity.TaskStatusCancelled
+
+	IntervalDay  = entity.IntervalDay
+	IntervalWeek = entity.IntervalWeek
+
+	FormatCSV  = entity.FormatCSV
+	FormatXLSX = entity.FormatXLSX
+
+	EmailModeInstant = entity.EmailModeInstant
+	EmailModeDigest  = entity.EmailModeDigest
+	EmailModeOff     = entity.EmailModeOff
+
+	OrgRoleOwner  = entity.OrgRoleOwner
+	OrgRoleAdmin  = entity.OrgRoleAdmin
+	OrgRoleMember = entity.OrgRoleMember
+)
+
+// Created identifies a user, project or task the server has created.
+type Created struct {
+	ID        string
+	CreatedAt time.Time
+}
+
+// created decodes the two spellings of the creation time in responses.
+type created struct {
+	ID          string    `json:"id"`
+	CreatedAt   time.Time `json:"createdAt"`
+	CreatedAtV2 time.Time `json:"created_at"`
+}
+
+func (c created) value() Created {
+	if c.CreatedAt.IsZero() {
+		return Created{ID: c.ID, CreatedAt: c.CreatedAtV2}
+	}
+	return Created{ID: c.ID, CreatedAt: c.CreatedAt}
+}
+
+// CalendarLink is a calendar feed token with the path of its feed.
+type CalendarLink struct {
+	Token CalendarToken `json:"token"`
+	URL   string        `json:"url"`
+}
+
+// Report is a project report over Range.
+type Report[P any] struct {
+	Range  ReportRange `json:"range"`
+	Points []P         `json:"points"`
+}
+
+// ListOptions apply to the task and project listings.
+type ListOptions struct {
+	// IncludeArchived also lists archived projects and their tasks.
+	IncludeArchived bool
+}
+
+func (o ListOptions) query() url.Values {
+	query := url.Values{}
+	if o.IncludeArchived {
+		query.Set("include_archived", "true")
+	}
+	return query
+}
+
+// TaskQuery filters and sorts a project's tasks by custom fields, referred
+// to by name or id.
+type TaskQuery struct {
+	Fields map[string]string
+	Sort   string
+	Desc   bool
+}
+
+func (q TaskQuery) query() url.Values {
+	query := url.Values{}
+	for name, value := range q.Fields {
+		query.Set("field["+name+"]", value)
+	}
+	if q.Sort != "" {
+		query.Set("sort", q.Sort)
+	}
+	if q.Desc {
+		query.Set("order", "desc")
+	}
+	return query
+}
+
+// ReportOptions select the range of a report. Zero values leave the
+// server's defaults: daily points over the last 30 days.
+type ReportOptions struct {
+	Interval string
+	From     time.Time
+	To       time.Time
+}
+
+func (o ReportOptions) query() url.Values {
+	query := url.Values{}
+	if o.Interval != "" {
+		query.Set("interval", o.Interval)
+	}
+	if !o.From.IsZero() {
+		query.Set("from", o.From.Format(time.DateOnly))
+	}
+	if !o.To.IsZero() {
+		query.Set("to", o.To.Format(time.DateOnly))
+	}
+	return query
+}
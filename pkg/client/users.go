@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+func (c *Client) CreateUser(ctx context.Context, user User) (Created, error) {
+	var response created
+	if err := c.post(ctx, "/users/", user, &response); err != nil {
+		return Created{}, err
+	}
+	return response.value(), nil
+}
+
+func (c *Client) ListUsers(ctx context.Context) ([]User, error) {
+	var users []User
+	err := c.get(ctx, "/users/", nil, &users)
+	return users, err
+}
+
+func (c *Client) GetUser(ctx context.Context, id string) (User, error) {
+	var user User
+	err := c.get(ctx, "/users/"+escape(id), nil, &user)
+	return user, err
+}
+
+func (c *Client) GetUserByName(ctx context.Context, name string) (User, error) {
+	var user User
+	err := c.get(ctx, "/users/search/name", url.Values{"name": {name}}, &user)
+	return user, err
+}
+
+func (c *Client) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	var user User
+	err := c.get(ctx, "/users/search/email", url.Values{"email": {email}}, &user)
+	return user, err
+}
+
+func (c *Client) UpdateUser(ctx context.Context, id string, user User) error {
+	return c.put(ctx, "/users/"+escape(id), user, nil)
+}
+
+func (c *Client) DeleteUser(ctx context.Context, id string) error {
+	return c.delete(ctx, "/users/"+escape(id), nil)
+}
+
+// GetUserTasks lists the tasks assigned to the user.
+func (c *Client) GetUserTasks(ctx context.Context, id string, options ListOptions) ([]Task, error) {
+	var response struct {
+		Tasks []Task `json:"tasks"`
+	}
+	err := c.get(ctx, "/users/"+escape(id)+"/tasks", options.query(), &response)
+	return response.Tasks, err
+}
+
+// ReassignUserTasks hands the user's open tasks to another user and returns
+// how many were moved.
+func (c *Client) ReassignUserTasks(ctx context.Context, id string, input ReassignTasks) (int64, error) {
+	var response struct {
+		Reassigned int64 `json:"reassigned"`
+	}
+	err := c.post(ctx, "/users/"+escape(id)+"/tasks/reassign", input, &response)
+	return response.Reassigned, err
+}
+
+// CreateUserCalendar creates a calendar feed of the user's tasks.
+func (c *Client) CreateUserCalendar(ctx context.Context, id string) (CalendarLink, error) {
+	var link CalendarLink
+	err := c.post(ctx, "/users/"+escape(id)+"/calendar", nil, &link)
+	return link, err
+}
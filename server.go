@@ -2,6 +2,7 @@ package projects_manager
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 )
@@ -10,16 +11,22 @@ type Server struct {
 	httpServer *http.Server
 }
 
-func (s *Server) Run(port string, handler http.Handler) error {
-	s.httpServer = &http.Server{
-		Addr:           ":8080",
+func NewServer(port string, handler http.Handler) *Server {
+	return &Server{httpServer: &http.Server{
+		Addr:           ":" + port,
 		Handler:        handler,
 		MaxHeaderBytes: 1 << 20,
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   10 * time.Second,
-	}
+	}}
+}
 
-	return s.httpServer.ListenAndServe()
+// Run serves until Shutdown is called, and then returns nil.
+func (s *Server) Run() error {
+	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMoveTaskRebalance(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	taskId := "0cc175b9-c0f1-4b6a-831c-399e26977266"
+	move := func(column *sqlmock.Rows) {
+		expectOrganization(mock)
+		expectMember(mock)
+		mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM tasks WHERE id = \\$2").
+			WithArgs("org1", taskId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery("SELECT \\* FROM tasks WHERE id = \\$1").WithArgs(taskId).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "project"}).AddRow(taskId, "Login", "Done", "project1"))
+		mock.ExpectQuery("SELECT id, rank FROM tasks WHERE project = \\$1 AND status = \\$2").
+			WithArgs("project1", "Done").WillReturnRows(column)
+	}
+	place := func(key string) {
+		mock.ExpectBegin()
+		mock.ExpectExec("SET LOCAL pm.rank_placed = 'on'").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE tasks SET status = \\$1, rank = \\$2 WHERE id = \\$3").
+			WithArgs("Done", key, taskId).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+
+	tests := []struct {
+		name string
+		mock func()
+	}{
+		{
+			name: "Short keys",
+			mock: func() {
+				move(sqlmock.NewRows([]string{"id", "rank"}).AddRow("t1", "i").AddRow(taskId, "j"))
+				place("9")
+			},
+		},
+		{
+			name: "Keys grown too long",
+			mock: func() {
+				move(sqlmock.NewRows([]string{"id", "rank"}).AddRow("t1", "i").AddRow(taskId, "zzzzzzzzzzzzzzzzz1"))
+				mock.ExpectBegin()
+				mock.ExpectExec("SET CONSTRAINTS tasks_rank_key DEFERRED").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT id FROM tasks WHERE project = \\$1 AND status = \\$2").
+					WithArgs("project1", "Done").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("t1").AddRow(taskId))
+				mock.ExpectExec("UPDATE tasks SET rank = \\$1 WHERE id = \\$2").WithArgs("c", "t1").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE tasks SET rank = \\$1 WHERE id = \\$2").WithArgs("o", taskId).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT id, rank FROM tasks WHERE project = \\$1 AND status = \\$2").
+					WithArgs("project1", "Done").WillReturnRows(sqlmock.NewRows([]string{"id", "rank"}).AddRow("t1", "c").AddRow(taskId, "o"))
+				place("6")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			req := httptest.NewRequest(http.MethodPost, "/tasks/"+taskId+"/move", strings.NewReader(`{"before": "t1"}`))
+			req.Header.Set("X-Organization", "acme")
+			req.Header.Set("X-User-ID", testUserId)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
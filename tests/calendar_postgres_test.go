@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateCalendarToken(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewCalendarPostgres(db)
+
+	rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow("1", time.Now())
+	mock.ExpectQuery("INSERT INTO calendar_tokens \\(token, scope, target, organization\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) RETURNING id, created_at").
+		WithArgs("secret", entity.CalendarScopeUser, "user1", "org1").
+		WillReturnRows(rows)
+
+	got, _, err := r.CreateCalendarToken(context.Background(), entity.CalendarToken{Token: "secret", Scope: entity.CalendarScopeUser, Target: "user1", Organization: "org1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetCalendarToken(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewCalendarPostgres(db)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    entity.CalendarToken
+		wantErr bool
+	}{
+		{
+			name: "success",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"id", "token", "scope", "target"}).AddRow("1", "secret", "project", "project1")
+				mock.ExpectQuery("SELECT (.+) FROM calendar_tokens WHERE token = \\$1 AND revoked_at IS NULL").
+					WithArgs("secret").WillReturnRows(rows)
+			},
+			want: entity.CalendarToken{ID: "1", Token: "secret", Scope: "project", Target: "project1"},
+		},
+		{
+			name: "error - revoked",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM calendar_tokens WHERE token = \\$1 AND revoked_at IS NULL").
+					WithArgs("secret").WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: true,
+		},
+		{
+			name: "error - database error",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM calendar_tokens").
+					WithArgs("secret").WillReturnError(fmt.Errorf("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.GetCalendarToken(context.Background(), "secret")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRevokeCalendarToken(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewCalendarPostgres(db)
+
+	mock.ExpectExec("UPDATE calendar_tokens SET revoked_at = NOW\\(\\) WHERE token = \\$1").
+		WithArgs("secret").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	affected, err := r.RevokeCalendarToken(context.Background(), "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRevokeUnknownCalendarToken(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	mock.ExpectExec("UPDATE calendar_tokens SET revoked_at = NOW\\(\\) WHERE token = \\$1 AND revoked_at IS NULL").
+		WithArgs("secret").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/calendar/secret.ics", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
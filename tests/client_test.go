@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/Aytya/projects-manager-HL/pkg/client"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var noBackoff = client.WithRetry(client.Retry{Attempts: 3})
+
+func TestClientAgainstHandler(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	server := httptest.NewServer(handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes())
+	defer server.Close()
+
+	c, err := client.New(server.URL, client.WithOrganization("acme"), client.WithUser(testUserId))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	projectId := "8f14e45f-ceea-4672-9bc5-1f0b2c5c1e3a"
+
+	tests := []struct {
+		name string
+		mock func()
+		call func(t *testing.T)
+	}{
+		{
+			name: "Users are a bare array",
+			mock: func() {
+				expectOrganization(mock)
+				expectMember(mock)
+				mock.ExpectQuery("SELECT \\* FROM users WHERE").WithArgs("org1").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}).
+						AddRow("u1", "Aya", "aya@test.com").
+						AddRow("u2", "Dana", "dana@test.com"))
+			},
+			call: func(t *testing.T) {
+				users, err := c.ListUsers(ctx)
+				assert.NoError(t, err)
+				if assert.Len(t, users, 2) {
+					assert.Equal(t, "Dana", users[1].Name)
+				}
+			},
+		},
+		{
+			name: "Tasks by status are under task",
+			mock: func() {
+				expectOrganization(mock)
+				expectMember(mock)
+				mock.ExpectQuery("SELECT \\* FROM tasks WHERE status = \\$1").WithArgs("Done", "org1").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status"}).AddRow("t1", "Landing page", "Done"))
+			},
+			call: func(t *testing.T) {
+				tasks, err := c.GetTasksByStatus(ctx, "Done", client.ListOptions{})
+				assert.NoError(t, err)
+				if assert.Len(t, tasks, 1) {
+					assert.Equal(t, "Landing page", tasks[0].Title)
+				}
+			},
+		},
+		{
+			name: "Project of another organization",
+			mock: func() {
+				expectOrganization(mock)
+				expectMember(mock)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM projects WHERE organization = \\$1 AND id = \\$2\\)").
+					WithArgs("org1", projectId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			call: func(t *testing.T) {
+				_, err := c.GetProject(ctx, projectId)
+				assert.ErrorIs(t, err, client.ErrNotFound)
+
+				var apiErr *client.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+					assert.Contains(t, apiErr.Message, "not found in the organization")
+				}
+			},
+		},
+		{
+			name: "Invalid task",
+			mock: func() {
+				expectOrganization(mock)
+				expectMember(mock)
+			},
+			call: func(t *testing.T) {
+				_, err := c.CreateTask(ctx, client.Task{Title: "Landing page"})
+				assert.ErrorIs(t, err, client.ErrBadRequest)
+				assert.False(t, errors.Is(err, client.ErrNotFound))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			tt.call(t)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"task": {"id": "t1", "title": "Landing page"}}`))
+	}))
+	defer server.Close()
+
+	c, err := client.New(server.URL, noBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := c.GetTask(context.Background(), "t1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Landing page", task.Title)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+
+	// Creating is not idempotent and is tried once.
+	atomic.StoreInt32(&calls, 0)
+	_, err = c.CreateTask(context.Background(), client.Task{Title: "Landing page"})
+	assert.ErrorIs(t, err, client.ErrServer)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestClientRetryHonorsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c, err := client.New(server.URL, client.WithRetry(client.Retry{Attempts: 5, MinBackoff: time.Minute, MaxBackoff: time.Minute}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = c.ListTasks(ctx, client.ListOptions{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestClientNotificationPages(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "u1", r.Header.Get("X-User-ID"))
+		pages = append(pages, r.URL.RawQuery)
+
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		switch offset {
+		case 0:
+			w.Write([]byte(`{"notifications": [{"id": "n1"}, {"id": "n2"}]}`))
+		case 2:
+			w.Write([]byte(`{"notifications": [{"id": "n3"}, {"id": "n4"}]}`))
+		default:
+			w.Write([]byte(`{"notifications": [{"id": "n5"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := client.New(server.URL, client.WithUser("u1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifications, err := c.GetNotifications(client.NotificationOptions{Unread: true, PageSize: 2}).All(context.Background())
+	assert.NoError(t, err)
+
+	ids := make([]string, 0, len(notifications))
+	for _, notification := range notifications {
+		ids = append(ids, notification.ID)
+	}
+	assert.Equal(t, []string{"n1", "n2", "n3", "n4", "n5"}, ids)
+	assert.Equal(t, []string{"limit=2&offset=0&unread=true", "limit=2&offset=2&unread=true", "limit=2&offset=4&unread=true"}, pages)
+
+	// The /me routes need a user.
+	anonymous, _ := client.New(server.URL)
+	_, err = anonymous.GetUnreadCount(context.Background())
+	assert.Error(t, err)
+}
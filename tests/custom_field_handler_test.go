@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequiredCustomField(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	projectId := "92eb5ffe-e0b4-4e1f-a7b4-1cd0a1f2e7a3"
+	fieldId := "a87ff679-a2f3-4c1d-a1e0-6f1b2c3d4e5f"
+	exists := func(query string, id string) {
+		mock.ExpectQuery(query).WithArgs("org1", id).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	}
+	fields := func(required bool) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "project", "name", "type", "options", "required", "created_at"}).
+			AddRow(fieldId, projectId, "severity", "select", []byte(`["low","high"]`), required, time.Now())
+	}
+	newTask := func(fields string) string {
+		return `{"title": "Login", "description": "Login form", "priority": "High", "status": "Not Started",
+			"assignee": "` + testUserId + `", "project": "` + projectId + `"` + fields + `}`
+	}
+	createTask := func() {
+		expectOrganization(mock)
+		expectMember(mock)
+		exists("SELECT EXISTS \\(SELECT 1 FROM projects", projectId)
+		exists("SELECT EXISTS \\(SELECT 1 FROM memberships", testUserId)
+		mock.ExpectQuery("SELECT \\* FROM custom_fields WHERE project = \\$1").WithArgs(projectId).WillReturnRows(fields(true))
+	}
+	updateField := func(required bool) {
+		expectOrganization(mock)
+		expectMember(mock)
+		exists("SELECT EXISTS \\(SELECT 1 FROM custom_fields", fieldId)
+		mock.ExpectQuery("SELECT \\* FROM custom_fields WHERE id = \\$1").WithArgs(fieldId).WillReturnRows(fields(required))
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		mock   func()
+		status int
+	}{
+		{
+			name:   "Task without a required value",
+			method: http.MethodPost,
+			path:   "/tasks/",
+			body:   newTask(""),
+			mock:   createTask,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Task with a required value",
+			method: http.MethodPost,
+			path:   "/tasks/",
+			body:   newTask(`, "custom_fields": {"severity": "high"}`),
+			mock: func() {
+				createTask()
+				mock.ExpectQuery("INSERT INTO tasks").
+					WithArgs("Login", "Login form", "High", "Not Started", testUserId, projectId, sqlmock.AnyArg(), []byte(`{"`+fieldId+`":"high"}`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("task1", time.Now()))
+				mock.ExpectQuery("FROM notification_preferences").WillReturnRows(sqlmock.NewRows([]string{"type", "enabled"}))
+				mock.ExpectQuery("INSERT INTO notifications").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("notification1", time.Now()))
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name:   "Rename keeps the field required",
+			method: http.MethodPut,
+			path:   "/fields/" + fieldId,
+			body:   `{"name": "impact"}`,
+			mock: func() {
+				updateField(true)
+				mock.ExpectExec("UPDATE custom_fields SET name = \\$1, options = \\$2, required = \\$3 WHERE id = \\$4").
+					WithArgs("impact", sqlmock.AnyArg(), true, fieldId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "Required while tasks have no value",
+			method: http.MethodPut,
+			path:   "/fields/" + fieldId,
+			body:   `{"required": true}`,
+			mock: func() {
+				updateField(false)
+				mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM tasks WHERE project = \\$1 AND NOT custom_fields \\? \\$2").
+					WithArgs(projectId, fieldId).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("X-Organization", "acme")
+			req.Header.Set("X-User-ID", testUserId)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCustomFieldsOfBulkAndSeriesTasks(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+
+	fieldId := "a87ff679-a2f3-4c1d-a1e0-6f1b2c3d4e5f"
+	fields := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "project", "name", "type", "options", "required", "created_at"}).
+			AddRow(fieldId, "project1", "severity", "select", []byte(`["low","high"]`), true, time.Now())
+	}
+	task := entity.Task{Title: "Login", Description: "Login form", Priority: "High", Status: "Not Started", Assignee: "user1", Project: "project1"}
+	withSeverity := task
+	withSeverity.Fields = entity.CustomValues{"severity": "high"}
+
+	t.Run("Bulk create", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM custom_fields WHERE project = \\$1").WithArgs("project1").WillReturnRows(fields())
+		mock.ExpectQuery("SELECT \\* FROM custom_fields WHERE project = \\$1").WithArgs("project1").WillReturnRows(fields())
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("INSERT INTO tasks \\(title, description, priority, status, assignee, project, due_at, custom_fields\\)").
+			WithArgs("Login", "Login form", "High", "Not Started", "user1", "project1", sql.NullTime{}, []byte(`{"`+fieldId+`":"high"}`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("task1", time.Now()))
+		mock.ExpectExec("RELEASE SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		mock.ExpectQuery("FROM notification_preferences").WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"type", "enabled"}))
+		mock.ExpectQuery("INSERT INTO notifications").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("notification1", time.Now()))
+
+		items := []entity.BulkTaskItem{{Op: entity.BulkCreate, Task: withSeverity}, {Op: entity.BulkCreate, Task: task}}
+		results, err := services.Task.BulkTasks(context.Background(), items, false)
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.Equal(t, "task1", results[0].ID)
+		assert.Contains(t, results[1].Error, "severity is required")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Series occurrence", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM task_series WHERE trigger = \\$1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "rule", "trigger", "start_at", "next_at", "title", "description", "priority", "status", "assignee", "project", "custom_fields"}).
+				AddRow("series1", "FREQ=DAILY", entity.RecurOnSchedule, time.Now(), time.Now(), "Login", "", "High", "Not Started", "user1", "project1", []byte("{}")))
+		mock.ExpectQuery("SELECT \\* FROM custom_fields WHERE project = \\$1").WithArgs("project1").WillReturnRows(fields())
+
+		created, err := services.Series.GenerateScheduled(context.Background(), time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, created)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserFieldNeedsMember(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+
+	mock.ExpectQuery("SELECT \\* FROM custom_fields WHERE project = \\$1").WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project", "name", "type", "options", "required", "created_at"}).
+			AddRow("field1", "project1", "reviewer", "user", []byte(`[]`), false, time.Now()))
+	mock.ExpectQuery("SELECT \\* FROM users WHERE id = \\$1 AND id IN \\(SELECT user_id FROM memberships WHERE organization = \\(SELECT organization FROM projects WHERE id = \\$2\\)\\)").
+		WithArgs("user2", "project1").WillReturnError(sql.ErrNoRows)
+
+	task := entity.Task{Title: "Login", Project: "project1", Assignee: "user1", Fields: entity.CustomValues{"reviewer": "user2"}}
+	_, _, err = services.Task.CreateTask(context.Background(), task)
+	assert.ErrorIs(t, err, service.ErrInvalidFieldValue)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"testing"
+)
+
+func TestSetTaskFields(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewCustomFieldPostgres(db)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success",
+			mock: func() {
+				mock.ExpectExec("UPDATE tasks SET custom_fields = \\(custom_fields \\|\\| \\$1::jsonb\\) - \\$2::text\\[\\] WHERE id = \\$3").
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "task1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "task not found",
+			mock: func() {
+				mock.ExpectExec("UPDATE tasks SET custom_fields").
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "task1").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: repository.ErrTaskNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := r.SetTaskFields(context.Background(), "task1", entity.CustomValues{"field1": "high"}, []string{"field2"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestGetTasksByFields(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewCustomFieldPostgres(db)
+
+	severity := entity.CustomField{ID: "field1", Type: entity.FieldSelect}
+	tags := entity.CustomField{ID: "field2", Type: entity.FieldMultiSelect}
+	points := entity.CustomField{ID: "field3", Type: entity.FieldNumber}
+
+	rows := sqlmock.NewRows([]string{"id", "title", "custom_fields"}).
+		AddRow("task1", "Fix login", []byte(`{"field1":"high","field2":["backend"],"field3":5}`))
+	mock.ExpectQuery("SELECT \\* FROM tasks WHERE project = \\$1 AND custom_fields->>\\$2 = \\$3 AND custom_fields->\\$4 @> to_jsonb\\(\\$5::text\\) "+
+		"ORDER BY \\(custom_fields->>\\$6\\)::numeric DESC NULLS LAST, created_at").
+		WithArgs("project1", "field1", "high", "field2", "backend", "field3").
+		WillReturnRows(rows)
+
+	filters := []entity.FieldFilter{{Field: severity, Value: "high"}, {Field: tags, Value: "backend"}}
+	got, err := r.GetTasksByFields(context.Background(), "project1", filters, &entity.FieldSort{Field: points, Desc: true})
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "high", got[0].Fields["field1"])
+		assert.Equal(t, float64(5), got[0].Fields["field3"])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteField(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewCustomFieldPostgres(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE tasks SET custom_fields = custom_fields - \\$1 WHERE project = \\$2").
+		WithArgs("field1", "project1").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE task_series SET custom_fields = custom_fields - \\$1 WHERE project = \\$2").
+		WithArgs("field1", "project1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM custom_fields WHERE id = \\$1").
+		WithArgs("field1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = r.DeleteField(context.Background(), entity.CustomField{ID: "field1", Project: "project1"})
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
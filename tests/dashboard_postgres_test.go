@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"testing"
+	"time"
+)
+
+func TestOpenTaskCounts(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewDashboardPostgres(db)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []entity.TaskCount
+		wantErr bool
+	}{
+		{
+			name: "success",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"status", "priority", "count"}).
+					AddRow("In Progress", "High", 2).
+					AddRow("Not Started", "Low", 5)
+				mock.ExpectQuery("SELECT status, priority, COUNT\\(\\*\\) AS count FROM tasks(.+)WHERE assignee = \\$1 AND finished_at IS NULL "+
+					"AND project IN \\(SELECT id FROM projects WHERE organization = \\$2 AND NOT is_template\\)").
+					WithArgs("user1", "org1").WillReturnRows(rows)
+			},
+			want: []entity.TaskCount{
+				{Status: "In Progress", Priority: "High", Count: 2},
+				{Status: "Not Started", Priority: "Low", Count: 5},
+			},
+		},
+		{
+			name: "error - database error",
+			mock: func() {
+				mock.ExpectQuery("SELECT status, priority").WithArgs("user1", "org1").WillReturnError(fmt.Errorf("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.OpenTaskCounts(context.Background(), "org1", "user1")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestManagedProjectProgress(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewDashboardPostgres(db)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "manager", "total", "finished"}).
+		AddRow("1", "title1", "description1", "user1", 4, 1)
+	mock.ExpectQuery("SELECT p.\\*, COUNT\\(t.id\\) AS total, COUNT\\(t.finished_at\\) AS finished(.+)WHERE p.manager = \\$1 AND p.organization = \\$2 AND p.finished_at IS NULL AND NOT p.is_template").
+		WithArgs("user1", "org1").WillReturnRows(rows)
+
+	got, err := r.ManagedProjectProgress(context.Background(), "org1", "user1")
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "title1", got[0].Title)
+		assert.Equal(t, 4, got[0].Total)
+		assert.Equal(t, 1, got[0].Finished)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRecentlyChangedTasks(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewDashboardPostgres(db)
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "status"}).AddRow("1", "title1", "Done")
+	mock.ExpectQuery("SELECT t.\\* FROM tasks t JOIN LATERAL \\(SELECT MAX\\(changed_at\\) AS changed_at FROM task_status_changes "+
+		"WHERE task = t.id AND changed_at >= \\$2\\) h ON h.changed_at IS NOT NULL WHERE t.assignee = \\$1(.+)ORDER BY h.changed_at DESC LIMIT \\$3").
+		WithArgs("user1", since, 10, "org1").WillReturnRows(rows)
+
+	got, err := r.RecentlyChangedTasks(context.Background(), "org1", "user1", since, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "title1", got[0].Title)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestUnsubscribe(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{Secret: "secret"})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(testUserId))
+	link := "/unsubscribe?" + url.Values{"user": {testUserId}, "token": {hex.EncodeToString(mac.Sum(nil))}}.Encode()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		mock   func()
+		status int
+	}{
+		{
+			name:   "Following the link",
+			method: http.MethodGet,
+			path:   link,
+			mock:   func() {},
+			status: http.StatusOK,
+		},
+		{
+			name:   "Forged token",
+			method: http.MethodPost,
+			path:   "/unsubscribe?user=" + testUserId + "&token=forged",
+			mock:   func() {},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Confirmed",
+			method: http.MethodPost,
+			path:   link,
+			mock: func() {
+				mock.ExpectExec("INSERT INTO email_preferences").WithArgs(testUserId, entity.EmailModeOff).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
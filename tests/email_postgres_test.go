@@ -0,0 +1,218 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"testing"
+	"time"
+)
+
+func TestQueueEmail(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewEmailPostgres(db)
+
+	email := entity.Email{UserID: "user1", Recipient: "user1@example.com", Subject: "Task is due soon", TextBody: "text", HTMLBody: "<p>html</p>"}
+	ids := []string{"n1", "n2"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr bool
+	}{
+		{
+			name: "success",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO email_outbox").
+					WithArgs(email.UserID, email.Recipient, email.Subject, email.TextBody, email.HTMLBody).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("UPDATE notifications SET emailed_at = NOW\\(\\) WHERE id = ANY").
+					WithArgs(pq.Array(ids)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "error - notifications are not marked",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO email_outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("UPDATE notifications").WillReturnError(fmt.Errorf("some error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := r.QueueEmail(context.Background(), email, ids)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestClaimEmails(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewEmailPostgres(db)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "success",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"id", "user_id", "recipient", "subject", "text_body", "html_body", "attempts", "next_attempt_at", "sent_at", "last_error", "created_at"}).
+					AddRow("1", "user1", "user1@example.com", "Task is due soon", "text", "<p>html</p>", 0, time.Now(), nil, nil, time.Now()).
+					AddRow("2", "user2", "user2@example.com", "Your daily summary", "text", "<p>html</p>", 2, time.Now(), nil, "timeout", time.Now())
+				mock.ExpectQuery("UPDATE email_outbox SET next_attempt_at = \\$1(.|\\n)*FOR UPDATE SKIP LOCKED").
+					WithArgs(sqlmock.AnyArg(), 5, 10).
+					WillReturnRows(rows)
+			},
+			want: 2,
+		},
+		{
+			name: "error - database error",
+			mock: func() {
+				mock.ExpectQuery("UPDATE email_outbox").WillReturnError(fmt.Errorf("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.ClaimEmails(context.Background(), 10, 5, time.Minute)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestSetEmailMode(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewEmailPostgres(db)
+
+	mock.ExpectExec("INSERT INTO email_preferences \\(user_id, mode\\)(.|\\n)*ON CONFLICT \\(user_id\\) DO UPDATE").
+		WithArgs("user1", entity.EmailModeDigest).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, r.SetEmailMode(context.Background(), "user1", entity.EmailModeDigest))
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestLastDigest(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewEmailPostgres(db)
+	at := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT queued_at FROM email_digests").
+		WillReturnRows(sqlmock.NewRows([]string{"queued_at"}).AddRow(at))
+	mock.ExpectExec("INSERT INTO email_digests \\(queued_at\\) VALUES \\(\\$1\\)(.|\\n)*ON CONFLICT \\(id\\) DO UPDATE").
+		WithArgs(at.Add(24 * time.Hour)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	got, err := r.GetLastDigest(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, at, got)
+	assert.NoError(t, r.SetLastDigest(context.Background(), at.Add(24*time.Hour)))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestQueueDigestEmails(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	emails := service.NewEmailService(repository.NewEmailPostgres(db), nil, service.EmailConfig{BaseURL: "http://localhost:8080"})
+
+	pending := func(counts map[string]int, users ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id", "user_id", "type", "message", "created_at", "user_name", "user_email"})
+		for _, user := range users {
+			for i := 0; i < counts[user]; i++ {
+				rows.AddRow(fmt.Sprintf("%s-n%d", user, i), user, entity.NotificationAssigned, "You were assigned to task \"Login\"", time.Now(), user, user+"@example.com")
+			}
+		}
+		return rows
+	}
+	expectQueued := func(user string, notifications int) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO email_outbox").WithArgs(user, user+"@example.com", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE notifications SET emailed_at = NOW\\(\\) WHERE id = ANY\\(\\$1\\)").
+			WillReturnResult(sqlmock.NewResult(0, int64(notifications)))
+		mock.ExpectCommit()
+	}
+
+	// The first batch is full, so user2 may have more notifications past it
+	// and is left for the second batch, which is the last one.
+	mock.ExpectQuery("SELECT queued_at FROM email_digests").
+		WillReturnRows(sqlmock.NewRows([]string{"queued_at"}).AddRow(time.Now().Add(-25 * time.Hour)))
+	mock.ExpectQuery("FROM notifications n").WithArgs(entity.EmailModeDigest, sqlmock.AnyArg(), entity.EmailModeInstant, 1000).
+		WillReturnRows(pending(map[string]int{"user1": 600, "user2": 400}, "user1", "user2"))
+	expectQueued("user1", 600)
+	mock.ExpectQuery("FROM notifications n").WithArgs(entity.EmailModeDigest, sqlmock.AnyArg(), entity.EmailModeInstant, 1000).
+		WillReturnRows(pending(map[string]int{"user2": 450, "user3": 1}, "user2", "user3"))
+	expectQueued("user2", 450)
+	expectQueued("user3", 1)
+	mock.ExpectExec("INSERT INTO email_digests").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	queued, err := emails.QueueDigestEmails(context.Background(), 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, queued)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
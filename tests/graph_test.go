@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func graphQuery(t *testing.T, server *graph.Server, query string) map[string]interface{} {
+	body, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(graph.WithOrganization(req.Context(), "org1"))
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+	return response
+}
+
+func TestGraphQLBatchesRelations(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Assignees and projects are loaded concurrently.
+	mock.MatchExpectationsInOrder(false)
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	server := graph.NewServer(services, graph.Limits{})
+
+	tasks := sqlmock.NewRows([]string{"id", "title", "status", "assignee", "project"}).
+		AddRow("t1", "Landing page", "Done", "u1", "p1").
+		AddRow("t2", "Login screen", "In Progress", "u2", "p2").
+		AddRow("t3", "Push notifications", "Not Started", "u2", "p2")
+	mock.ExpectQuery("SELECT \\* FROM tasks WHERE project IN \\(SELECT id FROM projects WHERE organization = \\$1 AND NOT is_template AND finished_at IS NULL\\)").
+		WithArgs("org1").WillReturnRows(tasks)
+
+	users := sqlmock.NewRows([]string{"id", "name"}).AddRow("u1", "Aya").AddRow("u2", "Dana")
+	mock.ExpectQuery("SELECT \\* FROM users WHERE id = ANY\\(\\$1\\) AND id IN \\(SELECT user_id FROM memberships WHERE organization = \\$2\\)").
+		WithArgs(sqlmock.AnyArg(), "org1").WillReturnRows(users)
+
+	projects := sqlmock.NewRows([]string{"id", "title", "manager", "organization"}).
+		AddRow("p1", "Website", "u1", "org1").
+		AddRow("p2", "Mobile app", "u1", "org1")
+	mock.ExpectQuery("SELECT \\* FROM projects WHERE id = ANY\\(\\$1\\) AND organization = \\$2").
+		WithArgs(sqlmock.AnyArg(), "org1").WillReturnRows(projects)
+
+	response := graphQuery(t, server, `{ tasks { title assignee { name } project { title } } }`)
+	assert.Nil(t, response["errors"])
+
+	want := `{"tasks":[` +
+		`{"title":"Landing page","assignee":{"name":"Aya"},"project":{"title":"Website"}},` +
+		`{"title":"Login screen","assignee":{"name":"Dana"},"project":{"title":"Mobile app"}},` +
+		`{"title":"Push notifications","assignee":{"name":"Dana"},"project":{"title":"Mobile app"}}]}`
+	got, _ := json.Marshal(response["data"])
+	assert.JSONEq(t, want, string(got))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGraphQLComplexityLimit(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	server := graph.NewServer(services, graph.Limits{Complexity: 100})
+
+	response := graphQuery(t, server, `{ projects(first: 50) { tasks(first: 50) { title } } }`)
+	if assert.NotNil(t, response["errors"]) {
+		assert.Contains(t, response["errors"].([]interface{})[0].(map[string]interface{})["message"], "exceeds the limit of 100")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
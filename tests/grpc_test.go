@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/grpcserver"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	pmv1 "github.com/Aytya/projects-manager-HL/pkg/api/pm/v1"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+)
+
+func grpcClient(t *testing.T, services *service.Service, feed *service.TaskFeed, token string) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpcserver.New(services, feed, token)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func expectOrganization(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT \\* FROM organizations WHERE slug = \\$1").WithArgs("acme").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug"}).AddRow("org1", "Acme", "acme"))
+}
+
+// testUserId is the caller of the HTTP tests, a member of org1.
+const testUserId = "5d41402a-bc4b-4a76-b971-9d911017c592"
+
+func expectMember(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT \\* FROM memberships WHERE organization = \\$1 AND user_id = \\$2").WithArgs("org1", testUserId).
+		WillReturnRows(sqlmock.NewRows([]string{"organization", "user_id", "role"}).AddRow("org1", testUserId, "member"))
+}
+
+func TestGRPCErrors(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	client := pmv1.NewProjectServiceClient(grpcClient(t, services, service.NewTaskFeed(), "secret"))
+
+	tests := []struct {
+		name     string
+		mock     func()
+		metadata []string
+		code     codes.Code
+	}{
+		{
+			name:     "Missing token",
+			mock:     func() {},
+			metadata: []string{"x-organization", "acme"},
+			code:     codes.Unauthenticated,
+		},
+		{
+			name: "Unknown organization",
+			mock: func() {
+				mock.ExpectQuery("SELECT \\* FROM organizations WHERE slug = \\$1").WithArgs("nope").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			metadata: []string{"authorization", "Bearer secret", "x-organization", "nope"},
+			code:     codes.NotFound,
+		},
+		{
+			name: "Project of another organization",
+			mock: func() {
+				expectOrganization(mock)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM projects WHERE organization = \\$1 AND id = \\$2\\)").
+					WithArgs("org1", "8f14e45f-ceea-4672-9bc5-1f0b2c5c1e3a").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			metadata: []string{"authorization", "Bearer secret", "x-organization", "acme"},
+			code:     codes.NotFound,
+		},
+		{
+			name: "Invalid user",
+			mock: func() {
+				expectOrganization(mock)
+			},
+			metadata: []string{"authorization", "Bearer secret", "x-organization", "acme", "x-user-id", "42"},
+			code:     codes.Unauthenticated,
+		},
+		{
+			name: "User of another organization",
+			mock: func() {
+				expectOrganization(mock)
+				mock.ExpectQuery("SELECT \\* FROM memberships WHERE organization = \\$1 AND user_id = \\$2").
+					WithArgs("org1", testUserId).WillReturnRows(sqlmock.NewRows([]string{"organization", "user_id", "role"}))
+			},
+			metadata: []string{"authorization", "Bearer secret", "x-organization", "acme", "x-user-id", testUserId},
+			code:     codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			ctx := metadata.AppendToOutgoingContext(context.Background(), tt.metadata...)
+			_, err := client.GetProject(ctx, &pmv1.GetProjectRequest{Id: "8f14e45f-ceea-4672-9bc5-1f0b2c5c1e3a"})
+			assert.Equal(t, tt.code, status.Code(err))
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestGRPCWithoutToken(t *testing.T) {
+	db, _, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	client := pmv1.NewProjectServiceClient(grpcClient(t, services, service.NewTaskFeed(), ""))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-organization", "acme")
+	_, err = client.GetProject(ctx, &pmv1.GetProjectRequest{Id: "8f14e45f-ceea-4672-9bc5-1f0b2c5c1e3a"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGRPCWatchTasks(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	feed := service.NewTaskFeed()
+	client := pmv1.NewTaskServiceClient(grpcClient(t, services, feed, "secret"))
+
+	expectOrganization(mock)
+	mock.ExpectQuery("SELECT \\* FROM tasks WHERE id = \\$1").WithArgs("t2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "project"}).AddRow("t2", "Login screen", "Done", "p1"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret", "x-organization", "acme")
+
+	stream, err := client.WatchTasks(ctx, &pmv1.WatchTasksRequest{Project: "p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subscription is registered once the stream passes authentication.
+	go func() {
+		for ctx.Err() == nil {
+			feed.Publish(entity.TaskEvent{Type: entity.TaskDeleted, ID: "t1", Project: "p1", Organization: "org2"})
+			feed.Publish(entity.TaskEvent{Type: entity.TaskUpdated, ID: "t9", Project: "p9", Organization: "org1"})
+			feed.Publish(entity.TaskEvent{Type: entity.TaskUpdated, ID: "t2", Project: "p1", Organization: "org1"})
+			time.Sleep(50 * time.Millisecond)
+		}
+	}()
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	assert.Equal(t, pmv1.TaskEvent_TYPE_UPDATED, event.Type)
+	assert.Equal(t, "t2", event.TaskId)
+	assert.Equal(t, "Login screen", event.Task.Title)
+	assert.Equal(t, "Done", event.Task.Status)
+}
+
+func TestGRPCWatchTasksShutdown(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	feed := service.NewTaskFeed()
+	client := pmv1.NewTaskServiceClient(grpcClient(t, services, feed, "secret"))
+
+	expectOrganization(mock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret", "x-organization", "acme")
+
+	stream, err := client.WatchTasks(ctx, &pmv1.WatchTasksRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed.Close()
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+	router := handler.NewHandler(&service.Service{}, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, w.Body.String())
+}
+
+func TestReadiness(t *testing.T) {
+	db, mock, err := sqlmock.Newx(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	services.Health.Go(context.Background(), "crashed", func(ctx context.Context) {})
+	services.Health.Wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	services.Health.Go(ctx, "running", func(ctx context.Context) { <-ctx.Done() })
+
+	expectSchemaVersion := func(version int) {
+		mock.ExpectPing()
+		mock.ExpectQuery(`SELECT version FROM schema_version`).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version))
+	}
+
+	tests := []struct {
+		name       string
+		mock       func()
+		drain      bool
+		status     int
+		components map[string]string
+	}{
+		{
+			name: "Database is down",
+			mock: func() {
+				mock.ExpectPing().WillReturnError(fmt.Errorf("connection refused"))
+			},
+			status: http.StatusServiceUnavailable,
+			components: map[string]string{
+				entity.ComponentDatabase:   "connection refused",
+				entity.ComponentMigrations: "database unavailable",
+			},
+		},
+		{
+			name:   "Migrations are missing",
+			mock:   func() { expectSchemaVersion(repository.SchemaVersion() - 1) },
+			status: http.StatusServiceUnavailable,
+			components: map[string]string{
+				entity.ComponentMigrations: fmt.Sprintf("schema version %d, want %d", repository.SchemaVersion()-1, repository.SchemaVersion()),
+			},
+		},
+		{
+			name:   "Shutting down",
+			mock:   func() { expectSchemaVersion(repository.SchemaVersion()) },
+			drain:  true,
+			status: http.StatusServiceUnavailable,
+			components: map[string]string{
+				entity.ComponentShutdown: "shutting down",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			if tt.drain {
+				services.Health.Drain()
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tt.status, w.Code)
+
+			var readiness entity.Readiness
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &readiness))
+			assert.Equal(t, entity.HealthFailing, readiness.Status)
+
+			details := map[string]string{}
+			for _, component := range readiness.Components {
+				details[component.Name] = component.Detail
+			}
+			// The crashed worker fails readiness in every case.
+			tt.components[entity.ComponentWorkers] = "stopped: crashed"
+			for name := range details {
+				assert.Equal(t, tt.components[name], details[name], name)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
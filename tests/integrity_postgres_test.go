@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"testing"
+)
+
+// integrityQueries match the find and fix statements of each check, in the
+// order the repository runs them.
+var integrityQueries = []struct {
+	check string
+	find  string
+	fix   string
+}{
+	{entity.CheckManagerNotMember, `SELECT p\.id, 'manager ' .* FROM projects p WHERE NOT EXISTS`, `INSERT INTO memberships .* FROM projects p`},
+	{entity.CheckAssigneeNotMember, `SELECT t\.id, 'assignee ' .* FROM tasks t JOIN projects p`, `UPDATE tasks t SET assignee = p\.manager`},
+	{entity.CheckUserWithoutOrg, `SELECT u\.id, u\.email .* FROM users u`, `INSERT INTO memberships .* WHERE o\.slug = 'default'`},
+	{entity.CheckArchivedOpenTask, `SELECT t\.id, 'project ' .* WHERE p\.finished_at IS NOT NULL AND t\.finished_at IS NULL`, `UPDATE tasks t SET finished_at = p\.finished_at`},
+	{entity.CheckForeignSprint, `JOIN sprints s ON s\.id = t\.sprint_id`, `UPDATE tasks t SET sprint_id = NULL`},
+	{entity.CheckForeignMilestone, `JOIN milestones ms ON ms\.id = t\.milestone_id`, `UPDATE tasks t SET milestone_id = NULL`},
+	{entity.CheckOrphanCalendarLink, `FROM calendar_tokens c WHERE`, `DELETE FROM calendar_tokens c WHERE`},
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	defer db.Close()
+
+	r := repository.NewIntegrityPostgres(db)
+
+	for _, query := range integrityQueries {
+		rows := sqlmock.NewRows([]string{"id", "detail"})
+		switch query.check {
+		case entity.CheckAssigneeNotMember:
+			rows.AddRow("task1", "assignee user2 is not a member of organization org1")
+		case entity.CheckArchivedOpenTask:
+			rows.AddRow("task2", "project project1 is finished but the task is still In Progress")
+		}
+		mock.ExpectQuery(query.find).WillReturnRows(rows)
+	}
+
+	got, err := r.CheckIntegrity(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.Inconsistency{
+		{Check: entity.CheckAssigneeNotMember, ID: "task1", Detail: "assignee user2 is not a member of organization org1"},
+		{Check: entity.CheckArchivedOpenTask, ID: "task2", Detail: "project project1 is finished but the task is still In Progress"},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFixIntegrity(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	defer db.Close()
+
+	r := repository.NewIntegrityPostgres(db)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []entity.IntegrityFix
+		wantErr bool
+	}{
+		{
+			name: "success",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`SET LOCAL pm\.maintenance = 'on'`).WillReturnResult(sqlmock.NewResult(0, 0))
+				for i, query := range integrityQueries {
+					mock.ExpectExec(query.fix).WillReturnResult(sqlmock.NewResult(0, int64(i%2)))
+				}
+				mock.ExpectCommit()
+			},
+			want: []entity.IntegrityFix{
+				{Check: entity.CheckManagerNotMember, Fixed: 0},
+				{Check: entity.CheckAssigneeNotMember, Fixed: 1},
+				{Check: entity.CheckUserWithoutOrg, Fixed: 0},
+				{Check: entity.CheckArchivedOpenTask, Fixed: 1},
+				{Check: entity.CheckForeignSprint, Fixed: 0},
+				{Check: entity.CheckForeignMilestone, Fixed: 1},
+				{Check: entity.CheckOrphanCalendarLink, Fixed: 0},
+			},
+		},
+		{
+			name: "failed repair rolls back",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`SET LOCAL pm\.maintenance = 'on'`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(integrityQueries[0].fix).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(integrityQueries[1].fix).WillReturnError(fmt.Errorf("some error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.FixIntegrity(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
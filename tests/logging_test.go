@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/logging"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestLogging(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var logs bytes.Buffer
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, logging.New(&logs, "json", "info")).InitRoutes()
+
+	tests := []struct {
+		name         string
+		organization string
+		requestId    string
+		mock         func()
+		status       int
+		level        string
+	}{
+		{
+			name:         "Request id is taken from the client",
+			organization: "acme",
+			requestId:    "req-42",
+			mock: func() {
+				expectOrganization(mock)
+				expectMember(mock)
+				mock.ExpectQuery("SELECT \\* FROM users WHERE").WithArgs("org1").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}))
+			},
+			status: http.StatusOK,
+			level:  "INFO",
+		},
+		{
+			name:         "Invalid request id is replaced",
+			organization: "missing",
+			requestId:    "bad id\n",
+			mock: func() {
+				mock.ExpectQuery(`SELECT \* FROM organizations WHERE slug = \$1`).WithArgs("missing").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug"}))
+			},
+			status: http.StatusNotFound,
+			level:  "WARN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			tt.mock()
+
+			req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+			req.Header.Set("X-Organization", tt.organization)
+			req.Header.Set("X-User-ID", testUserId)
+			req.Header.Set("X-Request-ID", tt.requestId)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			id := w.Header().Get("X-Request-ID")
+			if tt.status == http.StatusOK {
+				assert.Equal(t, tt.requestId, id)
+			} else {
+				assert.Len(t, id, 36)
+			}
+
+			var line map[string]interface{}
+			assert.NoError(t, json.Unmarshal(logs.Bytes(), &line))
+			assert.Equal(t, tt.level, line["level"])
+			assert.Equal(t, id, line["request_id"])
+			assert.Equal(t, "GET", line["method"])
+			assert.Equal(t, "/users/", line["route"])
+			assert.Equal(t, float64(tt.status), line["status"])
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	var logs bytes.Buffer
+	// Services without repositories make every route panic.
+	router := handler.NewHandler(&service.Service{}, "", graph.Limits{}, logging.New(&logs, "json", "info")).InitRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+	req.Header.Set("X-User-ID", testUserId)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error": "internal server error"}`, w.Body.String())
+	assert.Contains(t, logs.String(), "panic while handling request")
+	assert.Contains(t, logs.String(), `"status":500`)
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"bufio"
+	"github.com/Aytya/projects-manager-HL/internal/mailer"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStub is a minimal in-process SMTP server that accepts one message per
+// connection and hands the raw DATA section to the test.
+type smtpStub struct {
+	listener net.Listener
+	messages chan string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start smtp stub: %v", err)
+	}
+
+	stub := &smtpStub{listener: listener, messages: make(chan string, 1)}
+	go stub.serve()
+	t.Cleanup(func() { listener.Close() })
+	return stub
+}
+
+func (s *smtpStub) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func (s *smtpStub) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStub) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 stub ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 stub")
+		case strings.HasPrefix(command, "MAIL"), strings.HasPrefix(command, "RCPT"), strings.HasPrefix(command, "RSET"), strings.HasPrefix(command, "NOOP"):
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.messages <- data.String()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPTransportSend(t *testing.T) {
+	stub := newSMTPStub(t)
+	transport := mailer.NewSMTPTransport(mailer.Config{Host: "127.0.0.1", Port: stub.port(), From: "no-reply@example.com"})
+
+	message, err := mailer.Render("task_assigned", "user1@example.com", mailer.Data{
+		Name:           "Alice",
+		Items:          []mailer.Item{{Message: "You were assigned to task \"Write docs\"", TaskURL: "http://localhost:8080/tasks/1", CreatedAt: time.Now()}},
+		UnsubscribeURL: "http://localhost:8080/unsubscribe?user=1&token=abc",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, transport.Send(message))
+
+	select {
+	case data := <-stub.messages:
+		assert.Contains(t, data, "To: user1@example.com")
+		assert.Contains(t, data, "Subject: You were assigned a task")
+		assert.Contains(t, data, "multipart/alternative")
+		assert.Contains(t, data, "Content-Type: text/plain")
+		assert.Contains(t, data, "Content-Type: text/html")
+		assert.Contains(t, data, "Write docs")
+	case <-time.After(5 * time.Second):
+		t.Fatal("smtp stub did not receive the message")
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	message, err := mailer.Render(mailer.DigestTemplate, "user1@example.com", mailer.Data{
+		Name: "Alice",
+		Items: []mailer.Item{
+			{Message: "Task \"Write docs\" is due 2024-08-01 10:00", CreatedAt: time.Now()},
+			{Message: "Task \"<b>Deploy</b>\" changed status", TaskURL: "http://localhost:8080/tasks/2", CreatedAt: time.Now()},
+		},
+		UnsubscribeURL: "http://localhost:8080/unsubscribe?user=1&token=abc",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Your daily summary", message.Subject)
+	assert.Contains(t, message.Text, "Write docs")
+	assert.Contains(t, message.Text, "http://localhost:8080/unsubscribe?user=1&token=abc")
+	assert.Contains(t, message.HTML, "&lt;b&gt;Deploy&lt;/b&gt;")
+
+	_, err = mailer.Render("unknown", "user1@example.com", mailer.Data{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/metrics"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	expectOrganization(mock)
+	expectMember(mock)
+	mock.ExpectQuery("SELECT \\* FROM users WHERE").WithArgs("org1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+	req.Header.Set("X-Organization", "acme")
+	req.Header.Set("X-User-ID", testUserId)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/no/such/route", nil))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",route="/users/",status="200"}`)
+	assert.Contains(t, w.Body.String(), `http_request_duration_seconds_count{method="GET",route="/users/",status="200"}`)
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",route="unmatched",status="404"}`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsageCollector(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT t\.status, t\.priority, COUNT\(\*\) AS count FROM tasks t\s+JOIN projects p ON p\.id = t\.project\s+WHERE t\.finished_at IS NULL AND p\.finished_at IS NULL AND NOT p\.is_template`).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "priority", "count"}).
+			AddRow("In Progress", "High", 3).
+			AddRow("Not Started", "Low", 5))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM projects WHERE finished_at IS NULL AND NOT is_template`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	collector := metrics.NewUsageCollector(service.NewDashboardService(repository.NewDashboardPostgres(db)), time.Second)
+	expected := `
+# HELP pm_active_projects Unfinished projects, templates left out.
+# TYPE pm_active_projects gauge
+pm_active_projects 2
+# HELP pm_open_tasks Open tasks of unfinished projects by status and priority, templates left out.
+# TYPE pm_open_tasks gauge
+pm_open_tasks{priority="High",status="In Progress"} 3
+pm_open_tasks{priority="Low",status="Not Started"} 5
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
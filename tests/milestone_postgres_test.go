@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"testing"
+	"time"
+)
+
+func TestGetMilestonesByProject(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewMilestonePostgres(db)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "success",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"id", "project", "title", "description", "target_at", "created_at", "total", "done", "last_finished_at"}).
+					AddRow("1", "project1", "Release 2.3", "", time.Now().AddDate(0, 0, 14), time.Now(), 10, 4, time.Now()).
+					AddRow("2", "project1", "Release 2.4", "", time.Now().AddDate(0, 1, 0), time.Now(), 0, 0, nil)
+				mock.ExpectQuery("SELECT m.\\*, COUNT\\(t.id\\) AS total(.|\\n)*WHERE m.project = \\$1 GROUP BY m.id ORDER BY m.target_at").
+					WithArgs("project1").
+					WillReturnRows(rows)
+			},
+			want: 2,
+		},
+		{
+			name: "error - database error",
+			mock: func() {
+				mock.ExpectQuery("SELECT m.\\*").WillReturnError(fmt.Errorf("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.GetMilestonesByProject(context.Background(), "project1")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.want)
+				assert.Equal(t, 10, got[0].Total)
+				assert.Equal(t, 4, got[0].Done)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRemoveMilestoneTask(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewMilestonePostgres(db)
+
+	mock.ExpectExec("UPDATE tasks SET milestone_id = NULL WHERE id = \\$1 AND milestone_id = \\$2").
+		WithArgs("task1", "milestone1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	removed, err := r.RemoveMilestoneTask(context.Background(), "milestone1", "task1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMilestoneProgressIsPercent(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+
+	mock.ExpectQuery("SELECT m.\\*, COUNT\\(t.id\\) AS total(.|\\n)*WHERE m.id = \\$1 GROUP BY m.id").
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project", "title", "description", "target_at", "created_at", "total", "done", "last_finished_at"}).
+			AddRow("1", "project1", "Release 2.3", "", time.Now().AddDate(0, 0, 14), time.Now(), 3, 1, time.Now()))
+
+	got, err := services.Milestone.GetMilestone(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.Equal(t, 33.3, got.Progress)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"testing"
+	"time"
+)
+
+func TestCreateNotification(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewNotificationPostgres(db)
+
+	input := entity.Notification{
+		UserID:  "user1",
+		Type:    entity.NotificationAssigned,
+		Task:    sql.NullString{String: "task1", Valid: true},
+		Message: "You were assigned to task \"Write Unit Tests 1\"",
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "success",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow("1", time.Now())
+				mock.ExpectQuery("INSERT INTO notifications \\(user_id, type, task, message\\)").
+					WithArgs(input.UserID, input.Type, input.Task, input.Message).
+					WillReturnRows(rows)
+			},
+			want: "1",
+		},
+		{
+			name: "error - database error",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO notifications").WillReturnError(fmt.Errorf("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, _, err := r.CreateNotification(context.Background(), input)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestGetNotifications(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewNotificationPostgres(db)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "type", "message"}).
+		AddRow("1", "user1", entity.NotificationDueSoon, "Task \"Release\" is due 2024-07-29 15:00")
+	mock.ExpectQuery("SELECT \\* FROM notifications WHERE user_id = \\$1 AND read_at IS NULL ORDER BY created_at DESC LIMIT \\$2 OFFSET \\$3").
+		WithArgs("user1", 50, 0).WillReturnRows(rows)
+
+	got, err := r.GetNotifications(context.Background(), "user1", true, 50, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, entity.NotificationDueSoon, got[0].Type)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMarkAllRead(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewNotificationPostgres(db)
+
+	mock.ExpectExec("UPDATE notifications SET read_at = NOW\\(\\) WHERE user_id = \\$1 AND read_at IS NULL").
+		WithArgs("user1").WillReturnResult(sqlmock.NewResult(0, 3))
+
+	got, err := r.MarkAllRead(context.Background(), "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestBulkAndSeriesNotifications(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+
+	expectNotification := func(userId, kind, taskId, message string) {
+		mock.ExpectQuery("SELECT type, enabled FROM notification_preferences WHERE user_id = \\$1").WithArgs(userId).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "enabled"}))
+		mock.ExpectQuery("INSERT INTO notifications \\(user_id, type, task, message\\)").
+			WithArgs(userId, kind, sql.NullString{String: taskId, Valid: true}, message).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("notification1", time.Now()))
+	}
+
+	t.Run("Bulk update", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM tasks WHERE id = \\$1").WithArgs("task1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "assignee"}).AddRow("task1", "Login", "Not Started", "user1"))
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE tasks SET").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("RELEASE SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		expectNotification("user2", entity.NotificationAssigned, "task1", "You were assigned to task \"Login\"")
+		expectNotification("user2", entity.NotificationStatusChanged, "task1", "Status of task \"Login\" changed from \"Not Started\" to \"In Progress\"")
+
+		items := []entity.BulkTaskItem{{Op: entity.BulkUpdate, ID: "task1", Task: entity.Task{Status: "In Progress", Assignee: "user2"}}}
+		results, err := services.Task.BulkTasks(context.Background(), items, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []entity.BulkTaskResult{{Index: 0, Op: entity.BulkUpdate, ID: "task1"}}, results)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Series occurrence", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM task_series WHERE trigger = \\$1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "rule", "trigger", "start_at", "next_at", "title", "description", "priority", "status", "assignee", "project", "custom_fields"}).
+				AddRow("series1", "FREQ=DAILY", entity.RecurOnSchedule, time.Now(), time.Now(), "Standup", "", "High", "Not Started", "user1", "project1", []byte("{}")))
+		mock.ExpectQuery("SELECT \\* FROM custom_fields WHERE project = \\$1").WithArgs("project1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "project", "name", "type", "options", "required", "created_at"}))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE task_series SET next_at = \\$1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO tasks").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("task2"))
+		mock.ExpectCommit()
+		expectNotification("user1", entity.NotificationAssigned, "task2", "You were assigned to task \"Standup\"")
+		mock.ExpectQuery("SELECT \\* FROM task_series WHERE trigger = \\$1").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		created, err := services.Series.GenerateScheduled(context.Background(), time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, created)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,203 @@
+package tests
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOrganizationAccess(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	taskId := "0cc175b9-c0f1-4b6a-831c-399e26977266"
+	projectId := "92eb5ffe-e0b4-4e1f-a7b4-1cd0a1f2e7a3"
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		user   string
+		mock   func()
+		status int
+	}{
+		{
+			name:   "Caller without identity",
+			method: http.MethodGet,
+			path:   "/projects/",
+			mock:   func() {},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "Invalid caller id",
+			method: http.MethodGet,
+			path:   "/projects/",
+			user:   "42",
+			mock:   func() {},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "Caller of another organization",
+			method: http.MethodGet,
+			path:   "/projects/",
+			user:   testUserId,
+			mock: func() {
+				expectOrganization(mock)
+				mock.ExpectQuery("SELECT \\* FROM memberships WHERE organization = \\$1 AND user_id = \\$2").
+					WithArgs("org1", testUserId).WillReturnRows(sqlmock.NewRows([]string{"organization", "user_id", "role"}))
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Task of another organization",
+			method: http.MethodPut,
+			path:   "/tasks/" + taskId,
+			body:   `{"title": "Taken over"}`,
+			user:   testUserId,
+			mock: func() {
+				expectOrganization(mock)
+				expectMember(mock)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM tasks WHERE id = \\$2").
+					WithArgs("org1", taskId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "Project of another organization",
+			method: http.MethodDelete,
+			path:   "/projects/" + projectId,
+			user:   testUserId,
+			mock: func() {
+				expectOrganization(mock)
+				expectMember(mock)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM projects WHERE organization = \\$1 AND id = \\$2\\)").
+					WithArgs("org1", projectId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("X-Organization", "acme")
+			if tt.user != "" {
+				req.Header.Set("X-User-ID", tt.user)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSharedUser(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	userId := "c4ca4238-a0b9-4382-8dcc-509a6f75849b"
+	membership := func(userId, role string) {
+		rows := sqlmock.NewRows([]string{"organization", "user_id", "role"})
+		if role != "" {
+			rows.AddRow("org1", userId, role)
+		}
+		mock.ExpectQuery("SELECT \\* FROM memberships WHERE organization = \\$1 AND user_id = \\$2").
+			WithArgs("org1", userId).WillReturnRows(rows)
+	}
+	request := func(role string) {
+		expectOrganization(mock)
+		membership(testUserId, role)
+		mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM memberships WHERE organization = \\$1 AND user_id = \\$2\\)").
+			WithArgs("org1", userId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		membership(testUserId, role)
+	}
+	organizations := func() {
+		mock.ExpectQuery("SELECT o\\.\\*, m\\.role FROM organizations o JOIN memberships m").WithArgs(userId).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "role"}).
+				AddRow("org1", "Acme", "acme", "member").
+				AddRow("org2", "Globex", "globex", "member"))
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		mock    func()
+		status  int
+		message string
+	}{
+		{
+			name:   "Members do not delete others",
+			method: http.MethodDelete,
+			mock:   func() { request("member") },
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Admins do not edit profiles of other organizations",
+			method: http.MethodPut,
+			body:   `{"name": "Renamed"}`,
+			mock: func() {
+				request("admin")
+				organizations()
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Deleting a shared user removes the membership",
+			method: http.MethodDelete,
+			mock: func() {
+				request("admin")
+				membership(userId, "member")
+				organizations()
+				mock.ExpectExec("DELETE FROM memberships WHERE organization = \\$1 AND user_id = \\$2").
+					WithArgs("org1", userId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			status:  http.StatusOK,
+			message: "User removed from the organization",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			req := httptest.NewRequest(tt.method, "/users/"+userId, strings.NewReader(tt.body))
+			req.Header.Set("X-Organization", "acme")
+			req.Header.Set("X-User-ID", testUserId)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.message != "" {
+				assert.Contains(t, w.Body.String(), tt.message)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
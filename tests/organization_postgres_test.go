@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"testing"
+	"time"
+)
+
+func TestCreateOrganization(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewOrganizationPostgres(db)
+
+	input := entity.Organization{Name: "Acme", Slug: "acme"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "success",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow("org1", time.Now())
+				mock.ExpectQuery("INSERT INTO organizations \\(name, slug\\)(.+)INSERT INTO memberships \\(organization, user_id, role\\)").
+					WithArgs("Acme", "acme", "user1", entity.OrgRoleOwner).
+					WillReturnRows(rows)
+			},
+			want: "org1",
+		},
+		{
+			name: "error - database error",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO organizations").WillReturnError(fmt.Errorf("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, _, err := r.CreateOrganization(context.Background(), input, "user1")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewOrganizationPostgres(db)
+
+	tests := []struct {
+		name     string
+		resource string
+		mock     func()
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "task of the organization",
+			resource: entity.ResourceTask,
+			mock: func() {
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM tasks WHERE id = \\$2 AND project IN \\(SELECT id FROM projects WHERE organization = \\$1\\)\\)").
+					WithArgs("org1", "id1").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			want: true,
+		},
+		{
+			name:     "user of another organization",
+			resource: entity.ResourceUser,
+			mock: func() {
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM memberships WHERE organization = \\$1 AND user_id = \\$2\\)").
+					WithArgs("org1", "id1").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			want: false,
+		},
+		{
+			name:     "unknown resource",
+			resource: "comment",
+			mock:     func() {},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.Contains(context.Background(), "org1", tt.resource, "id1")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestGetMembers(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewOrganizationPostgres(db)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "role", "org_role"}).
+		AddRow("user1", "User1", "user1@test.com", "Developer", entity.OrgRoleAdmin)
+	mock.ExpectQuery("SELECT u.\\*, m.role AS org_role, m.joined_at FROM users u JOIN memberships m ON m.user_id = u.id(.+)WHERE m.organization = \\$1").
+		WithArgs("org1").WillReturnRows(rows)
+
+	got, err := r.GetMembers(context.Background(), "org1")
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "Developer", got[0].Role)
+		assert.Equal(t, entity.OrgRoleAdmin, got[0].OrgRole)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/rank"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"strings"
+	"testing"
+)
+
+// migrationStatements runs the migrations against a stub database and
+// returns the statements they executed.
+func migrationStatements(t *testing.T) []string {
+	var statements []string
+	record := sqlmock.QueryMatcherFunc(func(_, actual string) error {
+		statements = append(statements, actual)
+		return nil
+	})
+	db, mock, err := sqlmock.Newx(sqlmock.QueryMatcherOption(record))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	for i := 0; i <= repository.SchemaVersion()*2; i++ {
+		mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	assert.NoError(t, repository.Migrate(db))
+
+	return statements
+}
+
+func migrationStatement(t *testing.T, substr string) string {
+	for _, statement := range migrationStatements(t) {
+		if strings.Contains(statement, substr) {
+			return statement
+		}
+	}
+	t.Fatalf("no migration contains %q", substr)
+	return ""
+}
+
+func TestArchiveTriggerAllowsForeignKeyActions(t *testing.T) {
+	trigger := migrationStatement(t, "freeze_archived_task()")
+
+	// Cascades and SET NULL actions on tasks run as nested triggers.
+	assert.Contains(t, trigger, "IF pg_trigger_depth() = 1 AND")
+}
+
+func TestNotificationMessagesAreText(t *testing.T) {
+	notifications := migrationStatement(t, "CREATE TABLE IF NOT EXISTS notifications")
+
+	assert.Contains(t, notifications, "message TEXT NOT NULL")
+	assert.Contains(t, notifications, "ALTER TABLE notifications ALTER COLUMN message TYPE TEXT")
+}
+
+func TestTasksArePlacedAtTheBottom(t *testing.T) {
+	ranks := migrationStatement(t, "place_task_rank()")
+
+	assert.Contains(t, ranks, "DROP TRIGGER IF EXISTS task_rank_reset ON tasks")
+	assert.Contains(t, ranks, "CREATE TRIGGER task_rank_place BEFORE INSERT OR UPDATE OF status, project ON tasks")
+	assert.Contains(t, ranks, "digits CONSTANT TEXT := '"+rank.Digits+"'")
+	assert.Contains(t, ranks, "RETURN '"+rank.After("")+"'")
+}
+
+func TestPlacedMovesKeepTheirRank(t *testing.T) {
+	ranks := migrationStatement(t, "place_task_rank()")
+
+	// Every column uses the same keys, so a move to another column may pick
+	// the rank the task already had.
+	assert.Equal(t, rank.Spread(3)[1], rank.After(""))
+	assert.Contains(t, ranks, "AND NEW.rank IS NOT DISTINCT FROM OLD.rank AND current_setting('pm.rank_placed', true) IS DISTINCT FROM 'on'")
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestArchiveProjectHandler(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	projectId := "92eb5ffe-e0b4-4e1f-a7b4-1cd0a1f2e7a3"
+	project := func(manager string) {
+		expectOrganization(mock)
+		expectMember(mock)
+		mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM projects WHERE organization = \\$1 AND id = \\$2\\)").
+			WithArgs("org1", projectId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery("SELECT (.+) FROM projects WHERE id = \\$1").WithArgs(projectId).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "manager"}).AddRow(projectId, "Website", manager))
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		mock   func()
+		status int
+	}{
+		{
+			name: "Open status",
+			body: `{"policy": "close", "status": "Open"}`,
+			mock: func() {
+				expectOrganization(mock)
+				expectMember(mock)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM projects WHERE organization = \\$1 AND id = \\$2\\)").
+					WithArgs("org1", projectId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Not the manager",
+			body:   `{"policy": "cancel"}`,
+			mock:   func() { project("c4ca4238-a0b9-4382-8dcc-509a6f75849b") },
+			status: http.StatusForbidden,
+		},
+		{
+			name: "Manager",
+			body: `{"policy": "close", "status": "Cancelled"}`,
+			mock: func() {
+				project(testUserId)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE tasks SET status = \\$1, finished_at = \\$2 WHERE project = \\$3 AND finished_at IS NULL").
+					WithArgs("Cancelled", sqlmock.AnyArg(), projectId).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("UPDATE projects SET finished_at = \\$1 WHERE id = \\$2 AND finished_at IS NULL").
+					WithArgs(sqlmock.AnyArg(), projectId).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			req := httptest.NewRequest(http.MethodPost, "/projects/"+projectId+"/archive", strings.NewReader(tt.body))
+			req.Header.Set("X-Organization", "acme")
+			req.Header.Set("X-User-ID", testUserId)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUpdateArchivedProject(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	projectId := "92eb5ffe-e0b4-4e1f-a7b4-1cd0a1f2e7a3"
+	expectOrganization(mock)
+	expectMember(mock)
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM projects WHERE organization = \\$1 AND id = \\$2\\)").
+		WithArgs("org1", projectId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM memberships WHERE organization = \\$1 AND user_id = \\$2\\)").
+		WithArgs("org1", testUserId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectExec("UPDATE projects SET manager=\\$1 WHERE id = \\$2 AND finished_at IS NULL").
+		WithArgs(testUserId, projectId).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	req := httptest.NewRequest(http.MethodPut, "/projects/"+projectId, strings.NewReader(`{"manager": "`+testUserId+`"}`))
+	req.Header.Set("X-Organization", "acme")
+	req.Header.Set("X-User-ID", testUserId)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,9 +1,13 @@
 package tests
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
 	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	sqlmock "github.com/zhashkevych/go-sqlxmock"
 	"testing"
@@ -31,12 +35,14 @@ func TestCreateProject(t *testing.T) {
 			name: "success",
 			mock: func() {
 				rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now())
-				mock.ExpectQuery(`INSERT INTO projects \(title,description,manager\) VALUES \(\$1,\$2,\$3\) RETURNING id, created_at`).WillReturnRows(rows)
+				mock.ExpectQuery(`INSERT INTO projects \(title,description,manager,organization\) VALUES \(\$1,\$2,\$3,\$4\) RETURNING id, created_at`).
+					WithArgs("New Project 2", "This is a easy project.", "manager1", "org1").WillReturnRows(rows)
 			},
 			input: entity.Project{
-				Title:       "New Project 2",
-				Description: "This is a easy project.",
-				Manager:     "manager1",
+				Title:        "New Project 2",
+				Description:  "This is a easy project.",
+				Manager:      "manager1",
+				Organization: "org1",
 			},
 			want:    "1",
 			wantErr: false,
@@ -70,7 +76,7 @@ func TestCreateProject(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			got, _, err := r.CreateProject(tt.input)
+			got, _, err := r.CreateProject(context.Background(), tt.input)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -125,7 +131,7 @@ func TestGetProjectById(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			got, err := r.GetProjectById(tt.id)
+			got, err := r.GetProjectById(context.Background(), tt.id)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -197,7 +203,7 @@ func TestUpdateProject(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			err := r.UpdateProject(tt.input.ID, tt.input)
+			err := r.UpdateProject(context.Background(), tt.input.ID, tt.input)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -261,7 +267,7 @@ func TestDeleteProjectById(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			err := r.DeleteProject(tt.id)
+			err := r.DeleteProject(context.Background(), tt.id)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -294,7 +300,7 @@ func TestListProjects(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"id", "title", "description", "manager", "created_at"}).
 					AddRow(1, "title1", "description1", "manager1", staticTime).
 					AddRow(2, "title2", "description2", "manager2", staticTime)
-				mock.ExpectQuery("SELECT (.+) FROM projects").WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM projects WHERE organization = \\$1 AND NOT is_template AND finished_at IS NULL").WithArgs("org1").WillReturnRows(rows)
 			},
 			want: []entity.Project{
 				{
@@ -326,7 +332,7 @@ func TestListProjects(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			got, err := r.GetAllProjects()
+			got, err := r.GetAllProjects(context.Background(), "org1", false)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -340,3 +346,237 @@ func TestListProjects(t *testing.T) {
 		})
 	}
 }
+
+func TestCloneProject(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewProjectPostgres(db)
+
+	start := time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC)
+	due := sql.NullTime{Time: start.AddDate(0, 0, 3), Valid: true}
+	clone := entity.ProjectCopy{
+		Project: entity.Project{Title: "Onboarding Acme", Description: "Checklist", Manager: "manager1", Organization: "org1",
+			StartAt: sql.NullTime{Time: start, Valid: true}},
+		Fields:     []entity.CustomField{{ID: "field1", Name: "severity", Type: entity.FieldSelect, Options: entity.FieldOptions{"low", "high"}}},
+		Milestones: []entity.Milestone{{ID: "milestone1", Title: "Kick-off", TargetAt: start.AddDate(0, 0, 7)}},
+		Tasks: []entity.Task{{Title: "Send contract", Description: "Send contract", Priority: "High", Status: "Not Started", Assignee: "user1",
+			DueAt: due, Milestone: sql.NullString{String: "milestone1", Valid: true}, Fields: entity.CustomValues{"field1": "high"}}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO projects \\(title, description, manager, organization, start_at, is_template\\)").
+		WithArgs("Onboarding Acme", "Checklist", "manager1", "org1", clone.Project.StartAt, false).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("project2"))
+	mock.ExpectQuery("INSERT INTO custom_fields").
+		WithArgs("project2", "severity", entity.FieldSelect, sqlmock.AnyArg(), false).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("field2"))
+	mock.ExpectQuery("INSERT INTO milestones").
+		WithArgs("project2", "Kick-off", "", start.AddDate(0, 0, 7)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("milestone2"))
+	mock.ExpectExec("INSERT INTO tasks").
+		WithArgs("Send contract", "Send contract", "High", "Not Started", "user1", "project2", due, sql.NullString{String: "milestone2", Valid: true},
+			[]byte(`{"field2":"high"}`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	got, err := r.CloneProject(context.Background(), clone)
+	assert.NoError(t, err)
+	assert.Equal(t, "project2", got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCloneProjectRestartsTasks(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+
+	start := time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM projects WHERE id = \\$1").WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "manager", "organization", "start_at"}).
+			AddRow("project1", "Onboarding", "manager1", "org1", start))
+	mock.ExpectQuery("SELECT (.+) FROM custom_fields WHERE project = \\$1").WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT m.\\*").WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE project = \\$1").WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "assignee", "project", "finished_at", "rank"}).
+			AddRow("task1", "Send contract", "Done", "user1", "project1", start, "i").
+			AddRow("task2", "Kick-off call", "In Progress", "user1", "project1", nil, "i"))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO projects").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("project2"))
+	for _, title := range []string{"Send contract", "Kick-off call"} {
+		mock.ExpectExec("INSERT INTO tasks \\(title, description, priority, status, assignee, project, due_at, milestone_id, custom_fields\\)").
+			WithArgs(title, "", "", entity.TaskStatusNotStarted, "user1", "project2", sql.NullTime{}, sql.NullString{}, []byte("{}")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT (.+) FROM projects WHERE id = \\$1").WithArgs("project2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow("project2", "Onboarding (copy)"))
+
+	got, err := services.Template.CloneProject(context.Background(), "project1", entity.CloneProject{StartAt: start})
+	assert.NoError(t, err)
+	assert.Equal(t, "project2", got.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveAsTemplate(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+
+	start := time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM projects WHERE id = \\$1").WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "manager", "organization", "start_at"}).
+			AddRow("project1", "Onboarding", "manager1", "org1", start))
+	mock.ExpectQuery("SELECT (.+) FROM custom_fields WHERE project = \\$1").WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT m.\\*").WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE project = \\$1").WithArgs("project1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "assignee", "project", "due_at"}).
+			AddRow("task1", "Send contract", "Not Started", "user1", "project1", start.AddDate(0, 0, 3)))
+
+	templateStart := sql.NullTime{Time: time.Unix(0, 0).UTC(), Valid: true}
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO projects").
+		WithArgs("Onboarding template", "", "manager1", "org1", templateStart, true).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("template1"))
+	mock.ExpectExec("INSERT INTO tasks").
+		WithArgs("Send contract", "", "", entity.TaskStatusNotStarted, "manager1", "template1",
+			sql.NullTime{Time: templateStart.Time.AddDate(0, 0, 3), Valid: true}, sql.NullString{}, []byte("{}")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT (.+) FROM projects WHERE id = \\$1").WithArgs("template1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow("template1", "Onboarding template"))
+
+	got, err := services.Template.SaveAsTemplate(context.Background(), "project1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "template1", got.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCloneProjectRollback(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewProjectPostgres(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO projects").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("project2"))
+	mock.ExpectExec("INSERT INTO tasks").WillReturnError(fmt.Errorf("some error"))
+	mock.ExpectRollback()
+
+	_, err = r.CloneProject(context.Background(), entity.ProjectCopy{Tasks: []entity.Task{{Title: "Send contract"}}})
+	assert.Error(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestArchiveProject(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewProjectPostgres(db)
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int64
+		wantErr error
+	}{
+		{
+			name: "success",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE tasks SET status = \\$1, finished_at = \\$2 WHERE project = \\$3 AND finished_at IS NULL").
+					WithArgs("Cancelled", at, "project1").WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec("UPDATE projects SET finished_at = \\$1 WHERE id = \\$2 AND finished_at IS NULL").
+					WithArgs(at, "project1").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want: 3,
+		},
+		{
+			name: "already archived",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE tasks").WithArgs("Cancelled", at, "project1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("UPDATE projects").WithArgs(at, "project1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: repository.ErrProjectArchived,
+		},
+		{
+			name: "tasks frozen by trigger",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE tasks").WithArgs("Cancelled", at, "project1").
+					WillReturnError(&pq.Error{Code: "PM409", Message: "project is archived"})
+				mock.ExpectRollback()
+			},
+			wantErr: repository.ErrProjectArchived,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.ArchiveProject(context.Background(), "project1", "Cancelled", at)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestUpdateTaskOfArchivedProject(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewTaskPostgres(db)
+
+	mock.ExpectExec("UPDATE tasks SET title = \\$1 WHERE id = \\$2").WithArgs("new title", "task1").
+		WillReturnError(&pq.Error{Code: "PM409", Message: "project is archived"})
+
+	err = r.UpdateTask(context.Background(), "task1", entity.Task{Title: "new title"})
+	assert.ErrorIs(t, err, repository.ErrProjectArchived)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/rank"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		name       string
+		prev, next string
+		wantErr    bool
+	}{
+		{name: "empty column", prev: "", next: ""},
+		{name: "before first", prev: "", next: "i"},
+		{name: "after last", prev: "i", next: ""},
+		{name: "between distant", prev: "a", next: "k"},
+		{name: "between adjacent", prev: "a", next: "b"},
+		{name: "prefix", prev: "a", next: "a01"},
+		{name: "after z", prev: "z", next: ""},
+		{name: "before leading zeros", prev: "", next: "001"},
+		{name: "error - out of order", prev: "k", next: "a", wantErr: true},
+		{name: "error - equal", prev: "k", next: "k", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rank.Between(tt.prev, tt.next)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, rank.ErrOrder)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Greater(t, got, tt.prev)
+			if tt.next != "" {
+				assert.Less(t, got, tt.next)
+			}
+			assert.False(t, strings.HasSuffix(got, "0"))
+		})
+	}
+}
+
+func TestRankRandomInserts(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	keys := []string{}
+
+	for i := 0; i < 2000; i++ {
+		at := random.Intn(len(keys) + 1)
+		prev, next := "", ""
+		if at > 0 {
+			prev = keys[at-1]
+		}
+		if at < len(keys) {
+			next = keys[at]
+		}
+
+		key, err := rank.Between(prev, next)
+		assert.NoError(t, err)
+		keys = append(keys[:at], append([]string{key}, keys[at:]...)...)
+	}
+
+	assert.True(t, sort.StringsAreSorted(keys))
+	for i := 1; i < len(keys); i++ {
+		assert.NotEqual(t, keys[i-1], keys[i])
+	}
+}
+
+func TestRankSpread(t *testing.T) {
+	for _, count := range []int{1, 10, 35, 36, 1000} {
+		keys := rank.Spread(count)
+		assert.Len(t, keys, count)
+		assert.True(t, sort.StringsAreSorted(keys))
+		for i, key := range keys {
+			assert.False(t, strings.HasSuffix(key, "0"))
+			if i > 0 {
+				assert.NotEqual(t, keys[i-1], key)
+				_, err := rank.Between(keys[i-1], key)
+				assert.NoError(t, err)
+			}
+		}
+	}
+}
+
+func TestRankAfter(t *testing.T) {
+	key := rank.After("")
+	assert.Equal(t, "i", key)
+
+	for i := 0; i < 200; i++ {
+		next := rank.After(key)
+		assert.Greater(t, next, key)
+		assert.False(t, strings.HasSuffix(next, "0"))
+		key = next
+	}
+	assert.LessOrEqual(t, len(key), 7)
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"testing"
+	"time"
+)
+
+func TestBurndown(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewReportPostgres(db)
+
+	day1 := time.Date(2024, time.July, 29, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	period := entity.ReportRange{From: day1, To: day2, Interval: entity.IntervalDay}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []entity.BurndownPoint
+		wantErr bool
+	}{
+		{
+			name: "success",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"point", "total", "remaining"}).
+					AddRow(day1, 4, 3).
+					AddRow(day2, 5, 2)
+				mock.ExpectQuery("SELECT p.point(.+)FROM generate_series(.+)LEFT JOIN tasks t ON t.project = \\$1").
+					WithArgs("project1", day1, day2, entity.IntervalDay).
+					WillReturnRows(rows)
+			},
+			want: []entity.BurndownPoint{
+				{Date: day1, Total: 4, Remaining: 3},
+				{Date: day2, Total: 5, Remaining: 2},
+			},
+		},
+		{
+			name: "error - database error",
+			mock: func() {
+				mock.ExpectQuery("SELECT p.point").WillReturnError(fmt.Errorf("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.Burndown(context.Background(), "project1", period)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestThroughput(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewReportPostgres(db)
+
+	week := time.Date(2024, time.July, 29, 0, 0, 0, 0, time.UTC)
+	period := entity.ReportRange{From: week, To: week, Interval: entity.IntervalWeek}
+
+	rows := sqlmock.NewRows([]string{"point", "finished"}).AddRow(week, 7)
+	mock.ExpectQuery("SELECT p.point, COUNT\\(t.id\\) AS finished").
+		WithArgs("project1", week, week, entity.IntervalWeek).
+		WillReturnRows(rows)
+
+	got, err := r.Throughput(context.Background(), "project1", period)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.ThroughputPoint{{Date: week, Finished: 7}}, got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
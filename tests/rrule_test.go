@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/rrule"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "daily", input: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{name: "prefix and lower case", input: "RRULE:freq=weekly;interval=2;byday=mo,th", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH"},
+		{name: "monthly ordinal", input: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", want: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3"},
+		{name: "until date", input: "FREQ=DAILY;UNTIL=20240131", want: "FREQ=DAILY;UNTIL=20240131T235959Z"},
+		{name: "error - missing freq", input: "INTERVAL=2", wantErr: true},
+		{name: "error - yearly", input: "FREQ=YEARLY", wantErr: true},
+		{name: "error - count and until", input: "FREQ=DAILY;COUNT=2;UNTIL=20240131", wantErr: true},
+		{name: "error - weekly ordinal", input: "FREQ=WEEKLY;BYDAY=1MO", wantErr: true},
+		{name: "error - zero interval", input: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{name: "error - unknown part", input: "FREQ=DAILY;BYHOUR=9", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rrule.Parse(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, rrule.ErrInvalidRule)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got.String())
+			}
+		})
+	}
+}
+
+func TestRuleAfter(t *testing.T) {
+	// Monday, 1 January 2024, 09:00 UTC.
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 9, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		after time.Time
+		want  []time.Time
+	}{
+		{name: "daily every other day", rule: "FREQ=DAILY;INTERVAL=2", start: start, after: start, want: []time.Time{day(1, 3), day(1, 5), day(1, 7)}},
+		{name: "weekdays only", rule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", start: start, after: day(1, 4), want: []time.Time{day(1, 5), day(1, 8)}},
+		{name: "weekly on two days", rule: "FREQ=WEEKLY;BYDAY=MO,TH", start: start, after: start, want: []time.Time{day(1, 4), day(1, 8), day(1, 11)}},
+		{name: "biweekly", rule: "FREQ=WEEKLY;INTERVAL=2", start: start, after: start, want: []time.Time{day(1, 15), day(1, 29)}},
+		{name: "monthly on day 31 skips short months", rule: "FREQ=MONTHLY", start: day(1, 31), after: day(1, 31), want: []time.Time{day(3, 31), day(5, 31)}},
+		{name: "last friday of the month", rule: "FREQ=MONTHLY;BYDAY=-1FR", start: start, after: start, want: []time.Time{day(1, 26), day(2, 23), day(3, 29)}},
+		{name: "count includes the start", rule: "FREQ=DAILY;COUNT=3", start: start, after: start, want: []time.Time{day(1, 2), day(1, 3)}},
+		{name: "until is inclusive", rule: "FREQ=WEEKLY;UNTIL=20240115", start: start, after: start, want: []time.Time{day(1, 8), day(1, 15)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := rrule.Parse(tt.rule)
+			assert.NoError(t, err)
+
+			var got []time.Time
+			after := tt.after
+			for len(got) < len(tt.want) {
+				next, ok := rule.After(tt.start, after)
+				if !ok {
+					break
+				}
+				got = append(got, next)
+				after = next
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRuleAfterEnds(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	rule, err := rrule.Parse("FREQ=DAILY;COUNT=2")
+	assert.NoError(t, err)
+
+	next, ok := rule.After(start, start)
+	assert.True(t, ok)
+	assert.Equal(t, start.AddDate(0, 0, 1), next)
+
+	_, ok = rule.After(start, next)
+	assert.False(t, ok)
+}
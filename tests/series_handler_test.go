@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUpdateTaskSeriesForeignAssignee(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	taskId := "92eb5ffe-e0b4-4e1f-a7b4-1cd0a1f2e7a3"
+	assignee := "c4ca4238-a0b9-4382-8dcc-509a6f75849b"
+	expectOrganization(mock)
+	expectMember(mock)
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM tasks WHERE id = \\$2").
+		WithArgs("org1", taskId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM memberships WHERE organization = \\$1 AND user_id = \\$2\\)").
+		WithArgs("org1", assignee).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	req := httptest.NewRequest(http.MethodPut, "/tasks/"+taskId+"/series", strings.NewReader(`{"assignee": "`+assignee+`"}`))
+	req.Header.Set("X-Organization", "acme")
+	req.Header.Set("X-User-ID", testUserId)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
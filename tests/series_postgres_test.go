@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"testing"
+	"time"
+)
+
+func TestCreateOccurrence(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewSeriesPostgres(db)
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	series := entity.TaskSeries{
+		ID:          "series1",
+		Rule:        "FREQ=WEEKLY",
+		Trigger:     entity.RecurOnSchedule,
+		StartAt:     start,
+		NextAt:      sql.NullTime{Time: start.AddDate(0, 0, 7), Valid: true},
+		Title:       "Rotate logs",
+		Description: "Weekly chore",
+		Priority:    "Low",
+		Status:      "Not Started",
+		Assignee:    "user1",
+		Project:     "project1",
+		Fields:      entity.CustomValues{"field1": "high"},
+	}
+	occursAt := series.NextAt.Time
+	next := sql.NullTime{Time: start.AddDate(0, 0, 14), Valid: true}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    string
+		wantErr error
+	}{
+		{
+			name: "success",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE task_series SET next_at = \\$1 WHERE id = \\$2 AND next_at = \\$3").
+					WithArgs(next, series.ID, series.NextAt).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("INSERT INTO tasks \\(title, description, priority, status, assignee, project, due_at, series_id, occurs_at, custom_fields\\)").
+					WithArgs(series.Title, series.Description, series.Priority, series.Status, series.Assignee, series.Project, occursAt, series.ID, []byte(`{"field1":"high"}`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("task2"))
+				mock.ExpectCommit()
+			},
+			want: "task2",
+		},
+		{
+			name: "error - advanced by another worker",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE task_series").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: repository.ErrSeriesChanged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.CreateOccurrence(context.Background(), series, occursAt, next)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestUpdateSeries(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewSeriesPostgres(db)
+
+	from := time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)
+	series := entity.TaskSeries{ID: "series1", Rule: "FREQ=WEEKLY", Trigger: entity.RecurOnFinish, StartAt: from, Title: "Rotate all logs", Assignee: "user2"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE task_series SET rule = \\$1").
+		WithArgs(series.Rule, series.Trigger, series.StartAt, series.NextAt, series.Title, series.Description, series.Priority, series.Status, series.Assignee, series.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE tasks SET title = \\$1, assignee = \\$2 WHERE series_id = \\$3 AND occurs_at >= \\$4 AND finished_at IS NULL").
+		WithArgs("Rotate all logs", "user2", series.ID, from).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	err = r.UpdateSeries(context.Background(), series, from, entity.Task{Title: "Rotate all logs", Assignee: "user2"})
+	assert.NoError(t, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// upcoming matches a next_at argument that lies ahead but within a day.
+type upcoming struct{}
+
+func (upcoming) Match(v driver.Value) bool {
+	next, ok := v.(sql.NullTime)
+	if !ok {
+		value, _ := v.(time.Time)
+		next = sql.NullTime{Time: value, Valid: !value.IsZero()}
+	}
+	return next.Valid && next.Time.After(time.Now()) && next.Time.Before(time.Now().AddDate(0, 0, 1))
+}
+
+func TestSetRecurrenceSkipsPastDates(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+
+	due := time.Now().AddDate(0, -3, 0).UTC()
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").WithArgs("task1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "assignee", "project", "due_at"}).
+			AddRow("task1", "Standup notes", "Not Started", "user1", "project1", due))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO task_series").
+		WithArgs("FREQ=DAILY", entity.RecurOnSchedule, due, upcoming{}, "Standup notes", "", "", "Not Started", "user1", "project1", []byte("{}")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("series1"))
+	mock.ExpectExec("UPDATE tasks SET series_id = \\$1, occurs_at = \\$2 WHERE id = \\$3").
+		WithArgs("series1", due, "task1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	series, err := services.Series.SetRecurrence(context.Background(), "task1", entity.Recurrence{Rule: "FREQ=DAILY", Trigger: entity.RecurOnSchedule})
+	assert.NoError(t, err)
+	assert.True(t, series.NextAt.Time.After(time.Now()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
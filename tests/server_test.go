@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"context"
+	"github.com/Aytya/projects-manager-HL"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestServerRun(t *testing.T) {
+	lis, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when finding a free port", err)
+	}
+	port := strconv.Itoa(lis.Addr().(*net.TCPAddr).Port)
+	lis.Close()
+
+	server := projects_manager.NewServer(port, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Run()
+	}()
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get("http://localhost:" + port); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+	}
+
+	assert.NoError(t, server.Shutdown(context.Background()))
+	assert.NoError(t, <-done)
+}
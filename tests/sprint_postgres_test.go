@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Aytya/projects-manager-HL/internal/entity"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"testing"
+	"time"
+)
+
+func TestStartSprint(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+
+	tests := []struct {
+		name    string
+		result  func(*sqlmock.ExpectedExec)
+		wantErr error
+	}{
+		{
+			name:   "success",
+			result: func(exec *sqlmock.ExpectedExec) { exec.WillReturnResult(sqlmock.NewResult(0, 1)) },
+		},
+		{
+			name: "error - another sprint started meanwhile",
+			result: func(exec *sqlmock.ExpectedExec) {
+				exec.WillReturnError(&pq.Error{Code: "23505", Constraint: "sprints_active_idx"})
+			},
+			wantErr: service.ErrSprintState,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectQuery("SELECT \\* FROM sprints WHERE id = \\$1").WithArgs("sprint1").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "project", "state"}).AddRow("sprint1", "project1", entity.SprintPlanned))
+			mock.ExpectQuery("SELECT \\* FROM sprints WHERE project = \\$1").WithArgs("project1").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "project", "state"}).AddRow("sprint1", "project1", entity.SprintPlanned))
+			tt.result(mock.ExpectExec("UPDATE sprints SET state = \\$1, started_at = NOW\\(\\) WHERE id = \\$2 AND state = \\$3").
+				WithArgs(entity.SprintActive, "sprint1", entity.SprintPlanned))
+
+			err := services.Sprint.StartSprint(context.Background(), "sprint1")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestCloseSprint(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewSprintPostgres(db)
+	next := sql.NullString{String: "sprint2", Valid: true}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int64
+		wantErr error
+	}{
+		{
+			name: "success",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE sprints SET state = \\$1, closed_at = NOW\\(\\) WHERE id = \\$2 AND state = \\$3").
+					WithArgs(entity.SprintClosed, "sprint1", entity.SprintActive).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE tasks SET sprint_id = \\$1 WHERE sprint_id = \\$2 AND finished_at IS NULL").
+					WithArgs(next, "sprint1").
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectCommit()
+			},
+			want: 4,
+		},
+		{
+			name: "error - sprint is not active",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE sprints").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: repository.ErrTaskNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.CloseSprint(context.Background(), "sprint1", next)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestAddSprintTasks(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewSprintPostgres(db)
+	ids := []string{"task1", "task2"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int64
+		wantErr bool
+	}{
+		{
+			name: "success",
+			mock: func() {
+				mock.ExpectExec("UPDATE tasks SET sprint_id = \\$1 WHERE id = ANY\\(\\$2\\) AND project = \\$3").
+					WithArgs("sprint1", pq.Array(ids), "project1").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			want: 2,
+		},
+		{
+			name: "error - database error",
+			mock: func() {
+				mock.ExpectExec("UPDATE tasks").WillReturnError(fmt.Errorf("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.AddSprintTasks(context.Background(), "sprint1", "project1", ids)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestSprintReport(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewSprintPostgres(db)
+	start := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 14)
+
+	rows := sqlmock.NewRows([]string{"committed", "added", "removed", "completed", "committed_completed", "added_completed", "remaining"}).
+		AddRow(10, 3, 1, 9, 7, 2, 3)
+	mock.ExpectQuery("WITH membership AS (.|\\n)*FROM sprint_tasks m").
+		WithArgs("sprint1", start, end).
+		WillReturnRows(rows)
+
+	report, err := r.SprintReport(context.Background(), "sprint1", start, end)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, report.Committed)
+	assert.Equal(t, 3, report.Added)
+	assert.Equal(t, 7, report.CommittedCompleted)
+	assert.Equal(t, 3, report.Remaining)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBulkTasksTooManyItems(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	items := strings.Repeat(`{"op": "delete", "id": "92eb5ffe-e0b4-4e1f-a7b4-1cd0a1f2e7a3"},`, 501)
+	expectOrganization(mock)
+	expectMember(mock)
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks/bulk", strings.NewReader(`{"items": [`+strings.TrimSuffix(items, ",")+`]}`))
+	req.Header.Set("X-Organization", "acme")
+	req.Header.Set("X-User-ID", testUserId)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "at most 500 items")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
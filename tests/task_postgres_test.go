@@ -1,10 +1,12 @@
 package tests
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
 	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	sqlmock "github.com/zhashkevych/go-sqlxmock"
 	"testing"
@@ -48,7 +50,7 @@ func TestCreateTask(t *testing.T) {
 			name: "error - empty title",
 			mock: func() {
 				mock.ExpectQuery("INSERT INTO tasks").WithArgs(
-					"", "Create unit tests for the authentication module.", "High", "Not Started", "user1", "project1",
+					"", "Create unit tests for the authentication module.", "High", "Not Started", "user1", "project1", sql.NullTime{}, []byte("{}"),
 				).WillReturnError(fmt.Errorf("ERROR: null value in column \"title\" violates not-null constraint"))
 			},
 			input: entity.Task{
@@ -82,7 +84,7 @@ func TestCreateTask(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.mock()
 
-			got, _, err := r.CreateTask(tc.input)
+			got, _, err := r.CreateTask(context.Background(), tc.input)
 			if (err != nil) != tc.wantErr {
 				t.Errorf("CreateTask() error = %v, wantErr %v", err, tc.wantErr)
 			} else {
@@ -151,7 +153,7 @@ func TestUpdateTask(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			err := r.UpdateTask(tt.input.ID, tt.input)
+			err := r.UpdateTask(context.Background(), tt.input.ID, tt.input)
 			if tt.wantErr {
 				assert.Error(t, err)
 			}
@@ -209,7 +211,7 @@ func TestGetTaskById(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.mock()
 
-			got, err := r.GetTaskById(tc.id)
+			got, err := r.GetTaskById(context.Background(), tc.id)
 			if (err != nil) != tc.wantErr {
 				t.Errorf("GetTaskById() error = %v, wantErr %v", err, tc.wantErr)
 			}
@@ -262,7 +264,7 @@ func TestDeleteTask(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.mock()
 
-			err := r.DeleteTask(tc.id)
+			err := r.DeleteTask(context.Background(), tc.id)
 			if (err != nil) != tc.wantErr {
 				t.Errorf("DeleteTask() error = %v, wantErr %v", err, tc.wantErr)
 			}
@@ -295,7 +297,8 @@ func TestGetAllTasks(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"id", "title", "description", "priority", "status", "assignee", "project", "created_at", "finished_at"}).
 					AddRow(1, "Write Unit Tests 1", "Create unit tests for the authentication module.", "High", "Not Started", "user1", "project1", time.Now(), nil).
 					AddRow(2, "Write Unit Tests 2", "Create unit tests for the project management module.", "Medium", "In Progress", "user2", "project2", time.Now(), nil)
-				mock.ExpectQuery("SELECT \\* FROM tasks").WillReturnRows(rows)
+				mock.ExpectQuery("SELECT \\* FROM tasks WHERE project IN \\(SELECT id FROM projects WHERE organization = \\$1 AND NOT is_template AND finished_at IS NULL\\)").
+					WithArgs("org1").WillReturnRows(rows)
 			},
 			want: []entity.Task{
 				{
@@ -334,7 +337,7 @@ func TestGetAllTasks(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			got, err := r.GetAllTasks()
+			got, err := r.GetAllTasks(context.Background(), "org1", false)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -357,3 +360,263 @@ func TestGetAllTasks(t *testing.T) {
 		})
 	}
 }
+
+func TestBulkTasks(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewTaskPostgres(db)
+
+	items := []entity.BulkTaskItem{
+		{
+			Op: entity.BulkCreate,
+			Task: entity.Task{
+				Title:       "Write Unit Tests 1",
+				Description: "Create unit tests for the authentication module.",
+				Priority:    "High",
+				Status:      "Not Started",
+				Assignee:    "user1",
+				Project:     "project1",
+			},
+		},
+		{Op: entity.BulkDelete, ID: "2"},
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		atomic  bool
+		want    []entity.BulkTaskResult
+		wantErr bool
+	}{
+		{
+			name: "atomic success",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO tasks").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("1", time.Now()))
+				mock.ExpectExec("DELETE FROM tasks WHERE id = \\$1").
+					WithArgs("2").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			atomic: true,
+			want: []entity.BulkTaskResult{
+				{Index: 0, Op: entity.BulkCreate, ID: "1"},
+				{Index: 1, Op: entity.BulkDelete, ID: "2"},
+			},
+		},
+		{
+			name: "atomic rollback on missing task",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO tasks").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("1", time.Now()))
+				mock.ExpectExec("DELETE FROM tasks WHERE id = \\$1").
+					WithArgs("2").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			atomic:  true,
+			wantErr: true,
+		},
+		{
+			name: "per-item keeps going after failure",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("INSERT INTO tasks").WillReturnError(fmt.Errorf("insert failed"))
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM tasks WHERE id = \\$1").
+					WithArgs("2").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("RELEASE SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			atomic: false,
+			want: []entity.BulkTaskResult{
+				{Index: 0, Op: entity.BulkCreate, Error: "insert failed"},
+				{Index: 1, Op: entity.BulkDelete, ID: "2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.BulkTasks(context.Background(), items, tt.atomic)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestReassignTasks(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewTaskPostgres(db)
+
+	mock.ExpectExec("UPDATE tasks SET assignee = \\$1 WHERE assignee = \\$2 AND finished_at IS NULL "+
+		"AND project IN \\(SELECT id FROM projects WHERE organization = \\$3\\) AND project = \\$4").
+		WithArgs("user2", "user1", "org1", "project1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	got, err := r.ReassignTasks(context.Background(), "org1", "user1", entity.ReassignTasks{Assignee: "user2", Project: "project1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), got)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateTasks(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewTaskPostgres(db)
+
+	input := []entity.Task{
+		{Title: "Write Unit Tests 1", Description: "auth", Priority: "High", Status: "Not Started", Assignee: "user1", Project: "project1"},
+		{Title: "Write Unit Tests 2", Description: "projects", Priority: "Medium", Status: "In Progress", Assignee: "user2", Project: "project1"},
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "success",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO tasks").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+				mock.ExpectQuery("INSERT INTO tasks").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("2"))
+				mock.ExpectCommit()
+			},
+			want: []string{"1", "2"},
+		},
+		{
+			name: "error - rolls back",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO tasks").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+				mock.ExpectQuery("INSERT INTO tasks").WillReturnError(fmt.Errorf("some error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.CreateTasks(context.Background(), input)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewTaskPostgres(db)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("SET LOCAL pm.rank_placed = 'on'").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("UPDATE tasks SET status = \\$1, rank = \\$2 WHERE id = \\$3").
+					WithArgs("In Progress", "i", "1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "error - rank taken by a concurrent move",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("SET LOCAL pm.rank_placed").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("UPDATE tasks SET status").WillReturnError(&pq.Error{Code: "23505"})
+				mock.ExpectRollback()
+			},
+			wantErr: repository.ErrRankTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := r.MoveTask(context.Background(), "1", "In Progress", "i")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRebalanceColumn(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewTaskPostgres(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SET CONSTRAINTS tasks_rank_key DEFERRED").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id FROM tasks WHERE project = \\$1 AND status = \\$2 ORDER BY rank NULLS LAST, created_at, id FOR UPDATE").
+		WithArgs("project1", "Not Started").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+	mock.ExpectExec("UPDATE tasks SET rank = \\$1 WHERE id = \\$2").WithArgs("c", "1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE tasks SET rank = \\$1 WHERE id = \\$2").WithArgs("o", "2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, r.RebalanceColumn(context.Background(), "project1", "Not Started"))
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"errors"
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExportProjectTasks(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	projectId := "92eb5ffe-e0b4-4e1f-a7b4-1cd0a1f2e7a3"
+	project := func() {
+		expectOrganization(mock)
+		expectMember(mock)
+		mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM projects WHERE organization = \\$1 AND id = \\$2\\)").
+			WithArgs("org1", projectId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery("SELECT (.+) FROM projects WHERE id = \\$1").WithArgs(projectId).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "manager"}).AddRow(projectId, "Website", testUserId))
+	}
+
+	tests := []struct {
+		name        string
+		mock        func()
+		status      int
+		contentType string
+		body        string
+	}{
+		{
+			name: "Query fails",
+			mock: func() {
+				project()
+				mock.ExpectQuery("FROM tasks t JOIN users u").WithArgs(projectId).WillReturnError(errors.New("connection reset"))
+			},
+			status:      http.StatusInternalServerError,
+			contentType: "application/json; charset=utf-8",
+			body:        "Failed to export tasks",
+		},
+		{
+			name: "Formula is escaped",
+			mock: func() {
+				project()
+				mock.ExpectQuery("FROM tasks t JOIN users u").WithArgs(projectId).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "priority", "status", "assignee", "created_at", "assignee_name", "assignee_email"}).
+						AddRow("1", "=HYPERLINK(\"http://evil\")", "-1+2", "High", "Open", testUserId, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), "@john", "john@example.com"))
+			},
+			status:      http.StatusOK,
+			contentType: "text/csv",
+			body:        "1,\"'=HYPERLINK(\"\"http://evil\"\")\",'-1+2,High,Open," + testUserId + ",'@john,john@example.com,2024-05-01T00:00:00Z,,\n",
+		},
+		{
+			name: "Tab and carriage return are escaped",
+			mock: func() {
+				project()
+				mock.ExpectQuery("FROM tasks t JOIN users u").WithArgs(projectId).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "priority", "status", "assignee", "created_at", "assignee_name", "assignee_email"}).
+						AddRow("1", "\t=1+2", "\r=1+2", "High", "Open", testUserId, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), "John", "john@example.com"))
+			},
+			status:      http.StatusOK,
+			contentType: "text/csv",
+			body:        "1,'\t=1+2,\"'\r=1+2\",High,Open,",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			req := httptest.NewRequest(http.MethodGet, "/projects/"+projectId+"/tasks/export", nil)
+			req.Header.Set("X-Organization", "acme")
+			req.Header.Set("X-User-ID", testUserId)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Body.String(), tt.body)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
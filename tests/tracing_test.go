@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Aytya/projects-manager-HL/internal/graph"
+	"github.com/Aytya/projects-manager-HL/internal/handler"
+	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/Aytya/projects-manager-HL/internal/service"
+	"github.com/Aytya/projects-manager-HL/internal/tracing"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestTracing(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(provider)
+
+	_, err = tracing.Setup(context.Background(), tracing.Config{})
+	assert.NoError(t, err)
+
+	services := service.NewService(repository.NewRepository(db), nil, service.EmailConfig{})
+	router := handler.NewHandler(services, "", graph.Limits{}, slog.New(slog.NewTextHandler(io.Discard, nil))).InitRoutes()
+
+	expectOrganization(mock)
+	expectMember(mock)
+	mock.ExpectQuery("SELECT \\* FROM users WHERE").WithArgs("org1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+	req.Header.Set("X-Organization", "acme")
+	req.Header.Set("X-User-ID", testUserId)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+		spans[span.Name()] = span
+	}
+
+	request, ok := spans["/users/"]
+	if assert.True(t, ok, "no span for the request") {
+		assert.Equal(t, "00f067aa0ba902b7", request.Parent().SpanID().String())
+	}
+	call, ok := spans["UserService.GetAllUsers"]
+	if assert.True(t, ok, "no span for the service call") {
+		assert.Equal(t, request.SpanContext().SpanID(), call.Parent().SpanID())
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQuerySpanNames(t *testing.T) {
+	stub, mock, err := sqlmock.NewWithDSN("query-span-names")
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer stub.Close()
+
+	sql.Register("sqlmock-instrumented", repository.Instrument(stub.Driver()))
+	db, err := sqlx.Open("sqlmock-instrumented", "query-span-names")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(provider)
+
+	_, err = tracing.Setup(context.Background(), tracing.Config{})
+	assert.NoError(t, err)
+
+	tasks := repository.NewTaskPostgres(db)
+	mock.ExpectQuery("SELECT \\* FROM tasks WHERE project = \\$1").WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT \\* FROM tasks WHERE title = \\$1").WithArgs("Task", "org1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+
+	// GetTasksByProjectId has a value receiver, GetTaskByTitle a pointer one.
+	_, err = tasks.GetTasksByProjectId(context.Background(), "1")
+	assert.NoError(t, err)
+	_, err = tasks.GetTaskByTitle(context.Background(), "org1", "Task")
+	assert.NoError(t, err)
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+	}
+	assert.Equal(t, []string{"TaskPostgres.GetTasksByProjectId", "TaskPostgres.GetTaskByTitle"}, names)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
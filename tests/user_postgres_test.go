@@ -1,9 +1,11 @@
 package tests
 
 import (
+	"context"
 	"fmt"
 	"github.com/Aytya/projects-manager-HL/internal/entity"
 	"github.com/Aytya/projects-manager-HL/internal/repository"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	sqlmock "github.com/zhashkevych/go-sqlxmock"
 	"testing"
@@ -30,7 +32,8 @@ func TestCreateUser(t *testing.T) {
 			name: "ok",
 			mock: func() {
 				rows := sqlmock.NewRows([]string{"id", "registered_at"}).AddRow(1, time.Now())
-				mock.ExpectQuery("INSERT INTO users").WillReturnRows(rows)
+				mock.ExpectQuery("INSERT INTO users \\(name, email, role\\)(.+)INSERT INTO memberships").
+					WithArgs("Test User", "test@test.com", "User", "org1", entity.OrgRoleMember).WillReturnRows(rows)
 			},
 			input: entity.User{
 				Name:  "Test User",
@@ -55,7 +58,7 @@ func TestCreateUser(t *testing.T) {
 		{
 			name: "error - database error",
 			mock: func() {
-				mock.ExpectQuery("INSERT INTO users").WithArgs("Test User", "test@test.com", "").WillReturnError(fmt.Errorf("some error"))
+				mock.ExpectQuery("INSERT INTO users").WithArgs("Test User", "test@test.com", "", "org1", entity.OrgRoleMember).WillReturnError(fmt.Errorf("some error"))
 			},
 			input: entity.User{
 				Name:  "Test User",
@@ -69,7 +72,7 @@ func TestCreateUser(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			got, _, err := r.CreateUser(tt.input)
+			got, _, err := r.CreateUser(context.Background(), "org1", tt.input)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -84,6 +87,38 @@ func TestCreateUser(t *testing.T) {
 	}
 }
 
+func TestCreateUserExists(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewUserPostgres(db)
+	input := entity.User{Name: "Test User", Email: "test@test.com", Role: "User"}
+
+	mock.ExpectQuery("SELECT id, registered_at FROM users WHERE email = \\$2(.+)ON CONFLICT DO NOTHING").
+		WithArgs("Test User", "test@test.com", "User", "org2", entity.OrgRoleMember).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "registered_at"}).AddRow("user1", time.Now()))
+	got, _, err := r.CreateUser(context.Background(), "org2", input)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", got)
+
+	mock.ExpectQuery("INSERT INTO memberships").
+		WithArgs("Test User", "test@test.com", "User", "org1", entity.OrgRoleMember).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "registered_at"}))
+	_, _, err = r.CreateUser(context.Background(), "org1", input)
+	assert.ErrorIs(t, err, repository.ErrUserExists)
+
+	mock.ExpectQuery("INSERT INTO memberships").
+		WithArgs("Test User", "test@test.com", "User", "org1", entity.OrgRoleMember).
+		WillReturnError(&pq.Error{Code: "23505"})
+	_, _, err = r.CreateUser(context.Background(), "org1", input)
+	assert.ErrorIs(t, err, repository.ErrUserExists)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUpdateUser(t *testing.T) {
 	db, mock, err := sqlmock.Newx()
 	if err != nil {
@@ -119,7 +154,7 @@ func TestUpdateUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			err := r.UpdateUser(tt.input.ID, tt.input)
+			err := r.UpdateUser(context.Background(), tt.input.ID, tt.input)
 			if tt.wantErr {
 				assert.Error(t, err)
 			}
@@ -172,7 +207,7 @@ func TestGetUserById(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			got, err := r.GetUserById(tt.id)
+			got, err := r.GetUserById(context.Background(), tt.id)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -223,7 +258,7 @@ func TestDeleteUser(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			err := r.DeleteUser(tt.id)
+			err := r.DeleteUser(context.Background(), tt.id)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -258,7 +293,8 @@ func TestGetAllUsers(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"id", "name", "email", "role"}).
 					AddRow(1, "User1", "user1@test.com", "Admin").
 					AddRow(2, "User2", "user2@test.com", "User")
-				mock.ExpectQuery("SELECT \\* FROM users").WillReturnRows(rows)
+				mock.ExpectQuery("SELECT \\* FROM users WHERE id IN \\(SELECT user_id FROM memberships WHERE organization = \\$1\\)").
+					WithArgs("org1").WillReturnRows(rows)
 			},
 			want: []entity.User{
 				{ID: "1", Name: "User1", Email: "user1@test.com", Role: "Admin"},
@@ -278,7 +314,7 @@ func TestGetAllUsers(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			got, err := r.GetAllUsers()
+			got, err := r.GetAllUsers(context.Background(), "org1")
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {